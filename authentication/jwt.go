@@ -1,10 +1,11 @@
 package authentication
 
 import (
+	"crypto/rand"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"net/http"
-	"strconv"
 	"strings"
 	"time"
 
@@ -12,31 +13,54 @@ import (
 	"github.com/waliqueiroz/devbook-api/config"
 )
 
-// CreateToken generates a new json web token for a given user
-func CreateToken(userID uint64) (string, error) {
+var defaultScopes = []string{"api"}
+
+// CreateToken generates a new short-lived json web token for a given user session
+func CreateToken(userID, sessionID uint64) (string, error) {
+	token, _, err := CreateTokenWithExpiration(userID, sessionID)
+	return token, err
+}
+
+// CreateTokenWithExpiration generates a token like CreateToken and also returns when it expires
+func CreateTokenWithExpiration(userID, sessionID uint64) (string, time.Time, error) {
+	expiresAt := time.Now().Add(config.AccessTokenDuration)
+
+	tokenID, err := randomToken(16)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
 	permissions := jwt.MapClaims{}
 	permissions["authorized"] = true
-	permissions["exp"] = time.Now().Add(time.Hour * 6).Unix()
+	permissions["exp"] = expiresAt.Unix()
+	permissions["jti"] = tokenID
 	permissions["userID"] = userID
+	permissions["sessionID"] = sessionID
+	permissions["scopes"] = defaultScopes
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, permissions)
 
-	return token.SignedString(config.SecretKey)
-}
-
-// ValidateToken verify if a given jwt token is valid
-func ValidateToken(r *http.Request) error {
-	tokenString := extractToken(r)
-	token, err := jwt.Parse(tokenString, getVerificationKey)
+	signedToken, err := token.SignedString(config.SecretKey)
 	if err != nil {
-		return err
+		return "", time.Time{}, err
 	}
 
-	if _, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		return nil
+	return signedToken, time.Unix(expiresAt.Unix(), 0), nil
+}
+
+// CreateRefreshToken generates a new random opaque refresh token
+func CreateRefreshToken() (string, error) {
+	return randomToken(32)
+}
+
+func randomToken(size int) (string, error) {
+	randomBytes := make([]byte, size)
+
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", err
 	}
 
-	return errors.New("invalid token")
+	return base64.RawURLEncoding.EncodeToString(randomBytes), nil
 }
 
 func extractToken(r *http.Request) string {
@@ -59,22 +83,64 @@ func getVerificationKey(token *jwt.Token) (interface{}, error) {
 	return config.SecretKey, nil
 }
 
-// ExtractUserID returns the user id that is saved in the token
-func ExtractUserID(r *http.Request) (uint64, error) {
+// ParseToken verifies the json web token of a request and returns the identity it carries
+func ParseToken(r *http.Request) (Principal, error) {
 	tokenString := extractToken(r)
 	token, err := jwt.Parse(tokenString, getVerificationKey)
 	if err != nil {
-		return 0, err
+		return Principal{}, err
 	}
 
-	if permissions, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		userID, err := strconv.ParseUint(fmt.Sprintf("%.0f", permissions["userID"]), 10, 64)
-		if err != nil {
-			return 0, err
+	permissions, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return Principal{}, errors.New("invalid token")
+	}
+
+	// Tokens issued for a purpose, like opening the stream, are signed with the same key but are not access tokens
+	if _, ok := permissions["purpose"]; ok {
+		return Principal{}, errors.New("not an access token")
+	}
+
+	userID, err := numericClaim(permissions, "userID")
+	if err != nil {
+		return Principal{}, err
+	}
+
+	sessionID, err := numericClaim(permissions, "sessionID")
+	if err != nil {
+		return Principal{}, err
+	}
+
+	expiresAt, err := numericClaim(permissions, "exp")
+	if err != nil {
+		return Principal{}, err
+	}
+
+	tokenID, _ := permissions["jti"].(string)
+
+	var scopes []string
+	if claimedScopes, ok := permissions["scopes"].([]interface{}); ok {
+		for _, scope := range claimedScopes {
+			if scope, ok := scope.(string); ok {
+				scopes = append(scopes, scope)
+			}
 		}
+	}
+
+	return Principal{
+		UserID:    userID,
+		SessionID: sessionID,
+		TokenID:   tokenID,
+		Scopes:    scopes,
+		ExpiresAt: time.Unix(int64(expiresAt), 0),
+	}, nil
+}
 
-		return userID, nil
+func numericClaim(permissions jwt.MapClaims, claim string) (uint64, error) {
+	value, ok := permissions[claim].(float64)
+	if !ok || value < 0 {
+		return 0, fmt.Errorf("invalid %s claim", claim)
 	}
 
-	return 0, errors.New("invalid token")
+	return uint64(value), nil
 }
@@ -0,0 +1,53 @@
+package authentication
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// ErrUnauthenticated is returned when a request does not carry an authenticated identity
+var ErrUnauthenticated = errors.New("the request is not authenticated")
+
+// Principal represents the authenticated identity of a request
+type Principal struct {
+	UserID    uint64
+	SessionID uint64
+	TokenID   string
+	Scopes    []string
+	ExpiresAt time.Time
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of a context carrying a given identity
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the identity stored in a context, if there is one
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
+
+// ExtractUserID returns the id of the user that authenticated the request
+func ExtractUserID(r *http.Request) (uint64, error) {
+	principal, ok := PrincipalFromContext(r.Context())
+	if !ok {
+		return 0, ErrUnauthenticated
+	}
+
+	return principal.UserID, nil
+}
+
+// ExtractSessionID returns the id of the session that authenticated the request
+func ExtractSessionID(r *http.Request) (uint64, error) {
+	principal, ok := PrincipalFromContext(r.Context())
+	if !ok {
+		return 0, ErrUnauthenticated
+	}
+
+	return principal.SessionID, nil
+}
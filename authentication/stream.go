@@ -0,0 +1,86 @@
+package authentication
+
+import (
+	"errors"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/waliqueiroz/devbook-api/config"
+)
+
+// streamPurpose sets stream tokens apart from access tokens, which are signed with the same key
+const streamPurpose = "stream"
+
+// CreateStreamToken generates a short-lived token that opens the event stream on behalf of an authenticated identity,
+// for clients like the browser EventSource that cannot send the Authorization header. It only opens streams until it
+// expires, while the streams it opens last as long as the access token it was created with
+func CreateStreamToken(principal Principal) (string, time.Time, error) {
+	expiresAt := time.Now().Add(config.StreamTokenDuration)
+	if expiresAt.After(principal.ExpiresAt) {
+		expiresAt = principal.ExpiresAt
+	}
+
+	tokenID, err := randomToken(16)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	permissions := jwt.MapClaims{}
+	permissions["purpose"] = streamPurpose
+	permissions["exp"] = expiresAt.Unix()
+	permissions["jti"] = tokenID
+	permissions["userID"] = principal.UserID
+	permissions["sessionID"] = principal.SessionID
+	permissions["accessExp"] = principal.ExpiresAt.Unix()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, permissions)
+
+	signedToken, err := token.SignedString(config.SecretKey)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return signedToken, time.Unix(expiresAt.Unix(), 0), nil
+}
+
+// ParseStreamToken verifies the signature and the expiration of a stream token and returns the identity it carries,
+// which expires along with the access token the stream token was created with
+func ParseStreamToken(tokenString string) (Principal, error) {
+	token, err := jwt.Parse(tokenString, getVerificationKey)
+	if err != nil {
+		return Principal{}, err
+	}
+
+	permissions, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return Principal{}, errors.New("invalid token")
+	}
+
+	if purpose, _ := permissions["purpose"].(string); purpose != streamPurpose {
+		return Principal{}, errors.New("not a stream token")
+	}
+
+	userID, err := numericClaim(permissions, "userID")
+	if err != nil {
+		return Principal{}, err
+	}
+
+	sessionID, err := numericClaim(permissions, "sessionID")
+	if err != nil {
+		return Principal{}, err
+	}
+
+	accessExpiresAt, err := numericClaim(permissions, "accessExp")
+	if err != nil {
+		return Principal{}, err
+	}
+
+	tokenID, _ := permissions["jti"].(string)
+
+	return Principal{
+		UserID:    userID,
+		SessionID: sessionID,
+		TokenID:   tokenID,
+		ExpiresAt: time.Unix(int64(accessExpiresAt), 0),
+	}, nil
+}
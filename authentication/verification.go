@@ -0,0 +1,57 @@
+package authentication
+
+import (
+	"errors"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/waliqueiroz/devbook-api/config"
+)
+
+// verificationPurpose sets verification tokens apart from access tokens, which are signed with the same key
+const verificationPurpose = "email_verification"
+
+// CreateVerificationToken generates a signed token that confirms the email of a given user, and returns when it expires.
+// The signature only proves the token was issued by the API, the verification repository makes it single-use
+func CreateVerificationToken(userID uint64) (string, time.Time, error) {
+	expiresAt := time.Now().Add(config.EmailVerificationDuration)
+
+	tokenID, err := randomToken(16)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	permissions := jwt.MapClaims{}
+	permissions["purpose"] = verificationPurpose
+	permissions["exp"] = expiresAt.Unix()
+	permissions["jti"] = tokenID
+	permissions["userID"] = userID
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, permissions)
+
+	signedToken, err := token.SignedString(config.SecretKey)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return signedToken, time.Unix(expiresAt.Unix(), 0), nil
+}
+
+// ParseVerificationToken verifies the signature and the expiration of a verification token and returns the user it belongs to
+func ParseVerificationToken(tokenString string) (uint64, error) {
+	token, err := jwt.Parse(tokenString, getVerificationKey)
+	if err != nil {
+		return 0, err
+	}
+
+	permissions, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return 0, errors.New("invalid token")
+	}
+
+	if purpose, _ := permissions["purpose"].(string); purpose != verificationPurpose {
+		return 0, errors.New("not a verification token")
+	}
+
+	return numericClaim(permissions, "userID")
+}
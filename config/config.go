@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -15,6 +16,45 @@ var DBUsername = ""
 var DBPassword = ""
 var APIPort = 0
 var SecretKey []byte
+var AccessTokenDuration = 15 * time.Minute
+var RefreshTokenDuration = 30 * 24 * time.Hour
+var StreamHeartbeatInterval = 15 * time.Second
+var StreamTokenDuration = time.Minute
+
+// Replies deeper than MaxThreadDepth below a post are left out of its thread. MySQL stops recursive queries at 1000 levels
+var MaxThreadDepth = 100
+var MigrateOnStart = false
+var LogFormat = "json"
+var LogLevel = "info"
+var DBQueryTimeout = 5 * time.Second
+var HTTPReadTimeout = 15 * time.Second
+var HTTPReadHeaderTimeout = 5 * time.Second
+var HTTPWriteTimeout = 30 * time.Second
+var HTTPIdleTimeout = 120 * time.Second
+var ShutdownGracePeriod = 30 * time.Second
+var ShutdownDrainDelay = 5 * time.Second
+var MaxBodyBytes int64 = 1 << 20
+var EmailVerificationDuration = 24 * time.Hour
+var VerificationResendCooldown = time.Minute
+var VerificationURL = "http://localhost:3000/verify"
+
+// Emails are written to MailLogFile, or to the standard output when it is empty, unless MailDriver is smtp
+var MailDriver = "log"
+var MailLogFile = ""
+var MailFrom = "DevBook <no-reply@devbook.local>"
+var SMTPHost = ""
+var SMTPPort = 587
+var SMTPUsername = ""
+var SMTPPassword = ""
+var SMTPTimeout = 10 * time.Second
+
+// Maximum number of characters of each field. They cannot be set above the size of their columns
+var MaxNameLength = 50
+var MaxNickLength = 30
+var MaxEmailLength = 50
+var MaxTitleLength = 255
+var MaxPostContentLength = 5000
+var MaxCommentLength = 2000
 
 func Load() {
 	var err error
@@ -39,4 +79,127 @@ func Load() {
 
 	SecretKey = []byte(os.Getenv("SECRET_KEY"))
 
+	if minutes, err := strconv.Atoi(os.Getenv("ACCESS_TOKEN_MINUTES")); err == nil {
+		AccessTokenDuration = time.Duration(minutes) * time.Minute
+	}
+
+	if hours, err := strconv.Atoi(os.Getenv("REFRESH_TOKEN_HOURS")); err == nil {
+		RefreshTokenDuration = time.Duration(hours) * time.Hour
+	}
+
+	if seconds, err := strconv.Atoi(os.Getenv("STREAM_HEARTBEAT_SECONDS")); err == nil {
+		StreamHeartbeatInterval = time.Duration(seconds) * time.Second
+	}
+
+	if seconds, err := strconv.Atoi(os.Getenv("STREAM_TOKEN_SECONDS")); err == nil && seconds > 0 {
+		StreamTokenDuration = time.Duration(seconds) * time.Second
+	}
+
+	if depth, err := strconv.Atoi(os.Getenv("MAX_THREAD_DEPTH")); err == nil && depth > 0 && depth < 1000 {
+		MaxThreadDepth = depth
+	}
+
+	if seconds, err := strconv.Atoi(os.Getenv("DB_QUERY_TIMEOUT_SECONDS")); err == nil {
+		DBQueryTimeout = time.Duration(seconds) * time.Second
+	}
+
+	if seconds, err := strconv.Atoi(os.Getenv("HTTP_READ_TIMEOUT_SECONDS")); err == nil {
+		HTTPReadTimeout = time.Duration(seconds) * time.Second
+	}
+
+	if seconds, err := strconv.Atoi(os.Getenv("HTTP_READ_HEADER_TIMEOUT_SECONDS")); err == nil {
+		HTTPReadHeaderTimeout = time.Duration(seconds) * time.Second
+	}
+
+	if seconds, err := strconv.Atoi(os.Getenv("HTTP_WRITE_TIMEOUT_SECONDS")); err == nil {
+		HTTPWriteTimeout = time.Duration(seconds) * time.Second
+	}
+
+	if seconds, err := strconv.Atoi(os.Getenv("HTTP_IDLE_TIMEOUT_SECONDS")); err == nil {
+		HTTPIdleTimeout = time.Duration(seconds) * time.Second
+	}
+
+	if seconds, err := strconv.Atoi(os.Getenv("SHUTDOWN_GRACE_SECONDS")); err == nil {
+		ShutdownGracePeriod = time.Duration(seconds) * time.Second
+	}
+
+	if seconds, err := strconv.Atoi(os.Getenv("SHUTDOWN_DRAIN_SECONDS")); err == nil && seconds >= 0 {
+		ShutdownDrainDelay = time.Duration(seconds) * time.Second
+	}
+
+	if format := os.Getenv("LOG_FORMAT"); format != "" {
+		LogFormat = format
+	}
+
+	if level := os.Getenv("LOG_LEVEL"); level != "" {
+		LogLevel = level
+	}
+
+	if migrate, err := strconv.ParseBool(os.Getenv("MIGRATE_ON_START")); err == nil {
+		MigrateOnStart = migrate
+	}
+
+	if bytes, err := strconv.ParseInt(os.Getenv("MAX_BODY_BYTES"), 10, 64); err == nil {
+		MaxBodyBytes = bytes
+	}
+
+	if hours, err := strconv.Atoi(os.Getenv("EMAIL_VERIFICATION_HOURS")); err == nil {
+		EmailVerificationDuration = time.Duration(hours) * time.Hour
+	}
+
+	if seconds, err := strconv.Atoi(os.Getenv("VERIFICATION_RESEND_COOLDOWN_SECONDS")); err == nil && seconds >= 0 {
+		VerificationResendCooldown = time.Duration(seconds) * time.Second
+	}
+
+	if url := os.Getenv("VERIFICATION_URL"); url != "" {
+		VerificationURL = url
+	}
+
+	if driver := os.Getenv("MAIL_DRIVER"); driver != "" {
+		MailDriver = driver
+	}
+
+	MailLogFile = os.Getenv("MAIL_LOG_FILE")
+
+	if from := os.Getenv("MAIL_FROM"); from != "" {
+		MailFrom = from
+	}
+
+	SMTPHost = os.Getenv("SMTP_HOST")
+	SMTPUsername = os.Getenv("SMTP_USERNAME")
+	SMTPPassword = os.Getenv("SMTP_PASSWORD")
+
+	if port, err := strconv.Atoi(os.Getenv("SMTP_PORT")); err == nil {
+		SMTPPort = port
+	}
+
+	if seconds, err := strconv.Atoi(os.Getenv("SMTP_TIMEOUT_SECONDS")); err == nil && seconds > 0 {
+		SMTPTimeout = time.Duration(seconds) * time.Second
+	}
+
+	loadLength("MAX_NAME_LENGTH", &MaxNameLength, 50)
+	loadLength("MAX_NICK_LENGTH", &MaxNickLength, 50)
+	loadLength("MAX_EMAIL_LENGTH", &MaxEmailLength, 50)
+	loadLength("MAX_TITLE_LENGTH", &MaxTitleLength, 255)
+	loadLength("MAX_POST_CONTENT_LENGTH", &MaxPostContentLength, maxTextLength)
+	loadLength("MAX_COMMENT_LENGTH", &MaxCommentLength, maxTextLength)
+
+}
+
+// maxTextLength is how many characters always fit in a text column, which holds 65535 bytes of up to 4 bytes each
+const maxTextLength = 16383
+
+// loadLength reads a maximum length from an environment variable, keeping the default when it is not a positive number.
+// A length above the size of the column would pass validation and then fail on insert, so it stops the API from starting
+func loadLength(name string, length *int, columnSize int) {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || value <= 0 {
+		return
+	}
+
+	if value > columnSize {
+		log.Fatalf("%s must be at most %d, the size of its column", name, columnSize)
+	}
+
+	*length = value
 }
@@ -4,58 +4,189 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
+	"time"
 
 	"github.com/waliqueiroz/devbook-api/authentication"
+	"github.com/waliqueiroz/devbook-api/config"
 	"github.com/waliqueiroz/devbook-api/interfaces"
+	"github.com/waliqueiroz/devbook-api/metrics"
 	"github.com/waliqueiroz/devbook-api/model"
 	"github.com/waliqueiroz/devbook-api/response"
 	"github.com/waliqueiroz/devbook-api/security"
 )
 
+const tokenType = "Bearer"
+
 type AuthController struct {
-	userRepository interfaces.UserRepository
+	userRepository    interfaces.UserRepository
+	sessionRepository interfaces.SessionRepository
 }
 
 // NewAuthController creates a new AuthController
-func NewAuthController(userRepository interfaces.UserRepository) *AuthController {
+func NewAuthController(userRepository interfaces.UserRepository, sessionRepository interfaces.SessionRepository) *AuthController {
 	return &AuthController{
 		userRepository,
+		sessionRepository,
 	}
 }
 
-// Login authenticates an user
+// Login authenticates an user whose email was verified. Clients that accept application/json receive the tokens and the user profile.
+// Legacy clients receive the access token as plain text and the refresh token in the X-Refresh-Token header
 func (controller AuthController) Login(w http.ResponseWriter, r *http.Request) {
 	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
-		response.Error(w, http.StatusUnprocessableEntity, err)
+		response.Error(w, r, http.StatusUnprocessableEntity, model.NewUnreadableBodyError(err))
 		return
 	}
 
 	var user model.User
 	err = json.Unmarshal(body, &user)
 	if err != nil {
-		response.Error(w, http.StatusBadRequest, err)
+		response.Error(w, r, http.StatusBadRequest, model.NewInvalidBodyError(err))
 		return
 	}
 
-	storedUser, err := controller.userRepository.FindByEmail(user.Email)
+	storedUser, err := controller.userRepository.FindByEmail(r.Context(), model.NormalizeEmail(user.Email))
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		response.Error(w, r, repositoryErrorStatus(err), err)
 		return
 	}
 
 	err = security.Verify(storedUser.Password, user.Password)
 	if err != nil {
-		response.Error(w, http.StatusUnauthorized, err)
+		metrics.Logins.Inc(metrics.LoginFailed)
+		response.Error(w, r, http.StatusUnauthorized, model.NewUnauthorizedError("invalid email or password", err))
+		return
+	}
+
+	if !storedUser.Verified() {
+		metrics.Logins.Inc(metrics.LoginUnverified)
+		response.Error(w, r, http.StatusForbidden, model.NewNotVerifiedError())
+		return
+	}
+
+	refreshToken, err := authentication.CreateRefreshToken()
+	if err != nil {
+		response.Error(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	sessionID, err := controller.sessionRepository.Create(r.Context(), model.Session{
+		UserID:           storedUser.ID,
+		RefreshTokenHash: security.HashToken(refreshToken),
+		ExpiresAt:        time.Now().Add(config.RefreshTokenDuration),
+	})
+	if err != nil {
+		response.Error(w, r, repositoryErrorStatus(err), err)
+		return
+	}
+
+	token, expiresAt, err := authentication.CreateTokenWithExpiration(storedUser.ID, sessionID)
+	if err != nil {
+		response.Error(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	metrics.Logins.Inc(metrics.LoginSucceeded)
+
+	if !response.PrefersJSON(r) {
+		w.Header().Set("X-Refresh-Token", refreshToken)
+		response.Text(w, http.StatusOK, token)
+		return
+	}
+
+	profile, err := controller.userRepository.FindByID(r.Context(), storedUser.ID)
+	if err != nil {
+		response.Error(w, r, repositoryErrorStatus(err), err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, model.Login{
+		AuthTokens: model.AuthTokens{
+			AccessToken:  token,
+			TokenType:    tokenType,
+			ExpiresAt:    expiresAt,
+			RefreshToken: refreshToken,
+		},
+		User: profile,
+	})
+}
+
+// Refresh exchanges a refresh token for a new access token, rotating the refresh token
+func (controller AuthController) Refresh(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		response.Error(w, r, http.StatusUnprocessableEntity, model.NewUnreadableBodyError(err))
+		return
+	}
+
+	var refreshRequest model.RefreshRequest
+	err = json.Unmarshal(body, &refreshRequest)
+	if err != nil {
+		response.Error(w, r, http.StatusBadRequest, model.NewInvalidBodyError(err))
+		return
+	}
+
+	if refreshRequest.RefreshToken == "" {
+		response.Error(w, r, http.StatusBadRequest, model.NewBadRequestError("the refresh token is required"))
+		return
+	}
+
+	currentHash := security.HashToken(refreshRequest.RefreshToken)
+
+	session, err := controller.sessionRepository.FindByRefreshToken(r.Context(), currentHash)
+	if model.IsNotFound(err) {
+		response.Error(w, r, http.StatusUnauthorized, model.NewUnauthorizedError("invalid refresh token", nil))
+		return
+	}
+	if err != nil {
+		response.Error(w, r, repositoryErrorStatus(err), err)
+		return
+	}
+
+	refreshToken, err := authentication.CreateRefreshToken()
+	if err != nil {
+		response.Error(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = controller.sessionRepository.Rotate(r.Context(), session.ID, currentHash, security.HashToken(refreshToken), time.Now().Add(config.RefreshTokenDuration))
+	if model.IsNotFound(err) {
+		response.Error(w, r, http.StatusUnauthorized, model.NewUnauthorizedError("invalid refresh token", nil))
+		return
+	}
+	if err != nil {
+		response.Error(w, r, repositoryErrorStatus(err), err)
+		return
+	}
+
+	token, expiresAt, err := authentication.CreateTokenWithExpiration(session.UserID, session.ID)
+	if err != nil {
+		response.Error(w, r, http.StatusInternalServerError, err)
 		return
 	}
 
-	token, err := authentication.CreateToken(storedUser.ID)
+	response.JSON(w, http.StatusOK, model.AuthTokens{
+		AccessToken:  token,
+		TokenType:    tokenType,
+		ExpiresAt:    expiresAt,
+		RefreshToken: refreshToken,
+	})
+}
+
+// Logout revokes the session of the authenticated user
+func (controller AuthController) Logout(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := authentication.ExtractSessionID(r)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		response.Error(w, r, http.StatusUnauthorized, err)
 		return
 	}
 
-	w.Write([]byte(token))
+	err = controller.sessionRepository.Revoke(r.Context(), sessionID)
+	if err != nil {
+		response.Error(w, r, repositoryErrorStatus(err), err)
+		return
+	}
 
+	response.JSON(w, http.StatusNoContent, nil)
 }
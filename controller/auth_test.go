@@ -2,14 +2,18 @@ package controller_test
 
 import (
 	"bytes"
+	"encoding/json"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/waliqueiroz/devbook-api/controller"
+	"github.com/waliqueiroz/devbook-api/model"
 	"github.com/waliqueiroz/devbook-api/test/mock"
 )
 
@@ -18,47 +22,218 @@ func TestLogin(t *testing.T) {
 	invalidCredentials, _ := ioutil.ReadFile("../test/resource/json/login_input_with_invalid_credentials.json")
 	invalidLoginInput, _ := ioutil.ReadFile("../test/resource/json/invalid_login_input.json")
 
+	expectedUserJson, _ := ioutil.ReadFile("../test/resource/json/created_user.json")
+
+	var expectedUser model.User
+	json.Unmarshal(expectedUserJson, &expectedUser)
+
 	subTests := []struct {
 		name               string
 		input              io.Reader
+		accept             string
 		expectedStatusCode int
+		expectedErrorCode  string
+		expectJSON         bool
 	}{
 		{
 			name:               "Login with correct credentials",
 			input:              bytes.NewReader(loginInput),
 			expectedStatusCode: http.StatusOK,
 		},
+		{
+			name:               "Login with correct credentials accepting any content",
+			input:              bytes.NewReader(loginInput),
+			accept:             "*/*",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Login with correct credentials preferring plain text",
+			input:              bytes.NewReader(loginInput),
+			accept:             "application/json;q=0.5, text/plain",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Login with correct credentials accepting JSON",
+			input:              bytes.NewReader(loginInput),
+			accept:             "application/json",
+			expectedStatusCode: http.StatusOK,
+			expectJSON:         true,
+		},
 		{
 			name:               "Login with invalid credentials",
 			input:              bytes.NewReader(invalidCredentials),
 			expectedStatusCode: http.StatusUnauthorized,
+			expectedErrorCode:  model.CodeUnauthorized,
+		},
+		{
+			name:               "Login before verifying the email",
+			input:              strings.NewReader(`{"email": "` + mock.UnverifiedEmail + `", "password": "12345678"}`),
+			expectedStatusCode: http.StatusForbidden,
+			expectedErrorCode:  model.CodeNotVerified,
 		},
 		{
 			name:               "Login with invalid body payload",
 			input:              mock.NewReader(),
 			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedErrorCode:  model.CodeUnreadableBody,
 		},
 		{
 			name:               "Login with invalid data",
 			input:              bytes.NewReader(invalidLoginInput),
 			expectedStatusCode: http.StatusBadRequest,
+			expectedErrorCode:  model.CodeInvalidBody,
 		},
 	}
 
 	userRepository := mock.NewUserRepository()
-	authController := controller.NewAuthController(userRepository)
+	authController := controller.NewAuthController(userRepository, mock.NewSessionRepository())
 
 	for _, subTest := range subTests {
 		t.Run(subTest.name, func(t *testing.T) {
 			request := httptest.NewRequest("POST", "/login", subTest.input)
 			request.Header.Add("Content-Type", "application/json")
 
+			if subTest.accept != "" {
+				request.Header.Add("Accept", subTest.accept)
+			}
+
 			response := httptest.NewRecorder()
 
 			authController.Login(response, request)
 
 			assert.Equal(t, subTest.expectedStatusCode, response.Code, "Status code does not match with expected")
 			assert.NotEmpty(t, response.Body.String(), "Response body is empty")
+
+			if subTest.expectedStatusCode != http.StatusOK {
+				var body struct {
+					Error string `json:"error"`
+					Code  string `json:"code"`
+				}
+				json.Unmarshal(response.Body.Bytes(), &body)
+
+				assert.Equal(t, subTest.expectedErrorCode, body.Code, "Error code does not match with expected")
+				assert.NotContains(t, body.Error, "bcrypt", "Error message exposes internal details")
+				assert.NotContains(t, body.Error, "invalid character", "Error message exposes internal details")
+				return
+			}
+
+			if subTest.expectJSON {
+				var login model.Login
+				json.Unmarshal(response.Body.Bytes(), &login)
+
+				assert.Equal(t, "application/json", response.Header().Get("Content-Type"), "Content type does not match with expected")
+				assert.NotEmpty(t, login.AccessToken, "Access token is empty")
+				assert.NotEmpty(t, login.RefreshToken, "Refresh token is empty")
+				assert.Equal(t, "Bearer", login.TokenType, "Token type does not match with expected")
+				assert.True(t, login.ExpiresAt.After(time.Now()), "Token expiration is not in the future")
+				assert.Equal(t, expectedUser, login.User, "User does not match with expected")
+			} else {
+				assert.Equal(t, "text/plain; charset=utf-8", response.Header().Get("Content-Type"), "Content type does not match with expected")
+				assert.NotEmpty(t, response.Header().Get("X-Refresh-Token"), "Refresh token header is empty")
+			}
+		})
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	refreshInput, _ := ioutil.ReadFile("../test/resource/json/refresh_input.json")
+	invalidToken, _ := ioutil.ReadFile("../test/resource/json/refresh_input_with_invalid_token.json")
+	invalidRefreshInput, _ := ioutil.ReadFile("../test/resource/json/invalid_refresh_input.json")
+	incompleteRefreshInput, _ := ioutil.ReadFile("../test/resource/json/incomplete_refresh_input.json")
+
+	subTests := []struct {
+		name               string
+		input              io.Reader
+		expectedStatusCode int
+	}{
+		{
+			name:               "Refresh with a valid refresh token",
+			input:              bytes.NewReader(refreshInput),
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Refresh with an invalid refresh token",
+			input:              bytes.NewReader(invalidToken),
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "Refresh with invalid body payload",
+			input:              mock.NewReader(),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:               "Refresh with invalid data",
+			input:              bytes.NewReader(invalidRefreshInput),
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Refresh without a refresh token",
+			input:              bytes.NewReader(incompleteRefreshInput),
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	userRepository := mock.NewUserRepository()
+	authController := controller.NewAuthController(userRepository, mock.NewSessionRepository())
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			request := httptest.NewRequest("POST", "/refresh", subTest.input)
+			request.Header.Add("Content-Type", "application/json")
+
+			response := httptest.NewRecorder()
+
+			authController.Refresh(response, request)
+
+			assert.Equal(t, subTest.expectedStatusCode, response.Code, "Status code does not match with expected")
+
+			if subTest.expectedStatusCode == http.StatusOK {
+				var tokens model.AuthTokens
+				json.Unmarshal(response.Body.Bytes(), &tokens)
+				assert.NotEmpty(t, tokens.AccessToken, "Access token is empty")
+				assert.NotEmpty(t, tokens.RefreshToken, "Refresh token is empty")
+				assert.NotEqual(t, mock.RefreshToken, tokens.RefreshToken, "Refresh token was not rotated")
+			} else {
+				assert.NotEmpty(t, response.Body.String(), "Response body is empty")
+			}
+		})
+	}
+}
+
+func TestLogout(t *testing.T) {
+	subTests := []struct {
+		name               string
+		expectedStatusCode int
+		userID             uint64
+	}{
+		{
+			name:               "Logout",
+			expectedStatusCode: http.StatusNoContent,
+			userID:             1,
+		},
+		{
+			name:               "Logout without authentication",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	userRepository := mock.NewUserRepository()
+	authController := controller.NewAuthController(userRepository, mock.NewSessionRepository())
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			request := httptest.NewRequest("POST", "/logout", nil)
+			request = mock.Authenticate(request, subTest.userID)
+
+			response := httptest.NewRecorder()
+
+			authController.Logout(response, request)
+
+			assert.Equal(t, subTest.expectedStatusCode, response.Code, "Status code does not match with expected")
+
+			if subTest.expectedStatusCode != http.StatusNoContent {
+				assert.NotEmpty(t, response.Body.String(), "Response body is empty")
+			}
 		})
 	}
 }
@@ -0,0 +1,224 @@
+package controller
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/waliqueiroz/devbook-api/authentication"
+	"github.com/waliqueiroz/devbook-api/interfaces"
+	"github.com/waliqueiroz/devbook-api/model"
+	"github.com/waliqueiroz/devbook-api/response"
+)
+
+type CommentController struct {
+	commentRepository      interfaces.CommentRepository
+	postRepository         interfaces.PostRepository
+	notificationRepository interfaces.NotificationRepository
+	publisher              interfaces.Publisher
+}
+
+// NewCommentController creates a new CommentController
+func NewCommentController(commentRepository interfaces.CommentRepository, postRepository interfaces.PostRepository, notificationRepository interfaces.NotificationRepository, publisher interfaces.Publisher) *CommentController {
+	return &CommentController{
+		commentRepository,
+		postRepository,
+		notificationRepository,
+		publisher,
+	}
+}
+
+// Index shows a page of comments of a post
+func (controller CommentController) Index(w http.ResponseWriter, r *http.Request) {
+	userID, err := authentication.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, r, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+
+	postID, err := parseID(params, "postID")
+	if err != nil {
+		response.Error(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		response.Error(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if _, err = controller.postRepository.FindByID(r.Context(), postID, userID); err != nil {
+		response.Error(w, r, repositoryErrorStatus(err), err)
+		return
+	}
+
+	comments, err := controller.commentRepository.FindByPost(r.Context(), postID, page)
+	if err != nil {
+		response.Error(w, r, repositoryErrorStatus(err), err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, comments)
+}
+
+// Create creates a comment in a post
+func (controller CommentController) Create(w http.ResponseWriter, r *http.Request) {
+	userID, err := authentication.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, r, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+
+	postID, err := parseID(params, "postID")
+	if err != nil {
+		response.Error(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	post, err := controller.postRepository.FindByID(r.Context(), postID, userID)
+	if err != nil {
+		response.Error(w, r, repositoryErrorStatus(err), err)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		response.Error(w, r, http.StatusUnprocessableEntity, model.NewUnreadableBodyError(err))
+		return
+	}
+
+	var comment model.Comment
+	err = json.Unmarshal(body, &comment)
+	if err != nil {
+		response.Error(w, r, http.StatusBadRequest, model.NewInvalidBodyError(err))
+		return
+	}
+
+	comment.PostID = postID
+	comment.AuthorID = userID
+
+	if err := comment.Prepare(); err != nil {
+		response.Error(w, r, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	newComment, err := controller.commentRepository.Create(r.Context(), comment)
+	if err != nil {
+		response.Error(w, r, repositoryErrorStatus(err), err)
+		return
+	}
+
+	notify(r.Context(), controller.notificationRepository, controller.publisher, model.Notification{
+		UserID:    post.AuthorID,
+		ActorID:   userID,
+		Type:      model.NotificationComment,
+		PostID:    &postID,
+		CommentID: &newComment.ID,
+	})
+
+	response.JSON(w, http.StatusCreated, newComment)
+}
+
+// Update updates a comment. Only its author can edit it
+func (controller CommentController) Update(w http.ResponseWriter, r *http.Request) {
+	userID, err := authentication.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, r, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+
+	commentID, err := parseID(params, "commentID")
+	if err != nil {
+		response.Error(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	storedComment, err := controller.commentRepository.FindByID(r.Context(), commentID)
+	if err != nil {
+		response.Error(w, r, repositoryErrorStatus(err), err)
+		return
+	}
+
+	if userID != storedComment.AuthorID {
+		response.Error(w, r, http.StatusForbidden, model.NewForbiddenError("you cannot update a comment that is not yours"))
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		response.Error(w, r, http.StatusUnprocessableEntity, model.NewUnreadableBodyError(err))
+		return
+	}
+
+	var comment model.Comment
+	err = json.Unmarshal(body, &comment)
+	if err != nil {
+		response.Error(w, r, http.StatusBadRequest, model.NewInvalidBodyError(err))
+		return
+	}
+
+	if err := comment.Prepare(); err != nil {
+		response.Error(w, r, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	err = controller.commentRepository.Update(r.Context(), commentID, comment)
+	if err != nil {
+		response.Error(w, r, repositoryErrorStatus(err), err)
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+// Delete deletes a comment. It can be deleted by its author or by the owner of the post
+func (controller CommentController) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, err := authentication.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, r, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+
+	commentID, err := parseID(params, "commentID")
+	if err != nil {
+		response.Error(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	storedComment, err := controller.commentRepository.FindByID(r.Context(), commentID)
+	if err != nil {
+		response.Error(w, r, repositoryErrorStatus(err), err)
+		return
+	}
+
+	if userID != storedComment.AuthorID {
+		post, err := controller.postRepository.FindByID(r.Context(), storedComment.PostID, userID)
+		if err != nil {
+			response.Error(w, r, repositoryErrorStatus(err), err)
+			return
+		}
+
+		if userID != post.AuthorID {
+			response.Error(w, r, http.StatusForbidden, model.NewForbiddenError("you cannot delete a comment that is not yours or in a post of yours"))
+			return
+		}
+	}
+
+	err = controller.commentRepository.Delete(r.Context(), commentID)
+	if err != nil {
+		response.Error(w, r, repositoryErrorStatus(err), err)
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
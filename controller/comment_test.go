@@ -0,0 +1,344 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/waliqueiroz/devbook-api/controller"
+	"github.com/waliqueiroz/devbook-api/model"
+	"github.com/waliqueiroz/devbook-api/stream"
+	"github.com/waliqueiroz/devbook-api/test/mock"
+)
+
+func TestIndexComments(t *testing.T) {
+	expectedCommentListJson, _ := ioutil.ReadFile("../test/resource/json/stored_comment_list.json")
+
+	var expectedCommentList []model.Comment
+	json.Unmarshal(expectedCommentListJson, &expectedCommentList)
+
+	subTests := []struct {
+		name               string
+		routeVariable      string
+		query              string
+		expectedStatusCode int
+		expectedResponse   model.CommentPage
+		userID             uint64
+	}{
+		{
+			name:               "Get comments",
+			routeVariable:      "1",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   model.CommentPage{Data: expectedCommentList},
+			userID:             1,
+		},
+		{
+			name:               "Get comments without authentication",
+			routeVariable:      "1",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "Get comments with an invalid post ID",
+			routeVariable:      "teste",
+			expectedStatusCode: http.StatusBadRequest,
+			userID:             1,
+		},
+		{
+			name:               "Get comments with an invalid cursor",
+			routeVariable:      "1",
+			query:              "?cursor=teste",
+			expectedStatusCode: http.StatusBadRequest,
+			userID:             1,
+		},
+		{
+			name:               "Get comments of a post that does not exist",
+			routeVariable:      fmt.Sprintf("%d", mock.UnknownID),
+			expectedStatusCode: http.StatusNotFound,
+			userID:             1,
+		},
+	}
+
+	commentController := controller.NewCommentController(mock.NewCommentRepository(), mock.NewPostRepository(), mock.NewNotificationRepository(), stream.NewHub())
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			request := httptest.NewRequest("GET", "/posts/"+subTest.routeVariable+"/comments"+subTest.query, nil)
+			request = mux.SetURLVars(request, map[string]string{
+				"postID": subTest.routeVariable,
+			})
+			request.Header.Add("Content-Type", "application/json")
+			request = mock.Authenticate(request, subTest.userID)
+
+			response := httptest.NewRecorder()
+
+			commentController.Index(response, request)
+
+			assert.Equal(t, subTest.expectedStatusCode, response.Code, "Status code does not match with expected")
+
+			if subTest.expectedStatusCode == http.StatusOK {
+				var commentPage model.CommentPage
+				json.Unmarshal(response.Body.Bytes(), &commentPage)
+				assert.Equal(t, subTest.expectedResponse, commentPage, "Comment page does not match with expected")
+			} else {
+				assert.NotEmpty(t, response.Body.String(), "Response body is empty")
+			}
+		})
+	}
+}
+
+func TestCreateComment(t *testing.T) {
+	commentInputJson, _ := ioutil.ReadFile("../test/resource/json/comment_input.json")
+	invalidCommentInputJson, _ := ioutil.ReadFile("../test/resource/json/invalid_comment_input.json")
+	incompleteCommentInputJson, _ := ioutil.ReadFile("../test/resource/json/incomplete_comment_input.json")
+
+	expectedCommentJson, _ := ioutil.ReadFile("../test/resource/json/created_comment.json")
+
+	var expectedComment model.Comment
+	json.Unmarshal(expectedCommentJson, &expectedComment)
+
+	subTests := []struct {
+		name               string
+		input              io.Reader
+		routeVariable      string
+		expectedStatusCode int
+		expectedResponse   model.Comment
+		userID             uint64
+	}{
+		{
+			name:               "Create comment with valid data",
+			input:              bytes.NewReader(commentInputJson),
+			routeVariable:      "1",
+			expectedStatusCode: http.StatusCreated,
+			expectedResponse:   expectedComment,
+			userID:             2,
+		},
+		{
+			name:               "Create comment without authentication",
+			input:              bytes.NewReader(commentInputJson),
+			routeVariable:      "1",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "Create comment with an invalid post ID",
+			input:              bytes.NewReader(commentInputJson),
+			routeVariable:      "teste",
+			expectedStatusCode: http.StatusBadRequest,
+			userID:             2,
+		},
+		{
+			name:               "Create comment in a post that does not exist",
+			input:              bytes.NewReader(commentInputJson),
+			routeVariable:      fmt.Sprintf("%d", mock.UnknownID),
+			expectedStatusCode: http.StatusNotFound,
+			userID:             2,
+		},
+		{
+			name:               "Create comment with invalid body payload",
+			input:              mock.NewReader(),
+			routeVariable:      "1",
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			userID:             2,
+		},
+		{
+			name:               "Create comment with invalid data",
+			input:              bytes.NewReader(invalidCommentInputJson),
+			routeVariable:      "1",
+			expectedStatusCode: http.StatusBadRequest,
+			userID:             2,
+		},
+		{
+			name:               "Create comment with incomplete data",
+			input:              bytes.NewReader(incompleteCommentInputJson),
+			routeVariable:      "1",
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			userID:             2,
+		},
+	}
+
+	commentController := controller.NewCommentController(mock.NewCommentRepository(), mock.NewPostRepository(), mock.NewNotificationRepository(), stream.NewHub())
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			request := httptest.NewRequest("POST", "/posts/"+subTest.routeVariable+"/comments", subTest.input)
+			request = mux.SetURLVars(request, map[string]string{
+				"postID": subTest.routeVariable,
+			})
+			request.Header.Add("Content-Type", "application/json")
+			request = mock.Authenticate(request, subTest.userID)
+
+			response := httptest.NewRecorder()
+
+			commentController.Create(response, request)
+
+			assert.Equal(t, subTest.expectedStatusCode, response.Code, "Status code does not match with expected")
+
+			if subTest.expectedStatusCode == http.StatusCreated {
+				var createdComment model.Comment
+				json.Unmarshal(response.Body.Bytes(), &createdComment)
+				assert.Equal(t, subTest.expectedResponse, createdComment, "Comment does not match with expected")
+			} else {
+				assert.NotEmpty(t, response.Body.String(), "Response body is empty")
+			}
+		})
+	}
+}
+
+func TestUpdateComment(t *testing.T) {
+	commentInputJson, _ := ioutil.ReadFile("../test/resource/json/comment_input.json")
+	incompleteCommentInputJson, _ := ioutil.ReadFile("../test/resource/json/incomplete_comment_input.json")
+
+	subTests := []struct {
+		name               string
+		input              io.Reader
+		routeVariable      string
+		expectedStatusCode int
+		userID             uint64
+	}{
+		{
+			name:               "Update comment with valid data",
+			input:              bytes.NewReader(commentInputJson),
+			routeVariable:      "1",
+			expectedStatusCode: http.StatusNoContent,
+			userID:             2,
+		},
+		{
+			name:               "Update comment without authentication",
+			input:              bytes.NewReader(commentInputJson),
+			routeVariable:      "1",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "Update comment with an invalid comment ID",
+			input:              bytes.NewReader(commentInputJson),
+			routeVariable:      "teste",
+			expectedStatusCode: http.StatusBadRequest,
+			userID:             2,
+		},
+		{
+			name:               "Update comment that does not exist",
+			input:              bytes.NewReader(commentInputJson),
+			routeVariable:      fmt.Sprintf("%d", mock.UnknownID),
+			expectedStatusCode: http.StatusNotFound,
+			userID:             2,
+		},
+		{
+			name:               "Try to update a comment that is not yours, even as the post owner",
+			input:              bytes.NewReader(commentInputJson),
+			routeVariable:      "1",
+			expectedStatusCode: http.StatusForbidden,
+			userID:             1,
+		},
+		{
+			name:               "Update comment with invalid body payload",
+			input:              mock.NewReader(),
+			routeVariable:      "1",
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			userID:             2,
+		},
+		{
+			name:               "Update comment with incomplete data",
+			input:              bytes.NewReader(incompleteCommentInputJson),
+			routeVariable:      "1",
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			userID:             2,
+		},
+	}
+
+	commentController := controller.NewCommentController(mock.NewCommentRepository(), mock.NewPostRepository(), mock.NewNotificationRepository(), stream.NewHub())
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			request := httptest.NewRequest("PUT", "/comments/"+subTest.routeVariable, subTest.input)
+			request = mux.SetURLVars(request, map[string]string{
+				"commentID": subTest.routeVariable,
+			})
+			request.Header.Add("Content-Type", "application/json")
+			request = mock.Authenticate(request, subTest.userID)
+
+			response := httptest.NewRecorder()
+
+			commentController.Update(response, request)
+
+			assert.Equal(t, subTest.expectedStatusCode, response.Code, "Status code does not match with expected")
+
+			if subTest.expectedStatusCode != http.StatusNoContent {
+				assert.NotEmpty(t, response.Body.String(), "Response body is empty")
+			}
+		})
+	}
+}
+
+func TestDeleteComment(t *testing.T) {
+	subTests := []struct {
+		name               string
+		routeVariable      string
+		expectedStatusCode int
+		userID             uint64
+	}{
+		{
+			name:               "Delete comment as its author",
+			routeVariable:      "1",
+			expectedStatusCode: http.StatusNoContent,
+			userID:             2,
+		},
+		{
+			name:               "Delete comment as the post owner",
+			routeVariable:      "1",
+			expectedStatusCode: http.StatusNoContent,
+			userID:             1,
+		},
+		{
+			name:               "Delete comment without authentication",
+			routeVariable:      "1",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "Delete comment with an invalid comment ID",
+			routeVariable:      "teste",
+			expectedStatusCode: http.StatusBadRequest,
+			userID:             2,
+		},
+		{
+			name:               "Delete comment that does not exist",
+			routeVariable:      fmt.Sprintf("%d", mock.UnknownID),
+			expectedStatusCode: http.StatusNotFound,
+			userID:             2,
+		},
+		{
+			name:               "Try to delete a comment that is not yours in a post that is not yours",
+			routeVariable:      "1",
+			expectedStatusCode: http.StatusForbidden,
+			userID:             3,
+		},
+	}
+
+	commentController := controller.NewCommentController(mock.NewCommentRepository(), mock.NewPostRepository(), mock.NewNotificationRepository(), stream.NewHub())
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			request := httptest.NewRequest("DELETE", "/comments/"+subTest.routeVariable, nil)
+			request = mux.SetURLVars(request, map[string]string{
+				"commentID": subTest.routeVariable,
+			})
+			request.Header.Add("Content-Type", "application/json")
+			request = mock.Authenticate(request, subTest.userID)
+
+			response := httptest.NewRecorder()
+
+			commentController.Delete(response, request)
+
+			assert.Equal(t, subTest.expectedStatusCode, response.Code, "Status code does not match with expected")
+
+			if subTest.expectedStatusCode != http.StatusNoContent {
+				assert.NotEmpty(t, response.Body.String(), "Response body is empty")
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/waliqueiroz/devbook-api/model"
+)
+
+// StatusClientClosedRequest is the non-standard status code logged when a client goes away before its response is ready
+const StatusClientClosedRequest = 499
+
+// repositoryErrorStatus returns the HTTP status code that matches an error returned by a repository.
+// A query that ran out of time means the database is overloaded, so the client may try again later
+func repositoryErrorStatus(err error) int {
+	var appError *model.AppError
+	if errors.As(err, &appError) {
+		return appError.Status
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return http.StatusServiceUnavailable
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return StatusClientClosedRequest
+	}
+
+	return http.StatusInternalServerError
+}
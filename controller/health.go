@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/waliqueiroz/devbook-api/interfaces"
+	"github.com/waliqueiroz/devbook-api/logger"
+	"github.com/waliqueiroz/devbook-api/model"
+	"github.com/waliqueiroz/devbook-api/response"
+)
+
+// Errors reported by the readiness checks. The probe is public, so the underlying errors are only logged
+const (
+	errShuttingDown   = "the server is shutting down"
+	errDatabaseDown   = "the database does not answer"
+	errSchemaMismatch = "the database schema does not match the API"
+)
+
+// readinessTimeout bounds the checks of a readiness probe, so a stuck database makes it fail instead of hang
+const readinessTimeout = 2 * time.Second
+
+type HealthController struct {
+	database      interfaces.Database
+	schemaChecker interfaces.SchemaChecker
+	shuttingDown  int32
+}
+
+// NewHealthController creates a new HealthController
+func NewHealthController(database interfaces.Database, schemaChecker interfaces.SchemaChecker) *HealthController {
+	return &HealthController{
+		database:      database,
+		schemaChecker: schemaChecker,
+	}
+}
+
+// ShutDown makes the readiness probe fail from now on, so no new traffic is sent while the server drains
+func (controller *HealthController) ShutDown() {
+	atomic.StoreInt32(&controller.shuttingDown, 1)
+}
+
+// Live tells that the process is up and serving requests
+func (controller *HealthController) Live(w http.ResponseWriter, r *http.Request) {
+	response.JSON(w, http.StatusOK, model.Health{Status: model.HealthOK})
+}
+
+// Ready tells if the API can serve traffic: the database must answer and its schema must match the API,
+// and the server must not be shutting down
+func (controller *HealthController) Ready(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	health := model.Health{
+		Status: model.HealthReady,
+		Checks: map[string]model.HealthCheck{
+			"shutdown":   {Status: model.HealthOK},
+			"database":   controller.checkDatabase(ctx),
+			"migrations": controller.checkMigrations(ctx),
+		},
+	}
+
+	if atomic.LoadInt32(&controller.shuttingDown) == 1 {
+		health.Checks["shutdown"] = model.HealthCheck{Status: model.HealthFailing, Error: errShuttingDown}
+	}
+
+	for _, check := range health.Checks {
+		if check.Status != model.HealthOK {
+			health.Status = model.HealthNotReady
+		}
+	}
+
+	if health.Status != model.HealthReady {
+		response.JSON(w, http.StatusServiceUnavailable, health)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, health)
+}
+
+func (controller *HealthController) checkDatabase(ctx context.Context) model.HealthCheck {
+	stats := controller.database.Stats()
+
+	check := model.HealthCheck{
+		Status: model.HealthOK,
+		Pool: &model.DatabasePoolStats{
+			MaxOpenConnections: stats.MaxOpenConnections,
+			OpenConnections:    stats.OpenConnections,
+			InUse:              stats.InUse,
+			Idle:               stats.Idle,
+			WaitCount:          stats.WaitCount,
+			WaitDurationMillis: stats.WaitDuration.Milliseconds(),
+		},
+	}
+
+	if err := controller.database.PingContext(ctx); err != nil {
+		logger.FromContext(ctx).Error("readiness check failed: database", "error", err)
+		check.Status = model.HealthFailing
+		check.Error = errDatabaseDown
+	}
+
+	return check
+}
+
+func (controller *HealthController) checkMigrations(ctx context.Context) model.HealthCheck {
+	if err := controller.schemaChecker.Check(ctx); err != nil {
+		logger.FromContext(ctx).Error("readiness check failed: migrations", "error", err)
+		return model.HealthCheck{Status: model.HealthFailing, Error: errSchemaMismatch}
+	}
+
+	return model.HealthCheck{Status: model.HealthOK}
+}
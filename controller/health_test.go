@@ -0,0 +1,103 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/waliqueiroz/devbook-api/controller"
+	"github.com/waliqueiroz/devbook-api/database"
+	"github.com/waliqueiroz/devbook-api/model"
+	"github.com/waliqueiroz/devbook-api/test/mock"
+)
+
+func TestLive(t *testing.T) {
+	healthController := controller.NewHealthController(mock.NewDatabase(errors.New("connection refused")), mock.NewSchemaChecker(nil))
+
+	request := httptest.NewRequest("GET", "/healthz", nil)
+	response := httptest.NewRecorder()
+
+	healthController.Live(response, request)
+
+	var health model.Health
+	json.Unmarshal(response.Body.Bytes(), &health)
+
+	assert.Equal(t, http.StatusOK, response.Code, "Status code does not match with expected")
+	assert.Equal(t, model.Health{Status: model.HealthOK}, health, "Health does not match with expected")
+}
+
+func TestReady(t *testing.T) {
+	subTests := []struct {
+		name               string
+		pingError          error
+		schemaError        error
+		shuttingDown       bool
+		expectedStatusCode int
+		expectedStatus     string
+		failingCheck       string
+		expectedError      string
+	}{
+		{
+			name:               "Ready to serve traffic",
+			expectedStatusCode: http.StatusOK,
+			expectedStatus:     model.HealthReady,
+		},
+		{
+			name:               "Not ready when the database does not answer",
+			pingError:          errors.New("connection refused"),
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedStatus:     model.HealthNotReady,
+			failingCheck:       "database",
+			expectedError:      "the database does not answer",
+		},
+		{
+			name:               "Not ready when the schema is ahead of the API",
+			schemaError:        database.ErrSchemaAhead,
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedStatus:     model.HealthNotReady,
+			failingCheck:       "migrations",
+			expectedError:      "the database schema does not match the API",
+		},
+		{
+			name:               "Not ready when shutting down",
+			shuttingDown:       true,
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedStatus:     model.HealthNotReady,
+			failingCheck:       "shutdown",
+			expectedError:      "the server is shutting down",
+		},
+	}
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			healthController := controller.NewHealthController(mock.NewDatabase(subTest.pingError), mock.NewSchemaChecker(subTest.schemaError))
+			if subTest.shuttingDown {
+				healthController.ShutDown()
+			}
+
+			request := httptest.NewRequest("GET", "/readyz", nil)
+			response := httptest.NewRecorder()
+
+			healthController.Ready(response, request)
+
+			var health model.Health
+			json.Unmarshal(response.Body.Bytes(), &health)
+
+			assert.Equal(t, subTest.expectedStatusCode, response.Code, "Status code does not match with expected")
+			assert.Equal(t, subTest.expectedStatus, health.Status, "Health status does not match with expected")
+			assert.Equal(t, 2, health.Checks["database"].Pool.OpenConnections, "Pool stats do not match with expected")
+
+			for name, check := range health.Checks {
+				if name == subTest.failingCheck {
+					assert.Equal(t, model.HealthFailing, check.Status, "Check %s should fail", name)
+					assert.Equal(t, subTest.expectedError, check.Error, "Check %s should report a fixed error", name)
+				} else {
+					assert.Equal(t, model.HealthOK, check.Status, "Check %s should pass", name)
+				}
+			}
+		})
+	}
+}
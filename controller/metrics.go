@@ -0,0 +1,23 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/waliqueiroz/devbook-api/metrics"
+)
+
+type MetricsController struct {
+	registry *metrics.Registry
+}
+
+// NewMetricsController creates a new MetricsController
+func NewMetricsController(registry *metrics.Registry) *MetricsController {
+	return &MetricsController{
+		registry,
+	}
+}
+
+// Metrics exposes the metrics of the API in the Prometheus text format
+func (controller MetricsController) Metrics(w http.ResponseWriter, r *http.Request) {
+	controller.registry.ServeHTTP(w, r)
+}
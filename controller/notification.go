@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/waliqueiroz/devbook-api/authentication"
+	"github.com/waliqueiroz/devbook-api/interfaces"
+	"github.com/waliqueiroz/devbook-api/logger"
+	"github.com/waliqueiroz/devbook-api/model"
+	"github.com/waliqueiroz/devbook-api/response"
+	"github.com/waliqueiroz/devbook-api/stream"
+)
+
+type NotificationController struct {
+	notificationRepository interfaces.NotificationRepository
+}
+
+// NewNotificationController creates a new NotificationController
+func NewNotificationController(notificationRepository interfaces.NotificationRepository) *NotificationController {
+	return &NotificationController{
+		notificationRepository,
+	}
+}
+
+// Index shows a page of notifications of the authenticated user
+func (controller NotificationController) Index(w http.ResponseWriter, r *http.Request) {
+	userID, err := authentication.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, r, http.StatusUnauthorized, err)
+		return
+	}
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		response.Error(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	unreadOnly := false
+	if unread := r.URL.Query().Get("unread"); unread != "" {
+		unreadOnly, err = strconv.ParseBool(unread)
+		if err != nil {
+			response.Error(w, r, http.StatusBadRequest, model.NewBadRequestError("unread must be true or false"))
+			return
+		}
+	}
+
+	notifications, err := controller.notificationRepository.FindByUser(r.Context(), userID, unreadOnly, page)
+	if err != nil {
+		response.Error(w, r, repositoryErrorStatus(err), err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, notifications)
+}
+
+// MarkAsRead marks notifications of the authenticated user as read. An empty body marks all of them
+func (controller NotificationController) MarkAsRead(w http.ResponseWriter, r *http.Request) {
+	userID, err := authentication.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, r, http.StatusUnauthorized, err)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		response.Error(w, r, http.StatusUnprocessableEntity, model.NewUnreadableBodyError(err))
+		return
+	}
+
+	var readNotifications model.ReadNotifications
+	if len(body) > 0 {
+		if err = json.Unmarshal(body, &readNotifications); err != nil {
+			response.Error(w, r, http.StatusBadRequest, model.NewInvalidBodyError(err))
+			return
+		}
+	}
+
+	if err = controller.notificationRepository.MarkAsRead(r.Context(), userID, readNotifications.IDs); err != nil {
+		response.Error(w, r, repositoryErrorStatus(err), err)
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+// CountUnread shows how many notifications the authenticated user has not read yet
+func (controller NotificationController) CountUnread(w http.ResponseWriter, r *http.Request) {
+	userID, err := authentication.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, r, http.StatusUnauthorized, err)
+		return
+	}
+
+	unread, err := controller.notificationRepository.CountUnread(r.Context(), userID)
+	if err != nil {
+		response.Error(w, r, repositoryErrorStatus(err), err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, model.UnreadNotifications{Unread: unread})
+}
+
+// notify records a notification, skipping the ones about the user's own actions, and streams it to the user.
+// Failing to notify must not fail the action that caused it, so errors are only logged
+func notify(ctx context.Context, notificationRepository interfaces.NotificationRepository, publisher interfaces.Publisher, notification model.Notification) {
+	if notification.IsSelfAction() {
+		return
+	}
+
+	storedNotification, created, err := notificationRepository.Create(ctx, notification)
+	if err != nil {
+		logger.FromContext(ctx).Error("could not notify user", "user_id", notification.UserID, "error", err)
+		return
+	}
+
+	if created {
+		publisher.Publish(stream.UserTopic(notification.UserID), stream.EventNotification, storedNotification)
+	}
+}
+
+// notifyMentions records and streams the notifications of the users mentioned in a post, logging errors like notify
+func notifyMentions(ctx context.Context, notificationRepository interfaces.NotificationRepository, publisher interfaces.Publisher, post model.Post) {
+	notifications, err := notificationRepository.CreateMentions(ctx, post.AuthorID, post.ID, post.Mentions)
+	if err != nil {
+		logger.FromContext(ctx).Error("could not notify the users mentioned in a post", "post_id", post.ID, "error", err)
+		return
+	}
+
+	for _, notification := range notifications {
+		publisher.Publish(stream.UserTopic(notification.UserID), stream.EventNotification, notification)
+	}
+}
@@ -0,0 +1,221 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/waliqueiroz/devbook-api/controller"
+	"github.com/waliqueiroz/devbook-api/model"
+	"github.com/waliqueiroz/devbook-api/stream"
+	"github.com/waliqueiroz/devbook-api/test/mock"
+)
+
+func TestIndexNotifications(t *testing.T) {
+	expectedNotificationListJson, _ := ioutil.ReadFile("../test/resource/json/stored_notification_list.json")
+
+	var expectedNotificationList []model.Notification
+	json.Unmarshal(expectedNotificationListJson, &expectedNotificationList)
+
+	subTests := []struct {
+		name               string
+		query              string
+		expectedStatusCode int
+		expectedResponse   model.NotificationPage
+		userID             uint64
+	}{
+		{
+			name:               "Get notifications",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   model.NotificationPage{Data: expectedNotificationList},
+			userID:             1,
+		},
+		{
+			name:               "Get unread notifications",
+			query:              "?unread=true&limit=10",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   model.NotificationPage{Data: expectedNotificationList},
+			userID:             1,
+		},
+		{
+			name:               "Get notifications with an invalid unread filter",
+			query:              "?unread=talvez",
+			expectedStatusCode: http.StatusBadRequest,
+			userID:             1,
+		},
+		{
+			name:               "Get notifications with an invalid cursor",
+			query:              "?cursor=teste",
+			expectedStatusCode: http.StatusBadRequest,
+			userID:             1,
+		},
+		{
+			name:               "Get notifications without authentication",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	notificationController := controller.NewNotificationController(mock.NewNotificationRepository())
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			request := httptest.NewRequest("GET", "/notifications"+subTest.query, nil)
+			request = mock.Authenticate(request, subTest.userID)
+
+			response := httptest.NewRecorder()
+
+			notificationController.Index(response, request)
+
+			assert.Equal(t, subTest.expectedStatusCode, response.Code, "Status code does not match with expected")
+
+			if subTest.expectedStatusCode == http.StatusOK {
+				var notificationPage model.NotificationPage
+				json.Unmarshal(response.Body.Bytes(), &notificationPage)
+				assert.Equal(t, subTest.expectedResponse, notificationPage, "Notification page does not match with expected")
+			} else {
+				assert.NotEmpty(t, response.Body.String(), "Response body is empty")
+			}
+		})
+	}
+}
+
+func TestMarkNotificationsAsRead(t *testing.T) {
+	readNotificationsInputJson, _ := ioutil.ReadFile("../test/resource/json/read_notifications_input.json")
+	invalidReadNotificationsInputJson, _ := ioutil.ReadFile("../test/resource/json/invalid_read_notifications_input.json")
+
+	subTests := []struct {
+		name               string
+		input              io.Reader
+		expectedStatusCode int
+		userID             uint64
+	}{
+		{
+			name:               "Mark some notifications as read",
+			input:              bytes.NewReader(readNotificationsInputJson),
+			expectedStatusCode: http.StatusNoContent,
+			userID:             1,
+		},
+		{
+			name:               "Mark all notifications as read",
+			input:              bytes.NewReader(nil),
+			expectedStatusCode: http.StatusNoContent,
+			userID:             1,
+		},
+		{
+			name:               "Mark notifications as read with invalid data",
+			input:              bytes.NewReader(invalidReadNotificationsInputJson),
+			expectedStatusCode: http.StatusBadRequest,
+			userID:             1,
+		},
+		{
+			name:               "Mark notifications as read with invalid body payload",
+			input:              mock.NewReader(),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			userID:             1,
+		},
+		{
+			name:               "Mark notifications as read without authentication",
+			input:              bytes.NewReader(readNotificationsInputJson),
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	notificationController := controller.NewNotificationController(mock.NewNotificationRepository())
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			request := httptest.NewRequest("POST", "/notifications/read", subTest.input)
+			request.Header.Add("Content-Type", "application/json")
+			request = mock.Authenticate(request, subTest.userID)
+
+			response := httptest.NewRecorder()
+
+			notificationController.MarkAsRead(response, request)
+
+			assert.Equal(t, subTest.expectedStatusCode, response.Code, "Status code does not match with expected")
+
+			if subTest.expectedStatusCode != http.StatusNoContent {
+				assert.NotEmpty(t, response.Body.String(), "Response body is empty")
+			}
+		})
+	}
+}
+
+func TestCountUnreadNotifications(t *testing.T) {
+	subTests := []struct {
+		name               string
+		expectedStatusCode int
+		expectedResponse   model.UnreadNotifications
+		userID             uint64
+	}{
+		{
+			name:               "Count unread notifications",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   model.UnreadNotifications{Unread: 2},
+			userID:             1,
+		},
+		{
+			name:               "Count unread notifications without authentication",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	notificationController := controller.NewNotificationController(mock.NewNotificationRepository())
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			request := httptest.NewRequest("GET", "/notifications/unread-count", nil)
+			request = mock.Authenticate(request, subTest.userID)
+
+			response := httptest.NewRecorder()
+
+			notificationController.CountUnread(response, request)
+
+			assert.Equal(t, subTest.expectedStatusCode, response.Code, "Status code does not match with expected")
+
+			if subTest.expectedStatusCode == http.StatusOK {
+				var unread model.UnreadNotifications
+				json.Unmarshal(response.Body.Bytes(), &unread)
+				assert.Equal(t, subTest.expectedResponse, unread, "Unread count does not match with expected")
+			} else {
+				assert.NotEmpty(t, response.Body.String(), "Response body is empty")
+			}
+		})
+	}
+}
+
+func TestStreamStoredNotification(t *testing.T) {
+	hub := stream.NewHub()
+
+	subscription, _ := hub.Subscribe([]string{stream.UserTopic(1)}, nil)
+	defer hub.Unsubscribe(subscription)
+
+	postController := controller.NewPostController(mock.NewPostRepository(), mock.NewNotificationRepository(), hub)
+
+	request := httptest.NewRequest("POST", "/posts/1/like", nil)
+	request = mux.SetURLVars(request, map[string]string{"postID": "1"})
+	request = mock.Authenticate(request, 2)
+
+	response := httptest.NewRecorder()
+
+	postController.LikePost(response, request)
+
+	assert.Equal(t, http.StatusNoContent, response.Code, "Status code does not match with expected")
+
+	select {
+	case event := <-subscription.Events:
+		notification, ok := event.Data.(model.Notification)
+		assert.True(t, ok, "The event does not carry a notification")
+		assert.NotZero(t, notification.ID, "The streamed notification has no ID")
+		assert.Equal(t, "user2", notification.ActorNick)
+		assert.False(t, notification.CreatedAt.IsZero(), "The streamed notification has no creation time")
+	default:
+		t.Fatal("No notification was streamed")
+	}
+}
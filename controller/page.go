@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/waliqueiroz/devbook-api/model"
+)
+
+const defaultPageLimit = 20
+const maxPageLimit = 100
+
+// parsePageRequest reads the limit and cursor query parameters of a request
+func parsePageRequest(r *http.Request) (model.PageRequest, error) {
+	limit, err := parsePageLimit(r)
+	if err != nil {
+		return model.PageRequest{}, err
+	}
+
+	cursor, err := model.DecodeCursor(r.URL.Query().Get("cursor"))
+	if err != nil {
+		return model.PageRequest{}, err
+	}
+
+	return model.PageRequest{Limit: limit, Cursor: cursor}, nil
+}
+
+// parseSearchPageRequest reads the limit and cursor query parameters of a search request
+func parseSearchPageRequest(r *http.Request) (model.SearchPageRequest, error) {
+	limit, err := parsePageLimit(r)
+	if err != nil {
+		return model.SearchPageRequest{}, err
+	}
+
+	after, err := model.DecodeSearchCursor(r.URL.Query().Get("cursor"))
+	if err != nil {
+		return model.SearchPageRequest{}, err
+	}
+
+	return model.SearchPageRequest{Limit: limit, After: after}, nil
+}
+
+// parsePageLimit reads the limit query parameter of a request, which defaults to defaultPageLimit
+func parsePageLimit(r *http.Request) (uint64, error) {
+	limit := r.URL.Query().Get("limit")
+	if limit == "" {
+		return defaultPageLimit, nil
+	}
+
+	parsedLimit, err := strconv.ParseUint(limit, 10, 64)
+	if err != nil || parsedLimit == 0 || parsedLimit > maxPageLimit {
+		return 0, model.NewBadRequestError(fmt.Sprintf("limit must be a number between 1 and %d", maxPageLimit))
+	}
+
+	return parsedLimit, nil
+}
+
+// parseID reads a numeric ID from the route parameters of a request
+func parseID(params map[string]string, name string) (uint64, error) {
+	id, err := strconv.ParseUint(params[name], 10, 64)
+	if err != nil {
+		return 0, model.NewBadRequestError(fmt.Sprintf("%s must be a number", name))
+	}
+
+	return id, nil
+}
@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/waliqueiroz/devbook-api/model"
+)
+
+// mergePatchContentType is the media type of a JSON Merge Patch, as defined by RFC 7386
+const mergePatchContentType = "application/merge-patch+json"
+
+// bodyDecoder reads the body of an update into target, given the current data of the resource
+type bodyDecoder func(r *http.Request, body []byte, current interface{}, target interface{}) error
+
+// decodeReplacement reads a body that replaces the data of a resource, as sent to PUT endpoints
+func decodeReplacement(r *http.Request, body []byte, current interface{}, target interface{}) error {
+	if err := json.Unmarshal(body, target); err != nil {
+		return model.NewInvalidBodyError(err)
+	}
+
+	return nil
+}
+
+// decodeMergePatch applies a JSON Merge Patch to the current data of a resource, so only the fields
+// sent are changed and the ones sent as null are cleared. Plain JSON is accepted as well
+func decodeMergePatch(r *http.Request, body []byte, current interface{}, target interface{}) error {
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || (mediaType != mergePatchContentType && mediaType != "application/json") {
+			return model.NewUnsupportedMediaError(fmt.Sprintf("the request body must be sent as %s", mergePatchContentType))
+		}
+	}
+
+	var patch interface{}
+	if err := json.Unmarshal(body, &patch); err != nil {
+		return model.NewInvalidBodyError(err)
+	}
+
+	if _, ok := patch.(map[string]interface{}); !ok {
+		return model.NewBadRequestError("the merge patch must be a JSON object")
+	}
+
+	currentJSON, err := json.Marshal(current)
+	if err != nil {
+		return model.NewInternalError(err)
+	}
+
+	var document interface{}
+	if err = json.Unmarshal(currentJSON, &document); err != nil {
+		return model.NewInternalError(err)
+	}
+
+	patched, err := json.Marshal(mergePatch(document, patch))
+	if err != nil {
+		return model.NewInternalError(err)
+	}
+
+	if err = json.Unmarshal(patched, target); err != nil {
+		return model.NewInvalidBodyError(err)
+	}
+
+	return nil
+}
+
+// mergePatch applies a patch to a JSON document following the algorithm of RFC 7386
+func mergePatch(document, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	documentObject, ok := document.(map[string]interface{})
+	if !ok {
+		documentObject = make(map[string]interface{})
+	}
+
+	for name, value := range patchObject {
+		if value == nil {
+			delete(documentObject, name)
+			continue
+		}
+
+		documentObject[name] = mergePatch(documentObject[name], value)
+	}
+
+	return documentObject
+}
+
+// entityTag returns the ETag of a version of a resource
+func entityTag(version uint64) string {
+	return strconv.Quote(strconv.FormatUint(version, 10))
+}
+
+// checkIfMatch verifies the If-Match header of a request against the current version of a resource.
+// Requests without the header are let through, and the update is then guarded by the version read
+// right before it
+func checkIfMatch(r *http.Request, version uint64, resource string) error {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		return nil
+	}
+
+	current := entityTag(version)
+
+	for _, tag := range strings.Split(ifMatch, ",") {
+		tag = strings.TrimSpace(tag)
+
+		// If-Match uses the strong comparison, so weak tags never match
+		if tag == "*" || tag == current {
+			return nil
+		}
+	}
+
+	return model.NewVersionMismatchError(resource)
+}
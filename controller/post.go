@@ -2,39 +2,56 @@ package controller
 
 import (
 	"encoding/json"
-	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/waliqueiroz/devbook-api/authentication"
 	"github.com/waliqueiroz/devbook-api/interfaces"
+	"github.com/waliqueiroz/devbook-api/metrics"
 	"github.com/waliqueiroz/devbook-api/model"
 	"github.com/waliqueiroz/devbook-api/response"
+	"github.com/waliqueiroz/devbook-api/stream"
 )
 
+const defaultTrendingHours = 24
+const maxTrendingHours = 7 * 24
+const defaultTrendingLimit = 10
+
 type PostController struct {
-	postRepository interfaces.PostRepository
+	postRepository         interfaces.PostRepository
+	notificationRepository interfaces.NotificationRepository
+	publisher              interfaces.Publisher
 }
 
-func NewPostController(postRepository interfaces.PostRepository) *PostController {
+func NewPostController(postRepository interfaces.PostRepository, notificationRepository interfaces.NotificationRepository, publisher interfaces.Publisher) *PostController {
 	return &PostController{
 		postRepository,
+		notificationRepository,
+		publisher,
 	}
 }
 
-// Index shows all posts by a user and from who they are following
+// Index shows a page of posts by a user and from who they are following
 func (controller PostController) Index(w http.ResponseWriter, r *http.Request) {
 	userID, err := authentication.ExtractUserID(r)
 	if err != nil {
-		response.Error(w, http.StatusUnauthorized, err)
+		response.Error(w, r, http.StatusUnauthorized, err)
+		return
+	}
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		response.Error(w, r, http.StatusBadRequest, err)
 		return
 	}
 
-	posts, err := controller.postRepository.Index(userID)
+	posts, err := controller.postRepository.Index(r.Context(), userID, page)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		response.Error(w, r, repositoryErrorStatus(err), err)
 		return
 	}
 
@@ -46,201 +63,497 @@ func (controller PostController) Index(w http.ResponseWriter, r *http.Request) {
 func (controller PostController) Create(w http.ResponseWriter, r *http.Request) {
 	userID, err := authentication.ExtractUserID(r)
 	if err != nil {
-		response.Error(w, http.StatusUnauthorized, err)
+		response.Error(w, r, http.StatusUnauthorized, err)
 		return
 	}
 
 	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
-		response.Error(w, http.StatusUnprocessableEntity, err)
+		response.Error(w, r, http.StatusUnprocessableEntity, model.NewUnreadableBodyError(err))
 		return
 	}
 
 	var post model.Post
 	err = json.Unmarshal(body, &post)
 	if err != nil {
-		response.Error(w, http.StatusBadRequest, err)
+		response.Error(w, r, http.StatusBadRequest, model.NewInvalidBodyError(err))
 		return
 	}
 
 	post.AuthorID = userID
 
 	if err := post.Prepare(); err != nil {
-		response.Error(w, http.StatusBadRequest, err)
+		response.Error(w, r, http.StatusUnprocessableEntity, err)
 		return
 	}
 
-	newPost, err := controller.postRepository.Create(post)
+	if post.ParentPostID != nil {
+		if _, err = controller.postRepository.FindByID(r.Context(), *post.ParentPostID, userID); err != nil {
+			response.Error(w, r, repositoryErrorStatus(err), err)
+			return
+		}
+	}
+
+	if post.IsRepost() {
+		original, err := controller.postRepository.FindByID(r.Context(), *post.RepostOfID, userID)
+		if err != nil {
+			response.Error(w, r, repositoryErrorStatus(err), err)
+			return
+		}
+
+		// reposting a plain repost re-shares the post it points to
+		if original.IsPlainRepost() {
+			post.RepostOfID = original.RepostOfID
+		}
+	}
+
+	newPost, err := controller.postRepository.Create(r.Context(), post)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		response.Error(w, r, repositoryErrorStatus(err), err)
 		return
 	}
 
+	metrics.PostsCreated.Inc()
+	controller.publisher.Publish(stream.AuthorTopic(userID), stream.EventPost, newPost)
+
+	post.ID = newPost.ID
+	notifyMentions(r.Context(), controller.notificationRepository, controller.publisher, post)
+
 	response.JSON(w, http.StatusCreated, newPost)
 }
 
+// Search returns a page of posts that match a full-text search
+func (controller PostController) Search(w http.ResponseWriter, r *http.Request) {
+	userID, err := authentication.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, r, http.StatusUnauthorized, err)
+		return
+	}
+
+	search, err := parsePostSearch(r)
+	if err != nil {
+		response.Error(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	page, err := parseSearchPageRequest(r)
+	if err != nil {
+		response.Error(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	results, err := controller.postRepository.Search(r.Context(), search, userID, page)
+	if err != nil {
+		response.Error(w, r, repositoryErrorStatus(err), err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, results)
+}
+
 // Show shows a post
 func (controller PostController) Show(w http.ResponseWriter, r *http.Request) {
+	userID, err := authentication.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, r, http.StatusUnauthorized, err)
+		return
+	}
+
 	params := mux.Vars(r)
 
-	postID, err := strconv.ParseUint(params["postID"], 10, 64)
+	postID, err := parseID(params, "postID")
 	if err != nil {
-		response.Error(w, http.StatusBadRequest, err)
+		response.Error(w, r, http.StatusBadRequest, err)
 		return
 	}
 
-	post, err := controller.postRepository.FindByID(postID)
+	post, err := controller.postRepository.FindByID(r.Context(), postID, userID)
 
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		response.Error(w, r, repositoryErrorStatus(err), err)
 		return
 	}
 
+	w.Header().Set("ETag", entityTag(post.Version))
 	response.JSON(w, http.StatusOK, post)
 }
 
-// Update updates a post
+// Update replaces the title and the content of a post
 func (controller PostController) Update(w http.ResponseWriter, r *http.Request) {
+	controller.update(w, r, decodeReplacement)
+}
+
+// Patch changes only the fields of a post sent in a JSON Merge Patch
+func (controller PostController) Patch(w http.ResponseWriter, r *http.Request) {
+	controller.update(w, r, decodeMergePatch)
+}
+
+// update validates and saves the changes to a post of the authenticated user. When the client sends
+// the ETag it read in If-Match, the post is only updated if it was not changed since then
+func (controller PostController) update(w http.ResponseWriter, r *http.Request, decode bodyDecoder) {
 	userID, err := authentication.ExtractUserID(r)
 	if err != nil {
-		response.Error(w, http.StatusUnauthorized, err)
+		response.Error(w, r, http.StatusUnauthorized, err)
 		return
 	}
 
 	params := mux.Vars(r)
 
-	postID, err := strconv.ParseUint(params["postID"], 10, 64)
+	postID, err := parseID(params, "postID")
 	if err != nil {
-		response.Error(w, http.StatusBadRequest, err)
+		response.Error(w, r, http.StatusBadRequest, err)
 		return
 	}
 
-	storedPost, err := controller.postRepository.FindByID(postID)
+	storedPost, err := controller.postRepository.FindByID(r.Context(), postID, userID)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		response.Error(w, r, repositoryErrorStatus(err), err)
 		return
 	}
 
 	if userID != storedPost.AuthorID {
-		response.Error(w, http.StatusForbidden, errors.New("you cannot update a post that is not yours"))
+		response.Error(w, r, http.StatusForbidden, model.NewForbiddenError("you cannot update a post that is not yours"))
+		return
+	}
+
+	if err = checkIfMatch(r, storedPost.Version, "post"); err != nil {
+		response.Error(w, r, http.StatusPreconditionFailed, err)
 		return
 	}
 
 	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
-		response.Error(w, http.StatusUnprocessableEntity, err)
+		response.Error(w, r, http.StatusUnprocessableEntity, model.NewUnreadableBodyError(err))
 		return
 	}
 
 	var post model.Post
-	err = json.Unmarshal(body, &post)
-	if err != nil {
-		response.Error(w, http.StatusBadRequest, err)
+	if err = decode(r, body, storedPost, &post); err != nil {
+		response.Error(w, r, http.StatusBadRequest, err)
 		return
 	}
 
-	err = controller.postRepository.Update(postID, post)
+	// what a post answers or re-shares is set when it is created and cannot be changed
+	post.ID = postID
+	post.AuthorID = storedPost.AuthorID
+	post.ParentPostID = storedPost.ParentPostID
+	post.RepostOfID = storedPost.RepostOfID
+	post.Version = storedPost.Version
+
+	if err = post.Prepare(); err != nil {
+		response.Error(w, r, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	err = controller.postRepository.Update(r.Context(), postID, post)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		response.Error(w, r, repositoryErrorStatus(err), err)
 		return
 	}
 
-	response.JSON(w, http.StatusNoContent, nil)
+	// the users mentioned before the edit were already notified
+	edited := post
+	edited.Mentions = post.AddedMentions(storedPost)
+	notifyMentions(r.Context(), controller.notificationRepository, controller.publisher, edited)
 
+	w.Header().Set("ETag", entityTag(post.Version+1))
+	response.JSON(w, http.StatusNoContent, nil)
 }
 
 // Delete deletes a post
 func (controller PostController) Delete(w http.ResponseWriter, r *http.Request) {
 	userID, err := authentication.ExtractUserID(r)
 	if err != nil {
-		response.Error(w, http.StatusUnauthorized, err)
+		response.Error(w, r, http.StatusUnauthorized, err)
 		return
 	}
 
 	params := mux.Vars(r)
 
-	postID, err := strconv.ParseUint(params["postID"], 10, 64)
+	postID, err := parseID(params, "postID")
 	if err != nil {
-		response.Error(w, http.StatusBadRequest, err)
+		response.Error(w, r, http.StatusBadRequest, err)
 		return
 	}
 
-	storedPost, err := controller.postRepository.FindByID(postID)
+	storedPost, err := controller.postRepository.FindByID(r.Context(), postID, userID)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		response.Error(w, r, repositoryErrorStatus(err), err)
 		return
 	}
 
 	if userID != storedPost.AuthorID {
-		response.Error(w, http.StatusForbidden, errors.New("you cannot delete a post that is not yours"))
+		response.Error(w, r, http.StatusForbidden, model.NewForbiddenError("you cannot delete a post that is not yours"))
 		return
 	}
 
-	err = controller.postRepository.Delete(postID)
+	err = controller.postRepository.Delete(r.Context(), postID)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		response.Error(w, r, repositoryErrorStatus(err), err)
 		return
 	}
 
 	response.JSON(w, http.StatusNoContent, nil)
 }
 
-// FindByUser returns all posts from a given user
+// FindByUser returns a page of posts from a given user
 func (controller PostController) FindByUser(w http.ResponseWriter, r *http.Request) {
+	viewerID, err := authentication.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, r, http.StatusUnauthorized, err)
+		return
+	}
+
 	params := mux.Vars(r)
 
-	userID, err := strconv.ParseUint(params["userID"], 10, 64)
+	userID, err := parseID(params, "userID")
+	if err != nil {
+		response.Error(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	page, err := parsePageRequest(r)
 	if err != nil {
-		response.Error(w, http.StatusBadRequest, err)
+		response.Error(w, r, http.StatusBadRequest, err)
 		return
 	}
 
-	post, err := controller.postRepository.FindByUser(userID)
+	posts, err := controller.postRepository.FindByUser(r.Context(), userID, viewerID, page)
 
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		response.Error(w, r, repositoryErrorStatus(err), err)
 		return
 	}
 
-	response.JSON(w, http.StatusOK, post)
+	response.JSON(w, http.StatusOK, posts)
+}
+
+// FindByTag returns a page of posts that use a given tag
+func (controller PostController) FindByTag(w http.ResponseWriter, r *http.Request) {
+	viewerID, err := authentication.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, r, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+
+	tag := model.NormalizeTag(params["tag"])
+	if tag == "" {
+		response.Error(w, r, http.StatusBadRequest, model.NewBadRequestError("tag must not be empty"))
+		return
+	}
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		response.Error(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	posts, err := controller.postRepository.FindByTag(r.Context(), tag, viewerID, page)
+	if err != nil {
+		response.Error(w, r, repositoryErrorStatus(err), err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, posts)
+}
+
+// TrendingTags returns the tags most used in the last hours
+func (controller PostController) TrendingTags(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	hours := uint64(defaultTrendingHours)
+	if value := query.Get("hours"); value != "" {
+		parsedHours, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || parsedHours == 0 || parsedHours > maxTrendingHours {
+			response.Error(w, r, http.StatusBadRequest, model.NewBadRequestError(fmt.Sprintf("hours must be a number between 1 and %d", maxTrendingHours)))
+			return
+		}
+
+		hours = parsedHours
+	}
+
+	limit := uint64(defaultTrendingLimit)
+	if value := query.Get("limit"); value != "" {
+		parsedLimit, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || parsedLimit == 0 || parsedLimit > maxPageLimit {
+			response.Error(w, r, http.StatusBadRequest, model.NewBadRequestError(fmt.Sprintf("limit must be a number between 1 and %d", maxPageLimit)))
+			return
+		}
+
+		limit = parsedLimit
+	}
+
+	since := time.Now().Add(-time.Duration(hours) * time.Hour)
+
+	tags, err := controller.postRepository.TrendingTags(r.Context(), since, limit)
+	if err != nil {
+		response.Error(w, r, repositoryErrorStatus(err), err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, tags)
+}
+
+// FindByMention returns a page of posts that mention a given user
+func (controller PostController) FindByMention(w http.ResponseWriter, r *http.Request) {
+	viewerID, err := authentication.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, r, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+
+	userID, err := parseID(params, "userID")
+	if err != nil {
+		response.Error(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		response.Error(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	posts, err := controller.postRepository.FindByMention(r.Context(), userID, viewerID, page)
+	if err != nil {
+		response.Error(w, r, repositoryErrorStatus(err), err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, posts)
 }
 
-// LikePost increases the number of likes in a post
+// LikePost registers that the authenticated user liked a post
 func (controller PostController) LikePost(w http.ResponseWriter, r *http.Request) {
+	userID, err := authentication.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, r, http.StatusUnauthorized, err)
+		return
+	}
+
 	params := mux.Vars(r)
 
-	postID, err := strconv.ParseUint(params["postID"], 10, 64)
+	postID, err := parseID(params, "postID")
 	if err != nil {
-		response.Error(w, http.StatusBadRequest, err)
+		response.Error(w, r, http.StatusBadRequest, err)
 		return
 	}
 
-	err = controller.postRepository.LikePost(postID)
+	post, err := controller.postRepository.FindByID(r.Context(), postID, userID)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		response.Error(w, r, repositoryErrorStatus(err), err)
 		return
 	}
 
+	err = controller.postRepository.LikePost(r.Context(), postID, userID)
+	if err != nil {
+		response.Error(w, r, repositoryErrorStatus(err), err)
+		return
+	}
+
+	notify(r.Context(), controller.notificationRepository, controller.publisher, model.Notification{
+		UserID:  post.AuthorID,
+		ActorID: userID,
+		Type:    model.NotificationLike,
+		PostID:  &postID,
+	})
+
 	response.JSON(w, http.StatusNoContent, nil)
 
 }
 
-// DeslikePost decreases the number of likes in a post
+// DeslikePost removes the like of the authenticated user from a post
 func (controller PostController) DeslikePost(w http.ResponseWriter, r *http.Request) {
+	userID, err := authentication.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, r, http.StatusUnauthorized, err)
+		return
+	}
+
 	params := mux.Vars(r)
 
-	postID, err := strconv.ParseUint(params["postID"], 10, 64)
+	postID, err := parseID(params, "postID")
 	if err != nil {
-		response.Error(w, http.StatusBadRequest, err)
+		response.Error(w, r, http.StatusBadRequest, err)
 		return
 	}
 
-	err = controller.postRepository.DeslikePost(postID)
+	if _, err = controller.postRepository.FindByID(r.Context(), postID, userID); err != nil {
+		response.Error(w, r, repositoryErrorStatus(err), err)
+		return
+	}
+
+	err = controller.postRepository.DeslikePost(r.Context(), postID, userID)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		response.Error(w, r, repositoryErrorStatus(err), err)
 		return
 	}
 
 	response.JSON(w, http.StatusNoContent, nil)
 
 }
+
+// FindLikes returns a page of the users that liked a post
+func (controller PostController) FindLikes(w http.ResponseWriter, r *http.Request) {
+	userID, err := authentication.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, r, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+
+	postID, err := parseID(params, "postID")
+	if err != nil {
+		response.Error(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		response.Error(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if _, err = controller.postRepository.FindByID(r.Context(), postID, userID); err != nil {
+		response.Error(w, r, repositoryErrorStatus(err), err)
+		return
+	}
+
+	users, err := controller.postRepository.FindLikes(r.Context(), postID, page)
+	if err != nil {
+		response.Error(w, r, repositoryErrorStatus(err), err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, users)
+}
+
+// Thread returns the conversation tree below a post
+func (controller PostController) Thread(w http.ResponseWriter, r *http.Request) {
+	userID, err := authentication.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, r, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+
+	postID, err := parseID(params, "postID")
+	if err != nil {
+		response.Error(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	posts, err := controller.postRepository.Thread(r.Context(), postID, userID)
+	if err != nil {
+		response.Error(w, r, repositoryErrorStatus(err), err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, model.NewPostThread(posts, postID))
+}
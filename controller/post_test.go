@@ -8,13 +8,14 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
-	"github.com/waliqueiroz/devbook-api/authentication"
 	"github.com/waliqueiroz/devbook-api/controller"
 	"github.com/waliqueiroz/devbook-api/model"
+	"github.com/waliqueiroz/devbook-api/stream"
 	"github.com/waliqueiroz/devbook-api/test/mock"
 )
 
@@ -24,36 +25,56 @@ func TestIndex(t *testing.T) {
 	var expectedPostList []model.Post
 	json.Unmarshal(expectedPostListJson, &expectedPostList)
 
+	expectedPostPage := model.PostPage{Data: expectedPostList}
+
 	userID := uint64(1)
-	token, _ := authentication.CreateToken(userID)
 
 	subTests := []struct {
 		name               string
 		expectedStatusCode int
-		expectedResponse   []model.Post
-		token              string
+		query              string
+		expectedResponse   model.PostPage
+		userID             uint64
 	}{
 		{
 			name:               "Get posts",
 			expectedStatusCode: http.StatusOK,
-			expectedResponse:   expectedPostList,
-			token:              token,
+			expectedResponse:   expectedPostPage,
+			userID:             userID,
+		},
+		{
+			name:               "Get posts with a cursor",
+			query:              "?limit=10&cursor=" + model.EncodeCursor(5),
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   expectedPostPage,
+			userID:             userID,
+		},
+		{
+			name:               "Get posts with an invalid cursor",
+			query:              "?cursor=teste",
+			expectedStatusCode: http.StatusBadRequest,
+			userID:             userID,
 		},
 		{
-			name:               "Get posts with invalid token",
+			name:               "Get posts with an invalid limit",
+			query:              "?limit=1000",
+			expectedStatusCode: http.StatusBadRequest,
+			userID:             userID,
+		},
+		{
+			name:               "Get posts without authentication",
 			expectedStatusCode: http.StatusUnauthorized,
-			token:              "teste=",
 		},
 	}
 
 	postRepository := mock.NewPostRepository()
-	postController := controller.NewPostController(postRepository)
+	postController := controller.NewPostController(postRepository, mock.NewNotificationRepository(), stream.NewHub())
 
 	for _, subTest := range subTests {
 		t.Run(subTest.name, func(t *testing.T) {
-			request := httptest.NewRequest("GET", "/posts", nil)
+			request := httptest.NewRequest("GET", "/posts"+subTest.query, nil)
 			request.Header.Add("Content-Type", "application/json")
-			request.Header.Add("Authorization", fmt.Sprintf("Bearer %s", subTest.token))
+			request = mock.Authenticate(request, subTest.userID)
 
 			response := httptest.NewRecorder()
 
@@ -62,9 +83,112 @@ func TestIndex(t *testing.T) {
 			assert.Equal(t, subTest.expectedStatusCode, response.Code, "Status code does not match with expected")
 
 			if subTest.expectedStatusCode == http.StatusOK {
-				var postList []model.Post
-				json.Unmarshal(response.Body.Bytes(), &postList)
-				assert.Equal(t, subTest.expectedResponse, postList, "Post list does not match with expected")
+				var postPage model.PostPage
+				json.Unmarshal(response.Body.Bytes(), &postPage)
+				assert.Equal(t, subTest.expectedResponse, postPage, "Post page does not match with expected")
+			} else {
+				assert.NotEmpty(t, response.Body.String(), "Response body is empty")
+			}
+		})
+	}
+}
+
+func TestSearchPosts(t *testing.T) {
+	storedPostListJson, _ := ioutil.ReadFile("../test/resource/json/stored_post_list.json")
+
+	var storedPostList []model.Post
+	json.Unmarshal(storedPostListJson, &storedPostList)
+
+	expectedSearchPage := model.PostSearchPage{
+		Data: []model.PostSearchResult{
+			{Post: storedPostList[0], Score: 1, Snippet: "Essa é a <mark>publicação</mark> do Usuário 1! Oba!"},
+		},
+	}
+
+	userID := uint64(1)
+
+	subTests := []struct {
+		name               string
+		expectedStatusCode int
+		query              string
+		expectedResponse   model.PostSearchPage
+		userID             uint64
+	}{
+		{
+			name:               "Search posts",
+			query:              "?q=publicação",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   expectedSearchPage,
+			userID:             userID,
+		},
+		{
+			name:               "Search posts with filters",
+			query:              "?q=publicação&author=1&from=2021-04-01&until=2021-04-30T23:59:59Z&limit=10&cursor=" + model.EncodeSearchCursor(0.5, 10),
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   expectedSearchPage,
+			userID:             userID,
+		},
+		{
+			name:               "Search posts without a query",
+			query:              "?q=%20",
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			userID:             userID,
+		},
+		{
+			name:               "Search posts with an invalid author",
+			query:              "?q=publicação&author=abc",
+			expectedStatusCode: http.StatusBadRequest,
+			userID:             userID,
+		},
+		{
+			name:               "Search posts with an invalid date",
+			query:              "?q=publicação&from=06/04/2021",
+			expectedStatusCode: http.StatusBadRequest,
+			userID:             userID,
+		},
+		{
+			name:               "Search posts with an inverted date range",
+			query:              "?q=publicação&from=2021-04-30&until=2021-04-01",
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			userID:             userID,
+		},
+		{
+			name:               "Search posts with an invalid cursor",
+			query:              "?q=publicação&cursor=teste",
+			expectedStatusCode: http.StatusBadRequest,
+			userID:             userID,
+		},
+		{
+			name:               "Search posts with a cursor of another list",
+			query:              "?q=publicação&cursor=" + model.EncodeCursor(10),
+			expectedStatusCode: http.StatusBadRequest,
+			userID:             userID,
+		},
+		{
+			name:               "Search posts without authentication",
+			query:              "?q=publicação",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	postRepository := mock.NewPostRepository()
+	postController := controller.NewPostController(postRepository, mock.NewNotificationRepository(), stream.NewHub())
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			request := httptest.NewRequest("GET", "/posts/search"+subTest.query, nil)
+			request = mock.Authenticate(request, subTest.userID)
+
+			response := httptest.NewRecorder()
+
+			postController.Search(response, request)
+
+			assert.Equal(t, subTest.expectedStatusCode, response.Code, "Status code does not match with expected")
+
+			if subTest.expectedStatusCode == http.StatusOK {
+				var searchPage model.PostSearchPage
+				json.Unmarshal(response.Body.Bytes(), &searchPage)
+				assert.Equal(t, subTest.expectedResponse, searchPage, "Search page does not match with expected")
 			} else {
 				assert.NotEmpty(t, response.Body.String(), "Response body is empty")
 			}
@@ -85,22 +209,36 @@ func TestShowPost(t *testing.T) {
 		routeVariable      string
 		expectedStatusCode int
 		expectedResponse   model.Post
+		userID             uint64
 	}{
 		{
 			name:               "Get post with a valid user ID",
 			routeVariable:      fmt.Sprintf("%d", userID),
 			expectedStatusCode: http.StatusOK,
 			expectedResponse:   expectedPost,
+			userID:             userID,
 		},
 		{
 			name:               "Get post with an invalid user ID",
 			routeVariable:      "teste",
 			expectedStatusCode: http.StatusBadRequest,
+			userID:             userID,
+		},
+		{
+			name:               "Get post without authentication",
+			routeVariable:      fmt.Sprintf("%d", userID),
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "Get post that does not exist",
+			routeVariable:      fmt.Sprintf("%d", mock.UnknownID),
+			expectedStatusCode: http.StatusNotFound,
+			userID:             userID,
 		},
 	}
 
 	postRepository := mock.NewPostRepository()
-	postController := controller.NewPostController(postRepository)
+	postController := controller.NewPostController(postRepository, mock.NewNotificationRepository(), stream.NewHub())
 
 	for _, subTest := range subTests {
 		t.Run(subTest.name, func(t *testing.T) {
@@ -109,6 +247,7 @@ func TestShowPost(t *testing.T) {
 				"postID": subTest.routeVariable,
 			})
 			request.Header.Add("Content-Type", "application/json")
+			request = mock.Authenticate(request, subTest.userID)
 
 			response := httptest.NewRecorder()
 
@@ -131,6 +270,9 @@ func TestCreatePost(t *testing.T) {
 	postInputJson, _ := ioutil.ReadFile("../test/resource/json/post_input.json")
 	invalidPostInputJson, _ := ioutil.ReadFile("../test/resource/json/invalid_post_input.json")
 	incompletePostInputJson, _ := ioutil.ReadFile("../test/resource/json/incomplete_post_input.json")
+	replyInputJson, _ := ioutil.ReadFile("../test/resource/json/reply_input.json")
+	replyToUnknownPostInputJson, _ := ioutil.ReadFile("../test/resource/json/reply_to_unknown_post_input.json")
+	repostInputJson, _ := ioutil.ReadFile("../test/resource/json/repost_input.json")
 
 	expectedPostJson, _ := ioutil.ReadFile("../test/resource/json/created_post.json")
 
@@ -138,13 +280,12 @@ func TestCreatePost(t *testing.T) {
 	json.Unmarshal(expectedPostJson, &expectedPost)
 
 	userID := uint64(1)
-	token, _ := authentication.CreateToken(userID)
 
 	subTests := []struct {
 		name               string
 		input              io.Reader
 		expectedStatusCode int
-		token              string
+		userID             uint64
 		expectedResponse   model.Post
 	}{
 		{
@@ -152,42 +293,73 @@ func TestCreatePost(t *testing.T) {
 			input:              bytes.NewReader(postInputJson),
 			expectedStatusCode: http.StatusCreated,
 			expectedResponse:   expectedPost,
-			token:              token,
+			userID:             userID,
 		},
 		{
 			name:               "Create post with invalid body payload",
 			input:              mock.NewReader(),
 			expectedStatusCode: http.StatusUnprocessableEntity,
-			token:              token,
+			userID:             userID,
 		},
 		{
 			name:               "Create post with invalid data",
 			input:              bytes.NewReader(invalidPostInputJson),
 			expectedStatusCode: http.StatusBadRequest,
-			token:              token,
+			userID:             userID,
 		},
 		{
 			name:               "Create post with incomplete data",
 			input:              bytes.NewReader(incompletePostInputJson),
-			expectedStatusCode: http.StatusBadRequest,
-			token:              token,
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			userID:             userID,
+		},
+		{
+			name:               "Create post with nothing but HTML in the content",
+			input:              strings.NewReader(`{"title": "Título", "content": "<script>alert(1)</script><b></b>\u0007"}`),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			userID:             userID,
+		},
+		{
+			name:               "Create post with a title that is too long",
+			input:              strings.NewReader(`{"title": "` + strings.Repeat("T", 256) + `", "content": "Conteúdo"}`),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			userID:             userID,
 		},
 		{
-			name:               "Create post with invalid token",
+			name:               "Create post without authentication",
 			input:              bytes.NewReader(postInputJson),
 			expectedStatusCode: http.StatusUnauthorized,
-			token:              "teste=",
+		},
+		{
+			name:               "Reply to a post",
+			input:              bytes.NewReader(replyInputJson),
+			expectedStatusCode: http.StatusCreated,
+			expectedResponse:   expectedPost,
+			userID:             userID,
+		},
+		{
+			name:               "Reply to a post that does not exist",
+			input:              bytes.NewReader(replyToUnknownPostInputJson),
+			expectedStatusCode: http.StatusNotFound,
+			userID:             userID,
+		},
+		{
+			name:               "Repost a post",
+			input:              bytes.NewReader(repostInputJson),
+			expectedStatusCode: http.StatusCreated,
+			expectedResponse:   expectedPost,
+			userID:             userID,
 		},
 	}
 
 	postRepository := mock.NewPostRepository()
-	postController := controller.NewPostController(postRepository)
+	postController := controller.NewPostController(postRepository, mock.NewNotificationRepository(), stream.NewHub())
 
 	for _, subTest := range subTests {
 		t.Run(subTest.name, func(t *testing.T) {
 			request := httptest.NewRequest("POST", "/posts", subTest.input)
 			request.Header.Add("Content-Type", "application/json")
-			request.Header.Add("Authorization", fmt.Sprintf("Bearer %s", subTest.token))
+			request = mock.Authenticate(request, subTest.userID)
 
 			response := httptest.NewRecorder()
 
@@ -216,62 +388,90 @@ func TestUpdatePost(t *testing.T) {
 	json.Unmarshal(expectedUserJson, &expectedUser)
 
 	postID := 1
-	token, _ := authentication.CreateToken(1)
-	anotherUserToken, _ := authentication.CreateToken(2)
 
 	subTests := []struct {
 		name               string
 		input              io.Reader
 		routeVariable      string
+		ifMatch            string
 		expectedStatusCode int
-		token              string
+		userID             uint64
 	}{
 		{
 			name:               "Update post with valid data",
 			input:              bytes.NewReader(postInputJson),
 			routeVariable:      fmt.Sprintf("%d", postID),
 			expectedStatusCode: http.StatusNoContent,
-			token:              token,
+			userID:             1,
 		},
 		{
-			name:               "Update post with an invalid authorization token",
+			name:               "Update post without authentication",
 			input:              bytes.NewReader(postInputJson),
 			routeVariable:      fmt.Sprintf("%d", postID),
 			expectedStatusCode: http.StatusUnauthorized,
-			token:              "teste=",
 		},
 		{
 			name:               "Update post with an invalid post ID",
 			input:              bytes.NewReader(postInputJson),
 			routeVariable:      "teste",
 			expectedStatusCode: http.StatusBadRequest,
-			token:              token,
+			userID:             1,
 		},
 		{
 			name:               "Update post with invalid body payload",
 			input:              mock.NewReader(),
 			routeVariable:      fmt.Sprintf("%d", postID),
 			expectedStatusCode: http.StatusUnprocessableEntity,
-			token:              token,
+			userID:             1,
 		},
 		{
 			name:               "Try to update a post that is not yours",
 			input:              bytes.NewReader(postInputJson),
 			routeVariable:      fmt.Sprintf("%d", postID),
 			expectedStatusCode: http.StatusForbidden,
-			token:              anotherUserToken,
+			userID:             2,
 		},
 		{
 			name:               "Update post with invalid data",
 			input:              bytes.NewReader(invalidPostInputJson),
 			routeVariable:      fmt.Sprintf("%d", postID),
 			expectedStatusCode: http.StatusBadRequest,
-			token:              token,
+			userID:             1,
+		},
+		{
+			name:               "Update post without title and content",
+			input:              strings.NewReader(`{"title": "", "content": "  "}`),
+			routeVariable:      fmt.Sprintf("%d", postID),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			userID:             1,
+		},
+		{
+			name:               "Update post with the current ETag",
+			input:              bytes.NewReader(postInputJson),
+			routeVariable:      fmt.Sprintf("%d", postID),
+			ifMatch:            `"0"`,
+			expectedStatusCode: http.StatusNoContent,
+			userID:             1,
+		},
+		{
+			name:               "Update post with an outdated ETag",
+			input:              bytes.NewReader(postInputJson),
+			routeVariable:      fmt.Sprintf("%d", postID),
+			ifMatch:            `"7"`,
+			expectedStatusCode: http.StatusPreconditionFailed,
+			userID:             1,
+		},
+		{
+			name:               "Update post that does not exist",
+			input:              bytes.NewReader(postInputJson),
+			routeVariable:      fmt.Sprintf("%d", mock.UnknownID),
+			expectedStatusCode: http.StatusNotFound,
+			userID:             1,
 		},
 	}
 
 	postRepository := mock.NewPostRepository()
-	postController := controller.NewPostController(postRepository)
+	postController := controller.NewPostController(postRepository, mock.NewNotificationRepository(), stream.NewHub())
 
 	for _, subTest := range subTests {
 		t.Run(subTest.name, func(t *testing.T) {
@@ -280,7 +480,11 @@ func TestUpdatePost(t *testing.T) {
 				"postID": subTest.routeVariable,
 			})
 			request.Header.Add("Content-Type", "application/json")
-			request.Header.Add("Authorization", fmt.Sprintf("Bearer %s", subTest.token))
+			request = mock.Authenticate(request, subTest.userID)
+
+			if subTest.ifMatch != "" {
+				request.Header.Add("If-Match", subTest.ifMatch)
+			}
 
 			response := httptest.NewRecorder()
 
@@ -290,50 +494,140 @@ func TestUpdatePost(t *testing.T) {
 
 			if subTest.expectedStatusCode != http.StatusNoContent {
 				assert.NotEmpty(t, response.Body.String(), "Response body is empty")
+			} else {
+				assert.Equal(t, `"1"`, response.Header().Get("ETag"), "ETag does not match with expected")
 			}
 		})
 	}
 }
 
+func TestPatchPost(t *testing.T) {
+	subTests := []struct {
+		name               string
+		input              string
+		contentType        string
+		ifMatch            string
+		expectedStatusCode int
+		userID             uint64
+	}{
+		{
+			name:               "Patch the content of a post",
+			input:              `{"content": "Só o conteúdo mudou"}`,
+			contentType:        "application/merge-patch+json",
+			expectedStatusCode: http.StatusNoContent,
+			userID:             1,
+		},
+		{
+			name:               "Patch a post sent as plain JSON",
+			input:              `{"title": "Novo título"}`,
+			contentType:        "application/json",
+			expectedStatusCode: http.StatusNoContent,
+			userID:             1,
+		},
+		{
+			name:               "Patch a post clearing its title",
+			input:              `{"title": null}`,
+			contentType:        "application/merge-patch+json",
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			userID:             1,
+		},
+		{
+			name:               "Patch a post with something other than an object",
+			input:              `["title"]`,
+			contentType:        "application/merge-patch+json",
+			expectedStatusCode: http.StatusBadRequest,
+			userID:             1,
+		},
+		{
+			name:               "Patch a post with an unsupported content type",
+			input:              `title=Novo`,
+			contentType:        "application/x-www-form-urlencoded",
+			expectedStatusCode: http.StatusUnsupportedMediaType,
+			userID:             1,
+		},
+		{
+			name:               "Patch a post with an outdated ETag",
+			input:              `{"content": "Só o conteúdo mudou"}`,
+			contentType:        "application/merge-patch+json",
+			ifMatch:            `"3", W/"0"`,
+			expectedStatusCode: http.StatusPreconditionFailed,
+			userID:             1,
+		},
+		{
+			name:               "Patch a post that is not yours",
+			input:              `{"content": "Só o conteúdo mudou"}`,
+			contentType:        "application/merge-patch+json",
+			expectedStatusCode: http.StatusForbidden,
+			userID:             2,
+		},
+	}
+
+	postController := controller.NewPostController(mock.NewPostRepository(), mock.NewNotificationRepository(), stream.NewHub())
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			request := httptest.NewRequest("PATCH", "/posts/1", strings.NewReader(subTest.input))
+			request = mux.SetURLVars(request, map[string]string{
+				"postID": "1",
+			})
+			request.Header.Add("Content-Type", subTest.contentType)
+			request = mock.Authenticate(request, subTest.userID)
+
+			if subTest.ifMatch != "" {
+				request.Header.Add("If-Match", subTest.ifMatch)
+			}
+
+			response := httptest.NewRecorder()
+
+			postController.Patch(response, request)
+
+			assert.Equal(t, subTest.expectedStatusCode, response.Code, "Status code does not match with expected")
+		})
+	}
+}
+
 func TestDeletePost(t *testing.T) {
 	postID := 1
-	token, _ := authentication.CreateToken(1)
-	anotherUserToken, _ := authentication.CreateToken(2)
 
 	subTests := []struct {
 		name               string
 		routeVariable      string
 		expectedStatusCode int
-		token              string
+		userID             uint64
 	}{
 		{
 			name:               "Delete post",
 			routeVariable:      fmt.Sprintf("%d", postID),
 			expectedStatusCode: http.StatusNoContent,
-			token:              token,
+			userID:             1,
 		},
 		{
-			name:               "Delete post with an invalid authorization token",
+			name:               "Delete post without authentication",
 			routeVariable:      fmt.Sprintf("%d", postID),
 			expectedStatusCode: http.StatusUnauthorized,
-			token:              "teste=",
 		},
 		{
 			name:               "Delete post with an invalid post ID",
 			routeVariable:      "teste",
 			expectedStatusCode: http.StatusBadRequest,
-			token:              token,
+			userID:             1,
 		},
 		{
 			name:               "Try to delete a post that is not yours",
 			routeVariable:      fmt.Sprintf("%d", postID),
 			expectedStatusCode: http.StatusForbidden,
-			token:              anotherUserToken,
+			userID:             2,
+		},
+		{
+			name:               "Delete post that does not exist",
+			routeVariable:      fmt.Sprintf("%d", mock.UnknownID),
+			expectedStatusCode: http.StatusNotFound,
+			userID:             1,
 		},
 	}
 
 	postRepository := mock.NewPostRepository()
-	postController := controller.NewPostController(postRepository)
+	postController := controller.NewPostController(postRepository, mock.NewNotificationRepository(), stream.NewHub())
 
 	for _, subTest := range subTests {
 		t.Run(subTest.name, func(t *testing.T) {
@@ -342,7 +636,7 @@ func TestDeletePost(t *testing.T) {
 				"postID": subTest.routeVariable,
 			})
 			request.Header.Add("Content-Type", "application/json")
-			request.Header.Add("Authorization", fmt.Sprintf("Bearer %s", subTest.token))
+			request = mock.Authenticate(request, subTest.userID)
 
 			response := httptest.NewRecorder()
 
@@ -362,21 +656,35 @@ func TestLikePost(t *testing.T) {
 		name               string
 		routeVariable      string
 		expectedStatusCode int
+		userID             uint64
 	}{
 		{
-			name:               "Delete post",
+			name:               "Like post",
 			routeVariable:      "1",
 			expectedStatusCode: http.StatusNoContent,
+			userID:             1,
 		},
 		{
-			name:               "Delete post with an invalid post ID",
+			name:               "Like post with an invalid post ID",
 			routeVariable:      "teste",
 			expectedStatusCode: http.StatusBadRequest,
+			userID:             1,
+		},
+		{
+			name:               "Like post without authentication",
+			routeVariable:      "1",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "Like post that does not exist",
+			routeVariable:      fmt.Sprintf("%d", mock.UnknownID),
+			expectedStatusCode: http.StatusNotFound,
+			userID:             1,
 		},
 	}
 
 	postRepository := mock.NewPostRepository()
-	postController := controller.NewPostController(postRepository)
+	postController := controller.NewPostController(postRepository, mock.NewNotificationRepository(), stream.NewHub())
 
 	for _, subTest := range subTests {
 		t.Run(subTest.name, func(t *testing.T) {
@@ -385,6 +693,7 @@ func TestLikePost(t *testing.T) {
 				"postID": subTest.routeVariable,
 			})
 			request.Header.Add("Content-Type", "application/json")
+			request = mock.Authenticate(request, subTest.userID)
 
 			response := httptest.NewRecorder()
 
@@ -404,21 +713,35 @@ func TestDeslikePost(t *testing.T) {
 		name               string
 		routeVariable      string
 		expectedStatusCode int
+		userID             uint64
 	}{
 		{
-			name:               "Delete post",
+			name:               "Deslike post",
 			routeVariable:      "1",
 			expectedStatusCode: http.StatusNoContent,
+			userID:             1,
 		},
 		{
-			name:               "Delete post with an invalid post ID",
+			name:               "Deslike post with an invalid post ID",
 			routeVariable:      "teste",
 			expectedStatusCode: http.StatusBadRequest,
+			userID:             1,
+		},
+		{
+			name:               "Deslike post without authentication",
+			routeVariable:      "1",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "Deslike post that does not exist",
+			routeVariable:      fmt.Sprintf("%d", mock.UnknownID),
+			expectedStatusCode: http.StatusNotFound,
+			userID:             1,
 		},
 	}
 
 	postRepository := mock.NewPostRepository()
-	postController := controller.NewPostController(postRepository)
+	postController := controller.NewPostController(postRepository, mock.NewNotificationRepository(), stream.NewHub())
 
 	for _, subTest := range subTests {
 		t.Run(subTest.name, func(t *testing.T) {
@@ -427,6 +750,7 @@ func TestDeslikePost(t *testing.T) {
 				"postID": subTest.routeVariable,
 			})
 			request.Header.Add("Content-Type", "application/json")
+			request = mock.Authenticate(request, subTest.userID)
 
 			response := httptest.NewRecorder()
 
@@ -451,31 +775,54 @@ func TestFindByUser(t *testing.T) {
 		name               string
 		routeVariable      string
 		expectedStatusCode int
-		expectedResponse   []model.Post
+		query              string
+		expectedResponse   model.PostPage
+		userID             uint64
 	}{
 		{
 			name:               "Find posts by user",
 			routeVariable:      "1",
 			expectedStatusCode: http.StatusOK,
-			expectedResponse:   expectedPostList,
+			expectedResponse:   model.PostPage{Data: expectedPostList},
+			userID:             1,
 		},
 		{
 			name:               "Find posts by user with invalid user ID",
 			routeVariable:      "teste",
 			expectedStatusCode: http.StatusBadRequest,
+			userID:             1,
+		},
+		{
+			name:               "Find posts by user with an invalid limit",
+			routeVariable:      "1",
+			query:              "?limit=0",
+			expectedStatusCode: http.StatusBadRequest,
+			userID:             1,
+		},
+		{
+			name:               "Find posts by user without authentication",
+			routeVariable:      "1",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "Find posts by user that does not exist",
+			routeVariable:      fmt.Sprintf("%d", mock.UnknownID),
+			expectedStatusCode: http.StatusNotFound,
+			userID:             1,
 		},
 	}
 
 	postRepository := mock.NewPostRepository()
-	postController := controller.NewPostController(postRepository)
+	postController := controller.NewPostController(postRepository, mock.NewNotificationRepository(), stream.NewHub())
 
 	for _, subTest := range subTests {
 		t.Run(subTest.name, func(t *testing.T) {
-			request := httptest.NewRequest("GET", "/users/"+subTest.routeVariable+"/posts", nil)
+			request := httptest.NewRequest("GET", "/users/"+subTest.routeVariable+"/posts"+subTest.query, nil)
 			request = mux.SetURLVars(request, map[string]string{
 				"userID": subTest.routeVariable,
 			})
 			request.Header.Add("Content-Type", "application/json")
+			request = mock.Authenticate(request, subTest.userID)
 
 			response := httptest.NewRecorder()
 
@@ -484,9 +831,337 @@ func TestFindByUser(t *testing.T) {
 			assert.Equal(t, subTest.expectedStatusCode, response.Code, "Status code does not match with expected")
 
 			if subTest.expectedStatusCode == http.StatusOK {
-				var postList []model.Post
-				json.Unmarshal(response.Body.Bytes(), &postList)
-				assert.Equal(t, subTest.expectedResponse, postList, "Post list does not match with expected")
+				var postPage model.PostPage
+				json.Unmarshal(response.Body.Bytes(), &postPage)
+				assert.Equal(t, subTest.expectedResponse, postPage, "Post page does not match with expected")
+			} else {
+				assert.NotEmpty(t, response.Body.String(), "Response body is empty")
+			}
+		})
+	}
+}
+
+func TestFindLikes(t *testing.T) {
+	expectedUserListJson, _ := ioutil.ReadFile("../test/resource/json/stored_user_list.json")
+
+	var expectedUserList []model.User
+	json.Unmarshal(expectedUserListJson, &expectedUserList)
+
+	userID := uint64(1)
+
+	subTests := []struct {
+		name               string
+		routeVariable      string
+		query              string
+		expectedStatusCode int
+		expectedResponse   model.UserPage
+	}{
+		{
+			name:               "Find post likes",
+			routeVariable:      "1",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   model.UserPage{Data: expectedUserList},
+		},
+		{
+			name:               "Find post likes of a post that does not exist",
+			routeVariable:      fmt.Sprintf("%d", mock.UnknownID),
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "Find post likes with invalid cursor",
+			routeVariable:      "1",
+			query:              "?cursor=!",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Find post likes with invalid post ID",
+			routeVariable:      "teste",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	postRepository := mock.NewPostRepository()
+	postController := controller.NewPostController(postRepository, mock.NewNotificationRepository(), stream.NewHub())
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			request := httptest.NewRequest("GET", "/posts/"+subTest.routeVariable+"/likes"+subTest.query, nil)
+			request = mux.SetURLVars(request, map[string]string{
+				"postID": subTest.routeVariable,
+			})
+			request.Header.Add("Content-Type", "application/json")
+			request = mock.Authenticate(request, userID)
+
+			response := httptest.NewRecorder()
+
+			postController.FindLikes(response, request)
+
+			assert.Equal(t, subTest.expectedStatusCode, response.Code, "Status code does not match with expected")
+
+			if subTest.expectedStatusCode == http.StatusOK {
+				var userPage model.UserPage
+				json.Unmarshal(response.Body.Bytes(), &userPage)
+				assert.Equal(t, subTest.expectedResponse, userPage, "User page does not match with expected")
+			} else {
+				assert.NotEmpty(t, response.Body.String(), "Response body is empty")
+			}
+		})
+	}
+}
+
+func TestThread(t *testing.T) {
+	storedThreadJson, _ := ioutil.ReadFile("../test/resource/json/stored_thread.json")
+
+	var storedThread []model.Post
+	json.Unmarshal(storedThreadJson, &storedThread)
+
+	userID := uint64(1)
+
+	subTests := []struct {
+		name               string
+		postID             string
+		userID             uint64
+		expectedStatusCode int
+		expectedResponse   model.PostThread
+	}{
+		{
+			name:               "Get post thread",
+			postID:             "1",
+			userID:             userID,
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   model.NewPostThread(storedThread, 1),
+		},
+		{
+			name:               "Get post thread with invalid post ID",
+			postID:             "abc",
+			userID:             userID,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Get post thread without authentication",
+			postID:             "1",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "Get thread of a post that does not exist",
+			postID:             fmt.Sprintf("%d", mock.UnknownID),
+			userID:             userID,
+			expectedStatusCode: http.StatusNotFound,
+		},
+	}
+
+	postRepository := mock.NewPostRepository()
+	postController := controller.NewPostController(postRepository, mock.NewNotificationRepository(), stream.NewHub())
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			request := httptest.NewRequest("GET", "/posts/{postID}/thread", nil)
+			request = mux.SetURLVars(request, map[string]string{
+				"postID": subTest.postID,
+			})
+			request = mock.Authenticate(request, subTest.userID)
+
+			response := httptest.NewRecorder()
+
+			postController.Thread(response, request)
+
+			assert.Equal(t, subTest.expectedStatusCode, response.Code, "Status code does not match with expected")
+
+			if subTest.expectedStatusCode == http.StatusOK {
+				var thread model.PostThread
+				json.Unmarshal(response.Body.Bytes(), &thread)
+				assert.Equal(t, subTest.expectedResponse, thread, "Thread does not match with expected")
+				assert.Len(t, thread.Replies, 1)
+			} else {
+				assert.NotEmpty(t, response.Body.String(), "Response body is empty")
+			}
+		})
+	}
+}
+
+func TestFindByTag(t *testing.T) {
+	expectedPostListJson, _ := ioutil.ReadFile("../test/resource/json/stored_post_list.json")
+
+	var expectedPostList []model.Post
+	json.Unmarshal(expectedPostListJson, &expectedPostList)
+
+	subTests := []struct {
+		name               string
+		routeVariable      string
+		expectedStatusCode int
+		query              string
+		expectedResponse   model.PostPage
+		userID             uint64
+	}{
+		{
+			name:               "Find posts by tag",
+			routeVariable:      "golang",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   model.PostPage{Data: expectedPostList},
+			userID:             1,
+		},
+		{
+			name:               "Find posts by an empty tag",
+			routeVariable:      "#",
+			expectedStatusCode: http.StatusBadRequest,
+			userID:             1,
+		},
+		{
+			name:               "Find posts by tag with an invalid limit",
+			routeVariable:      "golang",
+			query:              "?limit=0",
+			expectedStatusCode: http.StatusBadRequest,
+			userID:             1,
+		},
+		{
+			name:               "Find posts by tag without authentication",
+			routeVariable:      "golang",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	postRepository := mock.NewPostRepository()
+	postController := controller.NewPostController(postRepository, mock.NewNotificationRepository(), stream.NewHub())
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			request := httptest.NewRequest("GET", "/tags/"+subTest.routeVariable+"/posts"+subTest.query, nil)
+			request = mux.SetURLVars(request, map[string]string{
+				"tag": subTest.routeVariable,
+			})
+			request = mock.Authenticate(request, subTest.userID)
+
+			response := httptest.NewRecorder()
+
+			postController.FindByTag(response, request)
+
+			assert.Equal(t, subTest.expectedStatusCode, response.Code, "Status code does not match with expected")
+
+			if subTest.expectedStatusCode == http.StatusOK {
+				var postPage model.PostPage
+				json.Unmarshal(response.Body.Bytes(), &postPage)
+				assert.Equal(t, subTest.expectedResponse, postPage, "Post page does not match with expected")
+			} else {
+				assert.NotEmpty(t, response.Body.String(), "Response body is empty")
+			}
+		})
+	}
+}
+
+func TestTrendingTags(t *testing.T) {
+	subTests := []struct {
+		name               string
+		query              string
+		expectedStatusCode int
+		expectedResponse   []model.TrendingTag
+	}{
+		{
+			name:               "Get trending tags",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   []model.TrendingTag{{Tag: "golang", Posts: 2}, {Tag: "devbook", Posts: 1}},
+		},
+		{
+			name:               "Get trending tags of a custom window",
+			query:              "?hours=48&limit=5",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   []model.TrendingTag{{Tag: "golang", Posts: 2}, {Tag: "devbook", Posts: 1}},
+		},
+		{
+			name:               "Get trending tags with an invalid window",
+			query:              "?hours=1000",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Get trending tags with an invalid limit",
+			query:              "?limit=abc",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	postRepository := mock.NewPostRepository()
+	postController := controller.NewPostController(postRepository, mock.NewNotificationRepository(), stream.NewHub())
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			request := httptest.NewRequest("GET", "/tags/trending"+subTest.query, nil)
+
+			response := httptest.NewRecorder()
+
+			postController.TrendingTags(response, request)
+
+			assert.Equal(t, subTest.expectedStatusCode, response.Code, "Status code does not match with expected")
+
+			if subTest.expectedStatusCode == http.StatusOK {
+				var tags []model.TrendingTag
+				json.Unmarshal(response.Body.Bytes(), &tags)
+				assert.Equal(t, subTest.expectedResponse, tags, "Tags do not match with expected")
+			} else {
+				assert.NotEmpty(t, response.Body.String(), "Response body is empty")
+			}
+		})
+	}
+}
+
+func TestFindByMention(t *testing.T) {
+	expectedPostListJson, _ := ioutil.ReadFile("../test/resource/json/stored_post_list.json")
+
+	var expectedPostList []model.Post
+	json.Unmarshal(expectedPostListJson, &expectedPostList)
+
+	subTests := []struct {
+		name               string
+		routeVariable      string
+		expectedStatusCode int
+		expectedResponse   model.PostPage
+		userID             uint64
+	}{
+		{
+			name:               "Find posts that mention a user",
+			routeVariable:      "2",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   model.PostPage{Data: expectedPostList},
+			userID:             1,
+		},
+		{
+			name:               "Find posts that mention a user with invalid user ID",
+			routeVariable:      "teste",
+			expectedStatusCode: http.StatusBadRequest,
+			userID:             1,
+		},
+		{
+			name:               "Find posts that mention a user without authentication",
+			routeVariable:      "2",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "Find posts that mention a user that does not exist",
+			routeVariable:      fmt.Sprintf("%d", mock.UnknownID),
+			expectedStatusCode: http.StatusNotFound,
+			userID:             1,
+		},
+	}
+
+	postRepository := mock.NewPostRepository()
+	postController := controller.NewPostController(postRepository, mock.NewNotificationRepository(), stream.NewHub())
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			request := httptest.NewRequest("GET", "/users/"+subTest.routeVariable+"/mentions", nil)
+			request = mux.SetURLVars(request, map[string]string{
+				"userID": subTest.routeVariable,
+			})
+			request = mock.Authenticate(request, subTest.userID)
+
+			response := httptest.NewRecorder()
+
+			postController.FindByMention(response, request)
+
+			assert.Equal(t, subTest.expectedStatusCode, response.Code, "Status code does not match with expected")
+
+			if subTest.expectedStatusCode == http.StatusOK {
+				var postPage model.PostPage
+				json.Unmarshal(response.Body.Bytes(), &postPage)
+				assert.Equal(t, subTest.expectedResponse, postPage, "Post page does not match with expected")
 			} else {
 				assert.NotEmpty(t, response.Body.String(), "Response body is empty")
 			}
@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/waliqueiroz/devbook-api/model"
+)
+
+const searchDateLayout = "2006-01-02"
+
+// parsePostSearch reads the q, author, from and until query parameters of a request.
+// Dates may be given as a day or as an RFC 3339 timestamp, and a day in until is included in the search
+func parsePostSearch(r *http.Request) (model.PostSearch, error) {
+	query := r.URL.Query()
+
+	search := model.PostSearch{Query: query.Get("q")}
+
+	if author := query.Get("author"); author != "" {
+		authorID, err := strconv.ParseUint(author, 10, 64)
+		if err != nil {
+			return model.PostSearch{}, model.NewBadRequestError("author must be a user ID")
+		}
+
+		search.AuthorID = &authorID
+	}
+
+	if from := query.Get("from"); from != "" {
+		date, _, err := parseSearchDate("from", from)
+		if err != nil {
+			return model.PostSearch{}, err
+		}
+
+		search.From = &date
+	}
+
+	if until := query.Get("until"); until != "" {
+		date, isDay, err := parseSearchDate("until", until)
+		if err != nil {
+			return model.PostSearch{}, err
+		}
+
+		if isDay {
+			date = date.AddDate(0, 0, 1)
+		}
+
+		search.Until = &date
+	}
+
+	if err := search.Prepare(); err != nil {
+		return model.PostSearch{}, err
+	}
+
+	return search, nil
+}
+
+// parseSearchDate parses the day or RFC 3339 timestamp of a parameter, telling which one it was
+func parseSearchDate(name, value string) (time.Time, bool, error) {
+	if date, err := time.Parse(searchDateLayout, value); err == nil {
+		return date, true, nil
+	}
+
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false, model.NewBadRequestError(fmt.Sprintf("%s: dates must be formatted as %s or RFC 3339", name, searchDateLayout))
+	}
+
+	return date, false, nil
+}
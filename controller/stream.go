@@ -0,0 +1,191 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/waliqueiroz/devbook-api/authentication"
+	"github.com/waliqueiroz/devbook-api/config"
+	"github.com/waliqueiroz/devbook-api/interfaces"
+	"github.com/waliqueiroz/devbook-api/logger"
+	"github.com/waliqueiroz/devbook-api/model"
+	"github.com/waliqueiroz/devbook-api/response"
+	"github.com/waliqueiroz/devbook-api/stream"
+)
+
+// reconnectDelay is how long browsers wait before reconnecting to the stream
+const reconnectDelay = 3 * time.Second
+
+type StreamController struct {
+	hub               *stream.Hub
+	userRepository    interfaces.UserRepository
+	sessionRepository interfaces.SessionRepository
+}
+
+// NewStreamController creates a new StreamController
+func NewStreamController(hub *stream.Hub, userRepository interfaces.UserRepository, sessionRepository interfaces.SessionRepository) *StreamController {
+	return &StreamController{
+		hub,
+		userRepository,
+		sessionRepository,
+	}
+}
+
+// Stream pushes to the authenticated user, as server-sent events, the new posts from who they are following
+// and their new notifications. Browsers, whose EventSource cannot send the Authorization header, authenticate
+// with a token from CreateToken in the token query parameter, and get a new one before reconnecting. Clients that reconnect with a Last-Event-ID receive the events they missed.
+// The users followed are read when the stream starts, so following someone takes effect on the next connection.
+// Streams end when the hub is closed, so they do not hold the server up when it shuts down, and when the
+// session that opened them is revoked or their token expires, which is checked on every heartbeat
+func (controller StreamController) Stream(w http.ResponseWriter, r *http.Request) {
+	principal, ok := authentication.PrincipalFromContext(r.Context())
+	if !ok {
+		response.Error(w, r, http.StatusUnauthorized, authentication.ErrUnauthenticated)
+		return
+	}
+
+	userID := principal.UserID
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		response.Error(w, r, http.StatusInternalServerError, model.NewInternalError(errors.New("streaming is not supported")))
+		return
+	}
+
+	lastEventID, err := parseLastEventID(r)
+	if err != nil {
+		response.Error(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	followingIDs, err := controller.userRepository.FollowingIDs(r.Context(), userID)
+	if err != nil {
+		response.Error(w, r, repositoryErrorStatus(err), err)
+		return
+	}
+
+	topics := []string{stream.UserTopic(userID)}
+	for _, followingID := range followingIDs {
+		topics = append(topics, stream.AuthorTopic(followingID))
+	}
+
+	// The stream outlives the server write timeout, which only suits regular requests
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	subscription, missed := controller.hub.Subscribe(topics, lastEventID)
+	defer controller.hub.Unsubscribe(subscription)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", reconnectDelay.Milliseconds())
+
+	for _, event := range missed {
+		if err = writeEvent(w, event); err != nil {
+			return
+		}
+	}
+
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(config.StreamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-controller.hub.Done():
+			return
+		case event := <-subscription.Events:
+			if err = writeEvent(w, event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if !controller.stillAuthenticated(r.Context(), principal) {
+				return
+			}
+
+			if _, err = io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+
+		flusher.Flush()
+	}
+}
+
+// CreateToken issues a short-lived token that opens the stream of the authenticated user, for clients that cannot
+// send the Authorization header. The streams it opens end when the access token used to create it expires
+func (controller StreamController) CreateToken(w http.ResponseWriter, r *http.Request) {
+	principal, ok := authentication.PrincipalFromContext(r.Context())
+	if !ok {
+		response.Error(w, r, http.StatusUnauthorized, authentication.ErrUnauthenticated)
+		return
+	}
+
+	token, expiresAt, err := authentication.CreateStreamToken(principal)
+	if err != nil {
+		response.Error(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, model.StreamToken{Token: token, ExpiresAt: expiresAt})
+}
+
+// stillAuthenticated tells if the token that opened a stream did not expire and its session is still active.
+// Failing to check the session ends the stream too, and the client is authenticated again when it reconnects
+func (controller StreamController) stillAuthenticated(ctx context.Context, principal authentication.Principal) bool {
+	if !time.Now().Before(principal.ExpiresAt) {
+		return false
+	}
+
+	active, err := controller.sessionRepository.IsActive(ctx, principal.SessionID)
+	if err != nil {
+		logger.FromContext(ctx).Error("could not check the session of a stream", "session_id", principal.SessionID, "error", err)
+		return false
+	}
+
+	return active
+}
+
+// parseLastEventID reads the ID of the last event a client received, sent by browsers in the Last-Event-ID
+// header when they reconnect. Clients that cannot set headers may use the last_event_id query parameter
+func parseLastEventID(r *http.Request) (*uint64, error) {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("last_event_id")
+	}
+
+	if value == "" {
+		return nil, nil
+	}
+
+	lastEventID, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return nil, model.NewBadRequestError("last event ID must be a number")
+	}
+
+	return &lastEventID, nil
+}
+
+// writeEvent writes an event in the server-sent events format
+func writeEvent(w io.Writer, event stream.Event) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+
+	return err
+}
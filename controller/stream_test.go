@@ -0,0 +1,210 @@
+package controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/waliqueiroz/devbook-api/authentication"
+	"github.com/waliqueiroz/devbook-api/config"
+	"github.com/waliqueiroz/devbook-api/controller"
+	"github.com/waliqueiroz/devbook-api/model"
+	"github.com/waliqueiroz/devbook-api/stream"
+	"github.com/waliqueiroz/devbook-api/test/mock"
+)
+
+func TestStream(t *testing.T) {
+	config.StreamHeartbeatInterval = 10 * time.Millisecond
+
+	hub := stream.NewHub()
+
+	hub.Publish(stream.UserTopic(1), stream.EventNotification, map[string]string{"type": "follow"})
+	hub.Publish(stream.AuthorTopic(2), stream.EventPost, map[string]string{"title": "followed"})
+	hub.Publish(stream.AuthorTopic(3), stream.EventPost, map[string]string{"title": "not followed"})
+
+	subTests := []struct {
+		name               string
+		lastEventID        string
+		expectedStatusCode int
+		expectedEvents     []string
+		unexpectedEvents   []string
+		userID             uint64
+	}{
+		{
+			name:               "Stream events",
+			expectedStatusCode: http.StatusOK,
+			unexpectedEvents:   []string{"id: 1\n", "id: 2\n"},
+			userID:             1,
+		},
+		{
+			name:               "Stream events after reconnecting",
+			lastEventID:        "0",
+			expectedStatusCode: http.StatusOK,
+			expectedEvents: []string{
+				"id: 1\nevent: notification\ndata: {\"type\":\"follow\"}\n\n",
+				"id: 2\nevent: post\ndata: {\"title\":\"followed\"}\n\n",
+			},
+			unexpectedEvents: []string{"id: 3\n"},
+			userID:           1,
+		},
+		{
+			name:               "Stream events with an invalid last event ID",
+			lastEventID:        "teste",
+			expectedStatusCode: http.StatusBadRequest,
+			userID:             1,
+		},
+		{
+			name:               "Stream events without authentication",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	streamController := controller.NewStreamController(hub, mock.NewUserRepository(), mock.NewSessionRepository())
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			request := httptest.NewRequest("GET", "/stream", nil).WithContext(ctx)
+			if subTest.lastEventID != "" {
+				request.Header.Set("Last-Event-ID", subTest.lastEventID)
+			}
+			request = mock.Authenticate(request, subTest.userID)
+
+			response := httptest.NewRecorder()
+
+			streamController.Stream(response, request)
+
+			assert.Equal(t, subTest.expectedStatusCode, response.Code, "Status code does not match with expected")
+
+			if subTest.expectedStatusCode == http.StatusOK {
+				body := response.Body.String()
+
+				assert.Equal(t, "text/event-stream", response.Header().Get("Content-Type"))
+				assert.Contains(t, body, "retry: 3000\n\n")
+				assert.Contains(t, body, ": heartbeat\n\n")
+
+				for _, event := range subTest.expectedEvents {
+					assert.Contains(t, body, event)
+				}
+
+				for _, event := range subTest.unexpectedEvents {
+					assert.NotContains(t, body, event)
+				}
+			} else {
+				assert.NotEmpty(t, response.Body.String(), "Response body is empty")
+			}
+		})
+	}
+}
+
+func TestStreamEndsWhenHubCloses(t *testing.T) {
+	hub := stream.NewHub()
+	streamController := controller.NewStreamController(hub, mock.NewUserRepository(), mock.NewSessionRepository())
+
+	request := mock.Authenticate(httptest.NewRequest("GET", "/stream", nil), 1)
+	response := httptest.NewRecorder()
+
+	done := make(chan struct{})
+
+	go func() {
+		streamController.Stream(response, request)
+		close(done)
+	}()
+
+	hub.Close()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Stream did not end after the hub was closed")
+	}
+}
+
+func TestStreamEndsWhenAuthenticationLapses(t *testing.T) {
+	config.StreamHeartbeatInterval = 10 * time.Millisecond
+
+	subTests := []struct {
+		name      string
+		principal authentication.Principal
+	}{
+		{
+			name:      "Stream ends when the session is revoked",
+			principal: authentication.Principal{UserID: 1, SessionID: mock.UnknownID, ExpiresAt: time.Now().Add(time.Hour)},
+		},
+		{
+			name:      "Stream ends when the token expires",
+			principal: authentication.Principal{UserID: 1, SessionID: 1, ExpiresAt: time.Now().Add(20 * time.Millisecond)},
+		},
+	}
+
+	streamController := controller.NewStreamController(stream.NewHub(), mock.NewUserRepository(), mock.NewSessionRepository())
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			request := httptest.NewRequest("GET", "/stream", nil)
+			request = request.WithContext(authentication.WithPrincipal(request.Context(), subTest.principal))
+
+			response := httptest.NewRecorder()
+
+			done := make(chan struct{})
+
+			go func() {
+				streamController.Stream(response, request)
+				close(done)
+			}()
+
+			select {
+			case <-done:
+				assert.Equal(t, http.StatusOK, response.Code, "Status code does not match with expected")
+			case <-time.After(time.Second):
+				t.Fatal("Stream did not end after its authentication lapsed")
+			}
+		})
+	}
+}
+
+func TestCreateStreamToken(t *testing.T) {
+	subTests := []struct {
+		name               string
+		userID             uint64
+		expectedStatusCode int
+	}{
+		{
+			name:               "Create a stream token",
+			userID:             1,
+			expectedStatusCode: http.StatusCreated,
+		},
+		{
+			name:               "Create a stream token without authentication",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	streamController := controller.NewStreamController(stream.NewHub(), mock.NewUserRepository(), mock.NewSessionRepository())
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			request := mock.Authenticate(httptest.NewRequest("POST", "/stream/token", nil), subTest.userID)
+			response := httptest.NewRecorder()
+
+			streamController.CreateToken(response, request)
+
+			assert.Equal(t, subTest.expectedStatusCode, response.Code, "Status code does not match with expected")
+
+			if subTest.expectedStatusCode == http.StatusCreated {
+				var streamToken model.StreamToken
+				json.Unmarshal(response.Body.Bytes(), &streamToken)
+
+				principal, err := authentication.ParseStreamToken(streamToken.Token)
+				assert.NoError(t, err)
+				assert.Equal(t, subTest.userID, principal.UserID, "User ID does not match with expected")
+			}
+		})
+	}
+}
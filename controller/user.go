@@ -2,140 +2,235 @@ package controller
 
 import (
 	"encoding/json"
-	"errors"
 	"io/ioutil"
 	"net/http"
-	"strconv"
 	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/waliqueiroz/devbook-api/authentication"
 	"github.com/waliqueiroz/devbook-api/interfaces"
+	"github.com/waliqueiroz/devbook-api/logger"
+	"github.com/waliqueiroz/devbook-api/metrics"
 	"github.com/waliqueiroz/devbook-api/model"
 	"github.com/waliqueiroz/devbook-api/response"
 	"github.com/waliqueiroz/devbook-api/security"
 )
 
 type UserController struct {
-	userRepository interfaces.UserRepository
+	userRepository         interfaces.UserRepository
+	sessionRepository      interfaces.SessionRepository
+	notificationRepository interfaces.NotificationRepository
+	publisher              interfaces.Publisher
+	verificationRepository interfaces.VerificationRepository
+	mailer                 interfaces.Mailer
 }
 
 // NewUserController creates a new UserController
-func NewUserController(userRepository interfaces.UserRepository) *UserController {
+func NewUserController(userRepository interfaces.UserRepository, sessionRepository interfaces.SessionRepository, notificationRepository interfaces.NotificationRepository, publisher interfaces.Publisher, verificationRepository interfaces.VerificationRepository, mailer interfaces.Mailer) *UserController {
 	return &UserController{
 		userRepository,
+		sessionRepository,
+		notificationRepository,
+		publisher,
+		verificationRepository,
+		mailer,
 	}
 }
 
-// Index shows all users
+// Index shows a page of users whose name or nick match the search
 func (controller UserController) Index(w http.ResponseWriter, r *http.Request) {
 	nameOrNick := strings.ToLower(r.URL.Query().Get("user"))
 
-	users, err := controller.userRepository.FindByNameOrNick(nameOrNick)
+	page, err := parsePageRequest(r)
+	if err != nil {
+		response.Error(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	users, err := controller.userRepository.FindByNameOrNick(r.Context(), nameOrNick, page)
 
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		response.Error(w, r, repositoryErrorStatus(err), err)
 		return
 	}
 
 	response.JSON(w, http.StatusOK, users)
 }
 
-// Create an user
+// Create an user. New users cannot log in until they confirm their email through the link sent to it.
+// Failing to send that email does not undo the signup, as the user can ask for it again
 func (controller UserController) Create(w http.ResponseWriter, r *http.Request) {
 	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
-		response.Error(w, http.StatusUnprocessableEntity, err)
+		response.Error(w, r, http.StatusUnprocessableEntity, model.NewUnreadableBodyError(err))
 		return
 	}
 
 	var user model.User
 	err = json.Unmarshal(body, &user)
 	if err != nil {
-		response.Error(w, http.StatusBadRequest, err)
+		response.Error(w, r, http.StatusBadRequest, model.NewInvalidBodyError(err))
 		return
 	}
 
 	if err = user.Prepare("register"); err != nil {
-		response.Error(w, http.StatusBadRequest, err)
+		response.Error(w, r, http.StatusUnprocessableEntity, err)
 		return
 	}
 
-	newUser, err := controller.userRepository.Create(user)
+	newUser, err := controller.userRepository.Create(r.Context(), user)
 
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		response.Error(w, r, repositoryErrorStatus(err), err)
 		return
 	}
 
+	if err = controller.sendVerification(r.Context(), newUser, 0); err != nil {
+		logger.FromContext(r.Context()).Error("could not send the verification email", "user_id", newUser.ID, "error", err)
+	}
+
 	response.JSON(w, http.StatusCreated, newUser)
 }
 
-// Show returns a specific user
+// Availability tells signup forms if a nick and an email can still be used, before the user is created.
+// Values that break the rules are reported along with the code of the problem, as if they were taken
+func (controller UserController) Availability(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	nick, email := query.Get("nick"), query.Get("email")
+	if nick == "" && email == "" {
+		response.Error(w, r, http.StatusBadRequest, model.NewBadRequestError("nick or email must be given"))
+		return
+	}
+
+	availability := model.NewAvailability(nick, email)
+
+	if availability.Nick.Pending() || availability.Email.Pending() {
+		var pendingNick, pendingEmail string
+		if availability.Nick.Pending() {
+			pendingNick = availability.Nick.Value
+		}
+		if availability.Email.Pending() {
+			pendingEmail = availability.Email.Value
+		}
+
+		nickTaken, emailTaken, err := controller.userRepository.Taken(r.Context(), pendingNick, pendingEmail)
+		if err != nil {
+			response.Error(w, r, repositoryErrorStatus(err), err)
+			return
+		}
+
+		if nickTaken && availability.Nick.Pending() {
+			availability.Nick.MarkTaken()
+		}
+		if emailTaken && availability.Email.Pending() {
+			availability.Email.MarkTaken()
+		}
+	}
+
+	response.JSON(w, http.StatusOK, availability)
+}
+
+// Show returns a specific user, with its version in the ETag header
 func (controller UserController) Show(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 
-	userID, err := strconv.ParseUint(params["userID"], 10, 64)
+	userID, err := parseID(params, "userID")
 	if err != nil {
-		response.Error(w, http.StatusBadRequest, err)
+		response.Error(w, r, http.StatusBadRequest, err)
 		return
 	}
 
-	user, err := controller.userRepository.FindByID(userID)
+	user, err := controller.userRepository.FindByID(r.Context(), userID)
 
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		response.Error(w, r, repositoryErrorStatus(err), err)
 		return
 	}
 
+	w.Header().Set("ETag", entityTag(user.Version))
 	response.JSON(w, http.StatusOK, user)
 }
 
-// Updates a user
+// Update replaces the name, nick and email of a user
 func (controller UserController) Update(w http.ResponseWriter, r *http.Request) {
+	controller.update(w, r, decodeReplacement)
+}
+
+// Patch changes only the fields of a user sent in a JSON Merge Patch
+func (controller UserController) Patch(w http.ResponseWriter, r *http.Request) {
+	controller.update(w, r, decodeMergePatch)
+}
+
+// update validates and saves the changes to the authenticated user. When the client sends the ETag
+// it read in If-Match, the user is only updated if it was not changed since then. A new email leaves
+// the account unverified, so a verification email is sent to it
+func (controller UserController) update(w http.ResponseWriter, r *http.Request, decode bodyDecoder) {
 	params := mux.Vars(r)
 
-	userID, err := strconv.ParseUint(params["userID"], 10, 64)
+	userID, err := parseID(params, "userID")
 	if err != nil {
-		response.Error(w, http.StatusBadRequest, err)
+		response.Error(w, r, http.StatusBadRequest, err)
 		return
 	}
 
 	tokenUserID, err := authentication.ExtractUserID(r)
 	if err != nil {
-		response.Error(w, http.StatusUnauthorized, err)
+		response.Error(w, r, http.StatusUnauthorized, err)
 		return
 	}
 
 	if userID != tokenUserID {
-		response.Error(w, http.StatusForbidden, errors.New("is not possible to update an user other than your own"))
+		response.Error(w, r, http.StatusForbidden, model.NewForbiddenError("is not possible to update an user other than your own"))
+		return
+	}
+
+	storedUser, err := controller.userRepository.FindByID(r.Context(), userID)
+	if err != nil {
+		response.Error(w, r, repositoryErrorStatus(err), err)
+		return
+	}
+
+	if err = checkIfMatch(r, storedUser.Version, "user"); err != nil {
+		response.Error(w, r, http.StatusPreconditionFailed, err)
 		return
 	}
 
 	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
-		response.Error(w, http.StatusUnprocessableEntity, err)
+		response.Error(w, r, http.StatusUnprocessableEntity, model.NewUnreadableBodyError(err))
 		return
 	}
 
 	var user model.User
-	err = json.Unmarshal(body, &user)
-	if err != nil {
-		response.Error(w, http.StatusBadRequest, err)
+	if err = decode(r, body, storedUser, &user); err != nil {
+		response.Error(w, r, http.StatusBadRequest, err)
 		return
 	}
 
+	user.Version = storedUser.Version
+
 	if err = user.Prepare("update"); err != nil {
-		response.Error(w, http.StatusBadRequest, err)
+		response.Error(w, r, http.StatusUnprocessableEntity, err)
 		return
 	}
 
-	err = controller.userRepository.Update(userID, user)
+	err = controller.userRepository.Update(r.Context(), userID, user)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		response.Error(w, r, repositoryErrorStatus(err), err)
 		return
 	}
 
+	// The stored email may predate normalization, and the database compares emails ignoring case, so this must too
+	if user.Email != model.NormalizeEmail(storedUser.Email) {
+		user.ID = userID
+
+		if err = controller.sendVerification(r.Context(), user, 0); err != nil {
+			logger.FromContext(r.Context()).Error("could not send the verification email", "user_id", userID, "error", err)
+		}
+	}
+
+	w.Header().Set("ETag", entityTag(user.Version+1))
 	response.JSON(w, http.StatusNoContent, nil)
 }
 
@@ -143,26 +238,26 @@ func (controller UserController) Update(w http.ResponseWriter, r *http.Request)
 func (controller UserController) Delete(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 
-	userID, err := strconv.ParseUint(params["userID"], 10, 64)
+	userID, err := parseID(params, "userID")
 	if err != nil {
-		response.Error(w, http.StatusBadRequest, err)
+		response.Error(w, r, http.StatusBadRequest, err)
 		return
 	}
 
 	tokenUserID, err := authentication.ExtractUserID(r)
 	if err != nil {
-		response.Error(w, http.StatusUnauthorized, err)
+		response.Error(w, r, http.StatusUnauthorized, err)
 		return
 	}
 
 	if userID != tokenUserID {
-		response.Error(w, http.StatusForbidden, errors.New("is not possible to delete an user other than your own"))
+		response.Error(w, r, http.StatusForbidden, model.NewForbiddenError("is not possible to delete an user other than your own"))
 		return
 	}
 
-	err = controller.userRepository.Delete(userID)
+	err = controller.userRepository.Delete(r.Context(), userID)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		response.Error(w, r, repositoryErrorStatus(err), err)
 		return
 	}
 
@@ -173,28 +268,41 @@ func (controller UserController) Delete(w http.ResponseWriter, r *http.Request)
 func (controller UserController) FollowUser(w http.ResponseWriter, r *http.Request) {
 	followerID, err := authentication.ExtractUserID(r)
 	if err != nil {
-		response.Error(w, http.StatusUnauthorized, err)
+		response.Error(w, r, http.StatusUnauthorized, err)
 		return
 	}
 
 	params := mux.Vars(r)
 
-	userID, err := strconv.ParseUint(params["userID"], 10, 64)
+	userID, err := parseID(params, "userID")
 	if err != nil {
-		response.Error(w, http.StatusBadRequest, err)
+		response.Error(w, r, http.StatusBadRequest, err)
 		return
 	}
 
 	if userID == followerID {
-		response.Error(w, http.StatusForbidden, errors.New("is not possible to follow yourself"))
+		response.Error(w, r, http.StatusForbidden, model.NewForbiddenError("is not possible to follow yourself"))
+		return
+	}
+
+	if _, err := controller.userRepository.FindByID(r.Context(), userID); err != nil {
+		response.Error(w, r, repositoryErrorStatus(err), err)
 		return
 	}
 
-	if err := controller.userRepository.Follow(userID, followerID); err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+	if err := controller.userRepository.Follow(r.Context(), userID, followerID); err != nil {
+		response.Error(w, r, repositoryErrorStatus(err), err)
 		return
 	}
 
+	metrics.Follows.Inc()
+
+	notify(r.Context(), controller.notificationRepository, controller.publisher, model.Notification{
+		UserID:  userID,
+		ActorID: followerID,
+		Type:    model.NotificationFollow,
+	})
+
 	response.JSON(w, http.StatusNoContent, nil)
 }
 
@@ -202,123 +310,151 @@ func (controller UserController) FollowUser(w http.ResponseWriter, r *http.Reque
 func (controller UserController) UnfollowUser(w http.ResponseWriter, r *http.Request) {
 	followerID, err := authentication.ExtractUserID(r)
 	if err != nil {
-		response.Error(w, http.StatusUnauthorized, err)
+		response.Error(w, r, http.StatusUnauthorized, err)
 		return
 	}
 
 	params := mux.Vars(r)
 
-	userID, err := strconv.ParseUint(params["userID"], 10, 64)
+	userID, err := parseID(params, "userID")
 	if err != nil {
-		response.Error(w, http.StatusBadRequest, err)
+		response.Error(w, r, http.StatusBadRequest, err)
 		return
 	}
 
 	if userID == followerID {
-		response.Error(w, http.StatusForbidden, errors.New("is not possible to unfollow yourself"))
+		response.Error(w, r, http.StatusForbidden, model.NewForbiddenError("is not possible to unfollow yourself"))
 		return
 	}
 
-	if err := controller.userRepository.Unfollow(userID, followerID); err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+	if _, err := controller.userRepository.FindByID(r.Context(), userID); err != nil {
+		response.Error(w, r, repositoryErrorStatus(err), err)
+		return
+	}
+
+	if err := controller.userRepository.Unfollow(r.Context(), userID, followerID); err != nil {
+		response.Error(w, r, repositoryErrorStatus(err), err)
 		return
 	}
 
 	response.JSON(w, http.StatusNoContent, nil)
 }
 
-// SearchFollowers returns a list of followers for a given user
+// SearchFollowers returns a page of followers for a given user
 func (controller UserController) SearchFollowers(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 
-	userID, err := strconv.ParseUint(params["userID"], 10, 64)
+	userID, err := parseID(params, "userID")
+	if err != nil {
+		response.Error(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	page, err := parsePageRequest(r)
 	if err != nil {
-		response.Error(w, http.StatusBadRequest, err)
+		response.Error(w, r, http.StatusBadRequest, err)
 		return
 	}
 
-	followers, err := controller.userRepository.SearchFollowers(userID)
+	followers, err := controller.userRepository.SearchFollowers(r.Context(), userID, page)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		response.Error(w, r, repositoryErrorStatus(err), err)
 		return
 	}
 
 	response.JSON(w, http.StatusOK, followers)
 }
 
-// SearchFollowing returns a list of users that a given user is following
+// SearchFollowing returns a page of users that a given user is following
 func (controller UserController) SearchFollowing(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 
-	userID, err := strconv.ParseUint(params["userID"], 10, 64)
+	userID, err := parseID(params, "userID")
+	if err != nil {
+		response.Error(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	page, err := parsePageRequest(r)
 	if err != nil {
-		response.Error(w, http.StatusBadRequest, err)
+		response.Error(w, r, http.StatusBadRequest, err)
 		return
 	}
 
-	followers, err := controller.userRepository.SearchFollowing(userID)
+	followers, err := controller.userRepository.SearchFollowing(r.Context(), userID, page)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		response.Error(w, r, repositoryErrorStatus(err), err)
 		return
 	}
 
 	response.JSON(w, http.StatusOK, followers)
 }
 
-// UpdatePassword updates the user password
+// UpdatePassword updates the user password and revokes all of their sessions
 func (controller UserController) UpdatePassword(w http.ResponseWriter, r *http.Request) {
 	tokenUserID, err := authentication.ExtractUserID(r)
 	if err != nil {
-		response.Error(w, http.StatusUnauthorized, err)
+		response.Error(w, r, http.StatusUnauthorized, err)
 		return
 	}
 
 	params := mux.Vars(r)
 
-	userID, err := strconv.ParseUint(params["userID"], 10, 64)
+	userID, err := parseID(params, "userID")
 	if err != nil {
-		response.Error(w, http.StatusBadRequest, err)
+		response.Error(w, r, http.StatusBadRequest, err)
 		return
 	}
 
 	if userID != tokenUserID {
-		response.Error(w, http.StatusForbidden, errors.New("you cannot update a user other than your own"))
+		response.Error(w, r, http.StatusForbidden, model.NewForbiddenError("you cannot update a user other than your own"))
 		return
 	}
 
 	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
-		response.Error(w, http.StatusUnprocessableEntity, err)
+		response.Error(w, r, http.StatusUnprocessableEntity, model.NewUnreadableBodyError(err))
 		return
 	}
 
 	var password model.Password
 	err = json.Unmarshal(body, &password)
 	if err != nil {
-		response.Error(w, http.StatusBadRequest, err)
+		response.Error(w, r, http.StatusBadRequest, model.NewInvalidBodyError(err))
 		return
 	}
 
-	hashedPassword, err := controller.userRepository.FindPassword(userID)
+	if err = password.Validate(); err != nil {
+		response.Error(w, r, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	hashedPassword, err := controller.userRepository.FindPassword(r.Context(), userID)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		response.Error(w, r, repositoryErrorStatus(err), err)
 		return
 	}
 
 	if err := security.Verify(hashedPassword, password.Current); err != nil {
-		response.Error(w, http.StatusUnauthorized, errors.New("the current password does not match the one saved in the database"))
+		response.Error(w, r, http.StatusUnauthorized, model.NewUnauthorizedError("the current password does not match the one saved in the database", nil))
 		return
 	}
 
 	newHasedPassword, err := security.Hash(password.New)
 	if err != nil {
-		response.Error(w, http.StatusBadRequest, err)
+		response.Error(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = controller.userRepository.UpdatePassword(r.Context(), userID, string(newHasedPassword))
+	if err != nil {
+		response.Error(w, r, repositoryErrorStatus(err), err)
 		return
 	}
 
-	err = controller.userRepository.UpdatePassword(userID, string(newHasedPassword))
+	err = controller.sessionRepository.RevokeAllByUser(r.Context(), userID)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		response.Error(w, r, repositoryErrorStatus(err), err)
 		return
 	}
 
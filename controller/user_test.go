@@ -2,19 +2,22 @@ package controller_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
-	"github.com/waliqueiroz/devbook-api/authentication"
 	"github.com/waliqueiroz/devbook-api/controller"
 	"github.com/waliqueiroz/devbook-api/model"
+	"github.com/waliqueiroz/devbook-api/stream"
 	"github.com/waliqueiroz/devbook-api/test/mock"
 )
 
@@ -54,12 +57,38 @@ func TestCreateUser(t *testing.T) {
 		{
 			name:               "Create user with incomplete data",
 			input:              bytes.NewReader(incompleteUserInputJson),
-			expectedStatusCode: http.StatusBadRequest,
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:               "Create user with a full-width nick and an upper case email",
+			input:              strings.NewReader(`{"name": "Juliette", "nick": "ｊｕｌｉｅｔｔｅ", "email": "Juliette@Mail.com", "password": "12345678"}`),
+			expectedStatusCode: http.StatusCreated,
+			expectedResponse:   expectedUser,
+		},
+		{
+			name:               "Create user with spaces in the nick",
+			input:              strings.NewReader(`{"name": "Juliette", "nick": "juliette freire", "email": "juliette@mail.com", "password": "12345678"}`),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:               "Create user with a reserved nick",
+			input:              strings.NewReader(`{"name": "Juliette", "nick": "Admin", "email": "juliette@mail.com", "password": "12345678"}`),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:               "Create user with a name that is too long",
+			input:              strings.NewReader(`{"name": "` + strings.Repeat("J", 51) + `", "nick": "juliette", "email": "juliette@mail.com", "password": "12345678"}`),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:               "Create user with a password longer than bcrypt takes",
+			input:              strings.NewReader(`{"name": "Juliette", "nick": "juliette", "email": "juliette@mail.com", "password": "` + strings.Repeat("1", 73) + `"}`),
+			expectedStatusCode: http.StatusUnprocessableEntity,
 		},
 	}
 
 	userRepository := mock.NewUserRepository()
-	userController := controller.NewUserController(userRepository)
+	userController := controller.NewUserController(userRepository, mock.NewSessionRepository(), mock.NewNotificationRepository(), stream.NewHub(), mock.NewVerificationRepository(), mock.NewMailer())
 
 	for _, subTest := range subTests {
 		t.Run(subTest.name, func(t *testing.T) {
@@ -91,7 +120,7 @@ func TestFindUsers(t *testing.T) {
 	json.Unmarshal(expectedUserListJson, &expectedUserList)
 
 	userRepository := mock.NewUserRepository()
-	userController := controller.NewUserController(userRepository)
+	userController := controller.NewUserController(userRepository, mock.NewSessionRepository(), mock.NewNotificationRepository(), stream.NewHub(), mock.NewVerificationRepository(), mock.NewMailer())
 
 	request := httptest.NewRequest("GET", "/users?user=Juliette", nil)
 	request.Header.Add("Content-Type", "application/json")
@@ -100,11 +129,81 @@ func TestFindUsers(t *testing.T) {
 
 	userController.Index(response, request)
 
-	var userList []model.User
-	json.Unmarshal(response.Body.Bytes(), &userList)
+	var userPage model.UserPage
+	json.Unmarshal(response.Body.Bytes(), &userPage)
 
 	assert.Equal(t, http.StatusOK, response.Code, "Status code does not match with expected")
-	assert.Equal(t, expectedUserList, userList, "User list does not match with expected")
+	assert.Equal(t, model.UserPage{Data: expectedUserList}, userPage, "User page does not match with expected")
+}
+
+func TestUserAvailability(t *testing.T) {
+	subTests := []struct {
+		name                 string
+		query                string
+		expectedStatusCode   int
+		expectedAvailability model.Availability
+	}{
+		{
+			name:               "Availability of a free nick and email",
+			query:              "nick=romeo&email=romeo@mail.com",
+			expectedStatusCode: http.StatusOK,
+			expectedAvailability: model.Availability{
+				Nick:  &model.ValueAvailability{Value: "romeo", Available: true},
+				Email: &model.ValueAvailability{Value: "romeo@mail.com", Available: true},
+			},
+		},
+		{
+			name:               "Availability of a taken nick and email written differently",
+			query:              "nick=Juliette&email=Juliette%40Mail.com",
+			expectedStatusCode: http.StatusOK,
+			expectedAvailability: model.Availability{
+				Nick:  &model.ValueAvailability{Value: "juliette", Code: model.FieldTaken},
+				Email: &model.ValueAvailability{Value: "juliette@mail.com", Code: model.FieldTaken},
+			},
+		},
+		{
+			name:               "Availability of a reserved nick only",
+			query:              "nick=admin",
+			expectedStatusCode: http.StatusOK,
+			expectedAvailability: model.Availability{
+				Nick: &model.ValueAvailability{Value: "admin", Code: model.FieldReserved},
+			},
+		},
+		{
+			name:               "Availability of an invalid email only",
+			query:              "email=juliette",
+			expectedStatusCode: http.StatusOK,
+			expectedAvailability: model.Availability{
+				Email: &model.ValueAvailability{Value: "juliette", Code: model.FieldInvalidEmail},
+			},
+		},
+		{
+			name:               "Availability without nick and email",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	userRepository := mock.NewUserRepository()
+	userController := controller.NewUserController(userRepository, mock.NewSessionRepository(), mock.NewNotificationRepository(), stream.NewHub(), mock.NewVerificationRepository(), mock.NewMailer())
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			request := httptest.NewRequest("GET", "/users/availability?"+subTest.query, nil)
+
+			response := httptest.NewRecorder()
+
+			userController.Availability(response, request)
+
+			assert.Equal(t, subTest.expectedStatusCode, response.Code, "Status code does not match with expected")
+
+			if subTest.expectedStatusCode == http.StatusOK {
+				var availability model.Availability
+				json.Unmarshal(response.Body.Bytes(), &availability)
+
+				assert.Equal(t, subTest.expectedAvailability, availability, "Availability does not match with expected")
+			}
+		})
+	}
 }
 
 func TestShowUser(t *testing.T) {
@@ -118,6 +217,8 @@ func TestShowUser(t *testing.T) {
 		routeVariable      string
 		expectedStatusCode int
 		expectedResponse   model.User
+		timedOut           bool
+		cancelled          bool
 	}{
 		{
 			name:               "Get user with a valid user ID",
@@ -130,10 +231,27 @@ func TestShowUser(t *testing.T) {
 			routeVariable:      "teste",
 			expectedStatusCode: http.StatusBadRequest,
 		},
+		{
+			name:               "Get user that does not exist",
+			routeVariable:      fmt.Sprintf("%d", mock.UnknownID),
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "Get user when the database query times out",
+			routeVariable:      "1",
+			expectedStatusCode: http.StatusServiceUnavailable,
+			timedOut:           true,
+		},
+		{
+			name:               "Get user when the client goes away",
+			routeVariable:      "1",
+			expectedStatusCode: controller.StatusClientClosedRequest,
+			cancelled:          true,
+		},
 	}
 
 	userRepository := mock.NewUserRepository()
-	userController := controller.NewUserController(userRepository)
+	userController := controller.NewUserController(userRepository, mock.NewSessionRepository(), mock.NewNotificationRepository(), stream.NewHub(), mock.NewVerificationRepository(), mock.NewMailer())
 
 	for _, subTest := range subTests {
 		t.Run(subTest.name, func(t *testing.T) {
@@ -143,6 +261,18 @@ func TestShowUser(t *testing.T) {
 			})
 			request.Header.Add("Content-Type", "application/json")
 
+			if subTest.timedOut {
+				ctx, cancel := context.WithDeadline(request.Context(), time.Now().Add(-time.Second))
+				defer cancel()
+				request = request.WithContext(ctx)
+			}
+
+			if subTest.cancelled {
+				ctx, cancel := context.WithCancel(request.Context())
+				cancel()
+				request = request.WithContext(ctx)
+			}
+
 			response := httptest.NewRecorder()
 
 			userController.Show(response, request)
@@ -171,68 +301,75 @@ func TestUpdateUser(t *testing.T) {
 	json.Unmarshal(expectedUserJson, &expectedUser)
 
 	userID := uint64(1)
-	token, _ := authentication.CreateToken(userID)
 
 	subTests := []struct {
 		name               string
 		input              io.Reader
 		routeVariable      string
+		ifMatch            string
 		expectedStatusCode int
-		token              string
+		userID             uint64
 	}{
 		{
 			name:               "Update user with valid data",
 			input:              bytes.NewReader(userInputJson),
 			routeVariable:      fmt.Sprintf("%d", userID),
 			expectedStatusCode: http.StatusNoContent,
-			token:              token,
+			userID:             userID,
 		},
 		{
-			name:               "Update user with an invalid authorization token",
+			name:               "Update user without authentication",
 			input:              bytes.NewReader(userInputJson),
 			routeVariable:      fmt.Sprintf("%d", userID),
 			expectedStatusCode: http.StatusUnauthorized,
-			token:              "teste=",
 		},
 		{
 			name:               "Update user with an invalid user ID",
 			input:              bytes.NewReader(userInputJson),
 			routeVariable:      "teste",
 			expectedStatusCode: http.StatusBadRequest,
-			token:              token,
+			userID:             userID,
 		},
 		{
 			name:               "Update user with invalid body payload",
 			input:              mock.NewReader(),
 			routeVariable:      fmt.Sprintf("%d", userID),
 			expectedStatusCode: http.StatusUnprocessableEntity,
-			token:              token,
+			userID:             userID,
 		},
 		{
 			name:               "Try to update a user other than your own",
 			input:              bytes.NewReader(userInputJson),
 			routeVariable:      "2",
 			expectedStatusCode: http.StatusForbidden,
-			token:              token,
+			userID:             userID,
 		},
 		{
 			name:               "Update user with invalid data",
 			input:              bytes.NewReader(invalidUserInputJson),
 			routeVariable:      fmt.Sprintf("%d", userID),
 			expectedStatusCode: http.StatusBadRequest,
-			token:              token,
+			userID:             userID,
 		},
 		{
 			name:               "Update user with incomplete data",
 			input:              bytes.NewReader(incompleteUserInputJson),
 			routeVariable:      fmt.Sprintf("%d", userID),
-			expectedStatusCode: http.StatusBadRequest,
-			token:              token,
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			userID:             userID,
+		},
+		{
+			name:               "Update user with an outdated ETag",
+			input:              bytes.NewReader(userInputJson),
+			routeVariable:      fmt.Sprintf("%d", userID),
+			ifMatch:            `"2"`,
+			expectedStatusCode: http.StatusPreconditionFailed,
+			userID:             userID,
 		},
 	}
 
 	userRepository := mock.NewUserRepository()
-	userController := controller.NewUserController(userRepository)
+	userController := controller.NewUserController(userRepository, mock.NewSessionRepository(), mock.NewNotificationRepository(), stream.NewHub(), mock.NewVerificationRepository(), mock.NewMailer())
 
 	for _, subTest := range subTests {
 		t.Run(subTest.name, func(t *testing.T) {
@@ -241,7 +378,11 @@ func TestUpdateUser(t *testing.T) {
 				"userID": subTest.routeVariable,
 			})
 			request.Header.Add("Content-Type", "application/json")
-			request.Header.Add("Authorization", fmt.Sprintf("Bearer %s", subTest.token))
+			request = mock.Authenticate(request, subTest.userID)
+
+			if subTest.ifMatch != "" {
+				request.Header.Add("If-Match", subTest.ifMatch)
+			}
 
 			response := httptest.NewRecorder()
 
@@ -257,45 +398,157 @@ func TestUpdateUser(t *testing.T) {
 
 }
 
-func TestDeleteUser(t *testing.T) {
+func TestPatchUser(t *testing.T) {
+	subTests := []struct {
+		name               string
+		input              string
+		ifMatch            string
+		expectedStatusCode int
+		userID             uint64
+	}{
+		{
+			name:               "Patch the name of a user",
+			input:              `{"name": "Juliette Freire"}`,
+			expectedStatusCode: http.StatusNoContent,
+			userID:             1,
+		},
+		{
+			name:               "Patch a user with the current ETag",
+			input:              `{"nick": "juliette"}`,
+			ifMatch:            `"0"`,
+			expectedStatusCode: http.StatusNoContent,
+			userID:             1,
+		},
+		{
+			name:               "Patch a user with an invalid email",
+			input:              `{"email": "juliette"}`,
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			userID:             1,
+		},
+		{
+			name:               "Patch a user clearing their nick",
+			input:              `{"nick": null}`,
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			userID:             1,
+		},
+		{
+			name:               "Patch a user other than your own",
+			input:              `{"name": "Juliette Freire"}`,
+			expectedStatusCode: http.StatusForbidden,
+			userID:             2,
+		},
+	}
+
+	userController := controller.NewUserController(mock.NewUserRepository(), mock.NewSessionRepository(), mock.NewNotificationRepository(), stream.NewHub(), mock.NewVerificationRepository(), mock.NewMailer())
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			request := httptest.NewRequest("PATCH", "/users/1", strings.NewReader(subTest.input))
+			request = mux.SetURLVars(request, map[string]string{
+				"userID": "1",
+			})
+			request.Header.Add("Content-Type", "application/merge-patch+json")
+			request = mock.Authenticate(request, subTest.userID)
+
+			if subTest.ifMatch != "" {
+				request.Header.Add("If-Match", subTest.ifMatch)
+			}
+
+			response := httptest.NewRecorder()
+
+			userController.Patch(response, request)
+
+			assert.Equal(t, subTest.expectedStatusCode, response.Code, "Status code does not match with expected")
+		})
+	}
+}
+
+func TestUpdateUserEmail(t *testing.T) {
+	subTests := []struct {
+		name             string
+		input            string
+		expectedVerified bool
+		expectedEmailTo  string
+	}{
+		{
+			name:             "Keep the account verified when the email does not change",
+			input:            `{"name": "Juliette Freire"}`,
+			expectedVerified: true,
+		},
+		{
+			name:            "Make the account unverified when the email changes",
+			input:           `{"email": "juliette.freire@mail.com"}`,
+			expectedEmailTo: "juliette.freire@mail.com",
+		},
+	}
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			userRepository := mock.NewUserRepository()
+			mailer := mock.NewMailer()
+			userController := controller.NewUserController(userRepository, mock.NewSessionRepository(), mock.NewNotificationRepository(), stream.NewHub(), mock.NewVerificationRepository(), mailer)
 
+			request := httptest.NewRequest("PATCH", "/users/1", strings.NewReader(subTest.input))
+			request = mux.SetURLVars(request, map[string]string{
+				"userID": "1",
+			})
+			request.Header.Add("Content-Type", "application/merge-patch+json")
+			request = mock.Authenticate(request, 1)
+
+			response := httptest.NewRecorder()
+
+			userController.Patch(response, request)
+
+			assert.Equal(t, http.StatusNoContent, response.Code, "Status code does not match with expected")
+			assert.Equal(t, subTest.expectedVerified, userRepository.Updated().Verified(), "Verification does not match with expected")
+
+			if subTest.expectedEmailTo != "" {
+				if assert.Len(t, mailer.Sent(), 1, "A verification email should be sent") {
+					assert.Equal(t, subTest.expectedEmailTo, mailer.Sent()[0].To, "Verification email was sent to the wrong address")
+				}
+			} else {
+				assert.Empty(t, mailer.Sent(), "No verification email should be sent")
+			}
+		})
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
 	userID := uint64(1)
-	token, _ := authentication.CreateToken(userID)
 
 	subTests := []struct {
 		name               string
 		routeVariable      string
 		expectedStatusCode int
-		token              string
+		userID             uint64
 	}{
 		{
 			name:               "Delete user",
 			routeVariable:      fmt.Sprintf("%d", userID),
 			expectedStatusCode: http.StatusNoContent,
-			token:              token,
+			userID:             userID,
 		},
 		{
-			name:               "Delete user with an invalid token",
+			name:               "Delete user without authentication",
 			routeVariable:      fmt.Sprintf("%d", userID),
 			expectedStatusCode: http.StatusUnauthorized,
-			token:              "teste=",
 		},
 		{
 			name:               "Delete user with an invalid user ID",
 			routeVariable:      "teste",
 			expectedStatusCode: http.StatusBadRequest,
-			token:              token,
+			userID:             userID,
 		},
 		{
 			name:               "Try to delete a user other than your own",
 			routeVariable:      "2",
 			expectedStatusCode: http.StatusForbidden,
-			token:              token,
+			userID:             userID,
 		},
 	}
 
 	userRepository := mock.NewUserRepository()
-	userController := controller.NewUserController(userRepository)
+	userController := controller.NewUserController(userRepository, mock.NewSessionRepository(), mock.NewNotificationRepository(), stream.NewHub(), mock.NewVerificationRepository(), mock.NewMailer())
 
 	for _, subTest := range subTests {
 		t.Run(subTest.name, func(t *testing.T) {
@@ -304,7 +557,7 @@ func TestDeleteUser(t *testing.T) {
 				"userID": subTest.routeVariable,
 			})
 			request.Header.Add("Content-Type", "application/json")
-			request.Header.Add("Authorization", fmt.Sprintf("Bearer %s", subTest.token))
+			request = mock.Authenticate(request, subTest.userID)
 
 			response := httptest.NewRecorder()
 
@@ -320,44 +573,47 @@ func TestDeleteUser(t *testing.T) {
 }
 
 func TestFollowUser(t *testing.T) {
-
 	userID := uint64(1)
-	token, _ := authentication.CreateToken(userID)
 
 	subTests := []struct {
 		name               string
 		routeVariable      string
 		expectedStatusCode int
-		token              string
+		userID             uint64
 	}{
 		{
 			name:               "Follow user",
 			routeVariable:      "2",
 			expectedStatusCode: http.StatusNoContent,
-			token:              token,
+			userID:             userID,
 		},
 		{
-			name:               "Follow user with an invalid token",
+			name:               "Follow user without authentication",
 			routeVariable:      "2",
 			expectedStatusCode: http.StatusUnauthorized,
-			token:              "teste=",
 		},
 		{
 			name:               "Follow user with an invalid user ID",
 			routeVariable:      "teste",
 			expectedStatusCode: http.StatusBadRequest,
-			token:              token,
+			userID:             userID,
 		},
 		{
 			name:               "Try to follow yourself",
 			routeVariable:      fmt.Sprintf("%d", userID),
 			expectedStatusCode: http.StatusForbidden,
-			token:              token,
+			userID:             userID,
+		},
+		{
+			name:               "Follow user that does not exist",
+			routeVariable:      fmt.Sprintf("%d", mock.UnknownID),
+			expectedStatusCode: http.StatusNotFound,
+			userID:             userID,
 		},
 	}
 
 	userRepository := mock.NewUserRepository()
-	userController := controller.NewUserController(userRepository)
+	userController := controller.NewUserController(userRepository, mock.NewSessionRepository(), mock.NewNotificationRepository(), stream.NewHub(), mock.NewVerificationRepository(), mock.NewMailer())
 
 	for _, subTest := range subTests {
 		t.Run(subTest.name, func(t *testing.T) {
@@ -366,7 +622,7 @@ func TestFollowUser(t *testing.T) {
 				"userID": subTest.routeVariable,
 			})
 			request.Header.Add("Content-Type", "application/json")
-			request.Header.Add("Authorization", fmt.Sprintf("Bearer %s", subTest.token))
+			request = mock.Authenticate(request, subTest.userID)
 
 			response := httptest.NewRecorder()
 
@@ -383,42 +639,46 @@ func TestFollowUser(t *testing.T) {
 
 func TestUnfollowUser(t *testing.T) {
 	userID := uint64(1)
-	token, _ := authentication.CreateToken(userID)
 
 	subTests := []struct {
 		name               string
 		routeVariable      string
 		expectedStatusCode int
-		token              string
+		userID             uint64
 	}{
 		{
 			name:               "Unfollow user",
 			routeVariable:      "2",
 			expectedStatusCode: http.StatusNoContent,
-			token:              token,
+			userID:             userID,
 		},
 		{
-			name:               "Unfollow user with an invalid token",
+			name:               "Unfollow user without authentication",
 			routeVariable:      "2",
 			expectedStatusCode: http.StatusUnauthorized,
-			token:              "teste=",
 		},
 		{
 			name:               "Unfollow user with an invalid user ID",
 			routeVariable:      "teste",
 			expectedStatusCode: http.StatusBadRequest,
-			token:              token,
+			userID:             userID,
 		},
 		{
 			name:               "Try to unfollow yourself",
 			routeVariable:      fmt.Sprintf("%d", userID),
 			expectedStatusCode: http.StatusForbidden,
-			token:              token,
+			userID:             userID,
+		},
+		{
+			name:               "Unfollow user that does not exist",
+			routeVariable:      fmt.Sprintf("%d", mock.UnknownID),
+			expectedStatusCode: http.StatusNotFound,
+			userID:             userID,
 		},
 	}
 
 	userRepository := mock.NewUserRepository()
-	userController := controller.NewUserController(userRepository)
+	userController := controller.NewUserController(userRepository, mock.NewSessionRepository(), mock.NewNotificationRepository(), stream.NewHub(), mock.NewVerificationRepository(), mock.NewMailer())
 
 	for _, subTest := range subTests {
 		t.Run(subTest.name, func(t *testing.T) {
@@ -427,7 +687,7 @@ func TestUnfollowUser(t *testing.T) {
 				"userID": subTest.routeVariable,
 			})
 			request.Header.Add("Content-Type", "application/json")
-			request.Header.Add("Authorization", fmt.Sprintf("Bearer %s", subTest.token))
+			request = mock.Authenticate(request, subTest.userID)
 
 			response := httptest.NewRecorder()
 
@@ -452,27 +712,34 @@ func TestSearchFollowers(t *testing.T) {
 		name               string
 		routeVariable      string
 		expectedStatusCode int
-		expectedResponse   []model.User
+		query              string
+		expectedResponse   model.UserPage
 	}{
 		{
 			name:               "Search followers",
 			routeVariable:      "1",
 			expectedStatusCode: http.StatusOK,
-			expectedResponse:   expectedUserList,
+			expectedResponse:   model.UserPage{Data: expectedUserList},
 		},
 		{
 			name:               "Search followers with an invalid user ID",
 			routeVariable:      "teste",
 			expectedStatusCode: http.StatusBadRequest,
 		},
+		{
+			name:               "Search followers with an invalid cursor",
+			routeVariable:      "1",
+			query:              "?cursor=teste",
+			expectedStatusCode: http.StatusBadRequest,
+		},
 	}
 
 	userRepository := mock.NewUserRepository()
-	userController := controller.NewUserController(userRepository)
+	userController := controller.NewUserController(userRepository, mock.NewSessionRepository(), mock.NewNotificationRepository(), stream.NewHub(), mock.NewVerificationRepository(), mock.NewMailer())
 
 	for _, subTest := range subTests {
 		t.Run(subTest.name, func(t *testing.T) {
-			request := httptest.NewRequest("GET", "/users/"+subTest.routeVariable+"/followers", nil)
+			request := httptest.NewRequest("GET", "/users/"+subTest.routeVariable+"/followers"+subTest.query, nil)
 			request = mux.SetURLVars(request, map[string]string{
 				"userID": subTest.routeVariable,
 			})
@@ -485,9 +752,9 @@ func TestSearchFollowers(t *testing.T) {
 			assert.Equal(t, subTest.expectedStatusCode, response.Code, "Status code does not match with expected")
 
 			if subTest.expectedStatusCode == http.StatusOK {
-				var userList []model.User
-				json.Unmarshal(response.Body.Bytes(), &userList)
-				assert.Equal(t, subTest.expectedResponse, userList, "User list does not match with expected")
+				var userPage model.UserPage
+				json.Unmarshal(response.Body.Bytes(), &userPage)
+				assert.Equal(t, subTest.expectedResponse, userPage, "User page does not match with expected")
 			} else {
 				assert.NotEmpty(t, response.Body.String(), "Response body is empty")
 			}
@@ -505,27 +772,34 @@ func TestSearchFollowing(t *testing.T) {
 		name               string
 		routeVariable      string
 		expectedStatusCode int
-		expectedResponse   []model.User
+		query              string
+		expectedResponse   model.UserPage
 	}{
 		{
 			name:               "Search following",
 			routeVariable:      "1",
 			expectedStatusCode: http.StatusOK,
-			expectedResponse:   expectedUserList,
+			expectedResponse:   model.UserPage{Data: expectedUserList},
 		},
 		{
 			name:               "Search following with an invalid user ID",
 			routeVariable:      "teste",
 			expectedStatusCode: http.StatusBadRequest,
 		},
+		{
+			name:               "Search following with an invalid cursor",
+			routeVariable:      "1",
+			query:              "?cursor=teste",
+			expectedStatusCode: http.StatusBadRequest,
+		},
 	}
 
 	userRepository := mock.NewUserRepository()
-	userController := controller.NewUserController(userRepository)
+	userController := controller.NewUserController(userRepository, mock.NewSessionRepository(), mock.NewNotificationRepository(), stream.NewHub(), mock.NewVerificationRepository(), mock.NewMailer())
 
 	for _, subTest := range subTests {
 		t.Run(subTest.name, func(t *testing.T) {
-			request := httptest.NewRequest("GET", "/users/"+subTest.routeVariable+"/following", nil)
+			request := httptest.NewRequest("GET", "/users/"+subTest.routeVariable+"/following"+subTest.query, nil)
 			request = mux.SetURLVars(request, map[string]string{
 				"userID": subTest.routeVariable,
 			})
@@ -538,9 +812,9 @@ func TestSearchFollowing(t *testing.T) {
 			assert.Equal(t, subTest.expectedStatusCode, response.Code, "Status code does not match with expected")
 
 			if subTest.expectedStatusCode == http.StatusOK {
-				var userList []model.User
-				json.Unmarshal(response.Body.Bytes(), &userList)
-				assert.Equal(t, subTest.expectedResponse, userList, "User list does not match with expected")
+				var userPage model.UserPage
+				json.Unmarshal(response.Body.Bytes(), &userPage)
+				assert.Equal(t, subTest.expectedResponse, userPage, "User page does not match with expected")
 			} else {
 				assert.NotEmpty(t, response.Body.String(), "Response body is empty")
 			}
@@ -554,68 +828,66 @@ func TestUpdatePassword(t *testing.T) {
 	invalidUpdatePasswordInputInvalidCredentialsJson, _ := ioutil.ReadFile("../test/resource/json/update_password_input_invalid_credentials.json")
 
 	userID := uint64(1)
-	token, _ := authentication.CreateToken(userID)
 
 	subTests := []struct {
 		name               string
 		input              io.Reader
 		routeVariable      string
 		expectedStatusCode int
-		token              string
+		userID             uint64
 	}{
 		{
 			name:               "Update password",
 			input:              bytes.NewReader(updatePasswordInputJson),
 			routeVariable:      fmt.Sprintf("%d", userID),
 			expectedStatusCode: http.StatusNoContent,
-			token:              token,
+			userID:             userID,
 		},
 		{
-			name:               "Update password with an invalid token",
+			name:               "Update password without authentication",
 			input:              bytes.NewReader(updatePasswordInputJson),
 			routeVariable:      fmt.Sprintf("%d", userID),
 			expectedStatusCode: http.StatusUnauthorized,
-			token:              "teste=",
 		},
 		{
 			name:               "Update password with an invalid user ID",
 			input:              bytes.NewReader(updatePasswordInputJson),
 			routeVariable:      "teste",
 			expectedStatusCode: http.StatusBadRequest,
-			token:              token,
+			userID:             userID,
 		},
 		{
 			name:               "Try to update password from a user other than your own",
 			input:              bytes.NewReader(updatePasswordInputJson),
 			routeVariable:      "2",
 			expectedStatusCode: http.StatusForbidden,
-			token:              token,
+			userID:             userID,
 		},
 		{
 			name:               "Update password with invalid body payload",
 			input:              mock.NewReader(),
 			routeVariable:      fmt.Sprintf("%d", userID),
 			expectedStatusCode: http.StatusUnprocessableEntity,
-			token:              token,
+			userID:             userID,
 		},
 		{
 			name:               "Update password with invalid data",
 			input:              bytes.NewReader(invalidUpdatePasswordInputJson),
 			routeVariable:      fmt.Sprintf("%d", userID),
 			expectedStatusCode: http.StatusBadRequest,
-			token:              token,
+			userID:             userID,
 		},
 		{
 			name:               "Update password with invalid credentials",
 			input:              bytes.NewReader(invalidUpdatePasswordInputInvalidCredentialsJson),
 			routeVariable:      fmt.Sprintf("%d", userID),
 			expectedStatusCode: http.StatusUnauthorized,
-			token:              token,
+			userID:             userID,
 		},
 	}
 
 	userRepository := mock.NewUserRepository()
-	userController := controller.NewUserController(userRepository)
+	userController := controller.NewUserController(userRepository, mock.NewSessionRepository(), mock.NewNotificationRepository(), stream.NewHub(), mock.NewVerificationRepository(), mock.NewMailer())
 
 	for _, subTest := range subTests {
 		t.Run(subTest.name, func(t *testing.T) {
@@ -624,7 +896,7 @@ func TestUpdatePassword(t *testing.T) {
 				"userID": subTest.routeVariable,
 			})
 			request.Header.Add("Content-Type", "application/json")
-			request.Header.Add("Authorization", fmt.Sprintf("Bearer %s", subTest.token))
+			request = mock.Authenticate(request, subTest.userID)
 
 			response := httptest.NewRecorder()
 
@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/waliqueiroz/devbook-api/authentication"
+	"github.com/waliqueiroz/devbook-api/config"
+	"github.com/waliqueiroz/devbook-api/logger"
+	"github.com/waliqueiroz/devbook-api/model"
+	"github.com/waliqueiroz/devbook-api/response"
+	"github.com/waliqueiroz/devbook-api/security"
+)
+
+// Verify confirms the email of a user with the token sent to it. A token only works once
+func (controller UserController) Verify(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		response.Error(w, r, http.StatusUnprocessableEntity, model.NewUnreadableBodyError(err))
+		return
+	}
+
+	var verifyRequest model.VerifyRequest
+	err = json.Unmarshal(body, &verifyRequest)
+	if err != nil {
+		response.Error(w, r, http.StatusBadRequest, model.NewInvalidBodyError(err))
+		return
+	}
+
+	if verifyRequest.Token == "" {
+		response.Error(w, r, http.StatusBadRequest, model.NewBadRequestError("the token is required"))
+		return
+	}
+
+	userID, err := authentication.ParseVerificationToken(verifyRequest.Token)
+	if err != nil {
+		response.Error(w, r, http.StatusBadRequest, model.NewInvalidTokenError(err))
+		return
+	}
+
+	err = controller.verificationRepository.Consume(r.Context(), userID, security.HashToken(verifyRequest.Token))
+	if model.IsNotFound(err) {
+		response.Error(w, r, http.StatusBadRequest, model.NewInvalidTokenError(err))
+		return
+	}
+	if err != nil {
+		response.Error(w, r, repositoryErrorStatus(err), err)
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+// ResendVerification sends a new verification email to an account that was not verified yet, replacing the token sent before.
+// Requests within the cooldown of the last email are ignored. It answers the same whether the account exists, is in the
+// cooldown or the email could not be sent, so it cannot be used to find out which emails are registered
+func (controller UserController) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		response.Error(w, r, http.StatusUnprocessableEntity, model.NewUnreadableBodyError(err))
+		return
+	}
+
+	var resendRequest model.ResendVerificationRequest
+	err = json.Unmarshal(body, &resendRequest)
+	if err != nil {
+		response.Error(w, r, http.StatusBadRequest, model.NewInvalidBodyError(err))
+		return
+	}
+
+	if resendRequest.Email == "" {
+		response.Error(w, r, http.StatusBadRequest, model.NewBadRequestError("the email is required"))
+		return
+	}
+
+	storedUser, err := controller.userRepository.FindByEmail(r.Context(), model.NormalizeEmail(resendRequest.Email))
+	if err != nil {
+		response.Error(w, r, repositoryErrorStatus(err), err)
+		return
+	}
+
+	if storedUser.ID != 0 && !storedUser.Verified() {
+		if err = controller.resendVerification(r.Context(), storedUser.ID); err != nil {
+			logger.FromContext(r.Context()).Error("could not resend the verification email", "user_id", storedUser.ID, "error", err)
+		}
+	}
+
+	response.JSON(w, http.StatusAccepted, nil)
+}
+
+// resendVerification sends a new verification email to a user, unless the last one was sent within the cooldown
+func (controller UserController) resendVerification(ctx context.Context, userID uint64) error {
+	profile, err := controller.userRepository.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	return controller.sendVerification(ctx, profile, config.VerificationResendCooldown)
+}
+
+// sendVerification issues a verification token for a user and emails the link that uses it. Nothing is sent
+// if the last token of the user was issued within the cooldown, which is only used when the user asks for it
+func (controller UserController) sendVerification(ctx context.Context, user model.User, cooldown time.Duration) error {
+	token, expiresAt, err := authentication.CreateVerificationToken(user.ID)
+	if err != nil {
+		return err
+	}
+
+	created, err := controller.verificationRepository.Create(ctx, model.Verification{
+		UserID:    user.ID,
+		TokenHash: security.HashToken(token),
+		ExpiresAt: expiresAt,
+	}, cooldown)
+	if err != nil {
+		return err
+	}
+
+	if !created {
+		return nil
+	}
+
+	link := config.VerificationURL + "?token=" + url.QueryEscape(token)
+
+	return controller.mailer.Send(ctx, model.NewVerificationEmail(user, link, expiresAt))
+}
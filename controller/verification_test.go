@@ -0,0 +1,202 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/waliqueiroz/devbook-api/authentication"
+	"github.com/waliqueiroz/devbook-api/config"
+	"github.com/waliqueiroz/devbook-api/controller"
+	"github.com/waliqueiroz/devbook-api/model"
+	"github.com/waliqueiroz/devbook-api/stream"
+	"github.com/waliqueiroz/devbook-api/test/mock"
+)
+
+func newVerifyingUserController(mailer *mock.MailerMock) *controller.UserController {
+	return controller.NewUserController(mock.NewUserRepository(), mock.NewSessionRepository(), mock.NewNotificationRepository(), stream.NewHub(), mock.NewVerificationRepository(), mailer)
+}
+
+// TestCreateUserSendsVerification checks that a new user receives a link whose token verifies the account
+func TestCreateUserSendsVerification(t *testing.T) {
+	mailer := mock.NewMailer()
+	userController := newVerifyingUserController(mailer)
+
+	request := httptest.NewRequest("POST", "/users", strings.NewReader(`{"name": "Juliette", "nick": "juliette", "email": "juliette@mail.com", "password": "12345678"}`))
+	request.Header.Add("Content-Type", "application/json")
+
+	response := httptest.NewRecorder()
+
+	userController.Create(response, request)
+
+	assert.Equal(t, http.StatusCreated, response.Code, "Status code does not match with expected")
+
+	sent := mailer.Sent()
+	if !assert.Len(t, sent, 1, "A verification email should be sent") {
+		return
+	}
+
+	assert.Equal(t, "juliette@mail.com", sent[0].To, "Verification email sent to the wrong address")
+
+	start := strings.Index(sent[0].Body, config.VerificationURL)
+	if !assert.GreaterOrEqual(t, start, 0, "Verification email does not have the link") {
+		return
+	}
+
+	link, err := url.Parse(strings.Fields(sent[0].Body[start:])[0])
+	assert.NoError(t, err)
+
+	request = httptest.NewRequest("POST", "/users/verify", strings.NewReader(`{"token": "`+link.Query().Get("token")+`"}`))
+	request.Header.Add("Content-Type", "application/json")
+
+	response = httptest.NewRecorder()
+
+	userController.Verify(response, request)
+
+	assert.Equal(t, http.StatusNoContent, response.Code, "The token sent by email should verify the user")
+}
+
+func TestVerifyUser(t *testing.T) {
+	validToken, _, _ := authentication.CreateVerificationToken(1)
+	usedToken, _, _ := authentication.CreateVerificationToken(mock.UnknownID)
+	accessToken, _ := authentication.CreateToken(1, 1)
+
+	subTests := []struct {
+		name               string
+		input              io.Reader
+		expectedStatusCode int
+		expectedErrorCode  string
+	}{
+		{
+			name:               "Verify user with a valid token",
+			input:              strings.NewReader(`{"token": "` + validToken + `"}`),
+			expectedStatusCode: http.StatusNoContent,
+		},
+		{
+			name:               "Verify user with a token that was already used",
+			input:              strings.NewReader(`{"token": "` + usedToken + `"}`),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedErrorCode:  model.CodeInvalidToken,
+		},
+		{
+			name:               "Verify user with an access token",
+			input:              strings.NewReader(`{"token": "` + accessToken + `"}`),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedErrorCode:  model.CodeInvalidToken,
+		},
+		{
+			name:               "Verify user with a forged token",
+			input:              strings.NewReader(`{"token": "` + validToken[:len(validToken)-2] + `"}`),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedErrorCode:  model.CodeInvalidToken,
+		},
+		{
+			name:               "Verify user without a token",
+			input:              strings.NewReader(`{}`),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedErrorCode:  model.CodeBadRequest,
+		},
+		{
+			name:               "Verify user with invalid body payload",
+			input:              mock.NewReader(),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedErrorCode:  model.CodeUnreadableBody,
+		},
+	}
+
+	userController := newVerifyingUserController(mock.NewMailer())
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			request := httptest.NewRequest("POST", "/users/verify", subTest.input)
+			request.Header.Add("Content-Type", "application/json")
+
+			response := httptest.NewRecorder()
+
+			userController.Verify(response, request)
+
+			assert.Equal(t, subTest.expectedStatusCode, response.Code, "Status code does not match with expected")
+
+			if subTest.expectedErrorCode != "" {
+				var body struct {
+					Code string `json:"code"`
+				}
+				json.Unmarshal(response.Body.Bytes(), &body)
+
+				assert.Equal(t, subTest.expectedErrorCode, body.Code, "Error code does not match with expected")
+			}
+		})
+	}
+}
+
+func TestResendVerification(t *testing.T) {
+	subTests := []struct {
+		name               string
+		input              io.Reader
+		expectedStatusCode int
+		expectedSent       int
+	}{
+		{
+			name:               "Resend verification to an unverified user",
+			input:              strings.NewReader(`{"email": "` + strings.ToUpper(mock.UnverifiedEmail) + `"}`),
+			expectedStatusCode: http.StatusAccepted,
+			expectedSent:       1,
+		},
+		{
+			name:               "Resend verification to a verified user",
+			input:              strings.NewReader(`{"email": "juliette@mail.com"}`),
+			expectedStatusCode: http.StatusAccepted,
+		},
+		{
+			name:               "Resend verification without an email",
+			input:              strings.NewReader(`{}`),
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Resend verification with invalid body payload",
+			input:              mock.NewReader(),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			mailer := mock.NewMailer()
+			userController := newVerifyingUserController(mailer)
+
+			request := httptest.NewRequest("POST", "/users/verify/resend", subTest.input)
+			request.Header.Add("Content-Type", "application/json")
+
+			response := httptest.NewRecorder()
+
+			userController.ResendVerification(response, request)
+
+			assert.Equal(t, subTest.expectedStatusCode, response.Code, "Status code does not match with expected")
+			assert.Len(t, mailer.Sent(), subTest.expectedSent, "Number of emails sent does not match with expected")
+		})
+	}
+}
+
+// TestResendVerificationCooldown checks that asking again within the cooldown sends nothing and answers the same
+func TestResendVerificationCooldown(t *testing.T) {
+	mailer := mock.NewMailer()
+	userController := newVerifyingUserController(mailer)
+
+	for i := 0; i < 2; i++ {
+		request := httptest.NewRequest("POST", "/users/verify/resend", strings.NewReader(`{"email": "`+mock.UnverifiedEmail+`"}`))
+		request.Header.Add("Content-Type", "application/json")
+
+		response := httptest.NewRecorder()
+
+		userController.ResendVerification(response, request)
+
+		assert.Equal(t, http.StatusAccepted, response.Code, "Status code does not match with expected")
+	}
+
+	assert.Len(t, mailer.Sent(), 1, "Only the first request should send an email")
+}
@@ -0,0 +1,373 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed migrations/*.sql
+var embeddedMigrations embed.FS
+
+// migrationLock names the MySQL lock that keeps two processes from migrating the database at the same time
+const migrationLock = "devbook_migrations"
+
+// migrationLockTimeout is how many seconds to wait for another process to finish migrating
+const migrationLockTimeout = 60
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// ErrSchemaAhead is returned when the database was migrated by a newer version of the API
+var ErrSchemaAhead = errors.New("the database schema is ahead of this version of the API")
+
+// ErrNotMigrated is returned when the database has no schema_migrations table yet
+var ErrNotMigrated = errors.New("the database was never migrated, run the migrate command")
+
+// ErrPendingMigrations is returned when the database has not been migrated to this version of the API yet
+var ErrPendingMigrations = errors.New("the database schema has pending migrations, run the migrate command")
+
+// Migration represents a versioned change to the database schema
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus tells if a migration was applied to the database
+type MigrationStatus struct {
+	Migration
+	Applied bool
+}
+
+// Migrator applies and rolls back migrations, recording the applied versions in the schema_migrations table
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// NewMigrator creates a migrator with the migrations shipped with the API
+func NewMigrator(db *sql.DB) (*Migrator, error) {
+	migrationsDir, err := fs.Sub(embeddedMigrations, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations, err := LoadMigrations(migrationsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db, migrations}, nil
+}
+
+// NewMigratorWithMigrations creates a migrator with the given migrations
+func NewMigratorWithMigrations(db *sql.DB, migrations []Migration) *Migrator {
+	return &Migrator{db, migrations}
+}
+
+// LoadMigrations reads the migrations of a directory, ordered by version. Files are named like
+// 0001_create_users.up.sql and 0001_create_users.down.sql
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	migrationsByVersion := make(map[uint64]*Migration)
+
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := migrationsByVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			migrationsByVersion[version] = migration
+		}
+
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has files with different names", version)
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	var migrations []Migration
+
+	for _, migration := range migrationsByVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d has no up file", migration.Version)
+		}
+
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Up applies all the pending migrations, from the oldest to the newest
+func (migrator Migrator) Up(ctx context.Context) error {
+	return migrator.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		if err = migrator.checkAhead(applied); err != nil {
+			return err
+		}
+
+		for _, migration := range migrator.migrations {
+			if applied[migration.Version] {
+				continue
+			}
+
+			if err = execStatements(ctx, conn, migration.Up); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			if _, err = conn.ExecContext(ctx, "insert into schema_migrations (version, name) values (?, ?)", migration.Version, migration.Name); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Down rolls back a given number of the latest applied migrations
+func (migrator Migrator) Down(ctx context.Context, steps int) error {
+	return migrator.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		if err = migrator.checkAhead(applied); err != nil {
+			return err
+		}
+
+		for i := len(migrator.migrations) - 1; i >= 0 && steps > 0; i-- {
+			migration := migrator.migrations[i]
+			if !applied[migration.Version] {
+				continue
+			}
+
+			if migration.Down == "" {
+				return fmt.Errorf("migration %d_%s cannot be rolled back", migration.Version, migration.Name)
+			}
+
+			if err = execStatements(ctx, conn, migration.Down); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			if _, err = conn.ExecContext(ctx, "delete from schema_migrations where version = ?", migration.Version); err != nil {
+				return err
+			}
+
+			steps--
+		}
+
+		return nil
+	})
+}
+
+// Status tells which migrations were applied to the database
+func (migrator Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+
+	err := migrator.withConn(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrator.migrations {
+			statuses = append(statuses, MigrationStatus{migration, applied[migration.Version]})
+		}
+
+		return migrator.checkAhead(applied)
+	})
+
+	return statuses, err
+}
+
+// Check returns ErrNotMigrated if the database was never migrated, ErrSchemaAhead if it was migrated by a newer
+// version of the API, or ErrPendingMigrations if it still needs to be migrated. Unlike Status, it only reads the
+// database, so it is cheap enough for readiness probes
+func (migrator Migrator) Check(ctx context.Context) error {
+	var tables int
+
+	err := migrator.db.QueryRowContext(ctx, "select count(*) from information_schema.tables where table_schema = database() and table_name = 'schema_migrations'").Scan(&tables)
+	if err != nil {
+		return err
+	}
+
+	if tables == 0 {
+		return ErrNotMigrated
+	}
+
+	applied, err := appliedVersions(ctx, migrator.db)
+	if err != nil {
+		return err
+	}
+
+	if err = migrator.checkAhead(applied); err != nil {
+		return err
+	}
+
+	for _, migration := range migrator.migrations {
+		if !applied[migration.Version] {
+			return ErrPendingMigrations
+		}
+	}
+
+	return nil
+}
+
+// checkAhead returns ErrSchemaAhead if a version applied to the database is unknown to this version of the API
+func (migrator Migrator) checkAhead(applied map[uint64]bool) error {
+	known := make(map[uint64]bool)
+	for _, migration := range migrator.migrations {
+		known[migration.Version] = true
+	}
+
+	for version := range applied {
+		if !known[version] {
+			return fmt.Errorf("%w: version %d is unknown", ErrSchemaAhead, version)
+		}
+	}
+
+	return nil
+}
+
+// withConn runs a function with a dedicated connection that has the schema_migrations table
+func (migrator Migrator) withConn(ctx context.Context, run func(*sql.Conn) error) error {
+	conn, err := migrator.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(ctx, `create table if not exists schema_migrations (
+										version bigint not null primary key,
+										name varchar(255) not null,
+										applied_at timestamp default current_timestamp()
+									) ENGINE = INNODB`)
+	if err != nil {
+		return err
+	}
+
+	return run(conn)
+}
+
+// withLock runs a function like withConn, holding the migration lock while it runs
+func (migrator Migrator) withLock(ctx context.Context, run func(*sql.Conn) error) error {
+	return migrator.withConn(ctx, func(conn *sql.Conn) error {
+		var locked sql.NullBool
+
+		if err := conn.QueryRowContext(ctx, "select get_lock(?, ?)", migrationLock, migrationLockTimeout).Scan(&locked); err != nil {
+			return err
+		}
+
+		if !locked.Bool {
+			return errors.New("timed out waiting for another process to finish migrating the database")
+		}
+
+		defer conn.ExecContext(context.Background(), "select release_lock(?)", migrationLock)
+
+		return run(conn)
+	})
+}
+
+// querier is implemented by both *sql.DB and *sql.Conn
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// appliedVersions returns the versions recorded in the schema_migrations table
+func appliedVersions(ctx context.Context, db querier) (map[uint64]bool, error) {
+	rows, err := db.QueryContext(ctx, "select version from schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	applied := make(map[uint64]bool)
+
+	for rows.Next() {
+		var version uint64
+
+		if err = rows.Scan(&version); err != nil {
+			return nil, err
+		}
+
+		applied[version] = true
+	}
+
+	return applied, rows.Err()
+}
+
+// execStatements runs each statement of a migration file. Statements end with a semicolon at the end of a line
+func execStatements(ctx context.Context, conn *sql.Conn, script string) error {
+	for _, statement := range splitStatements(script) {
+		if _, err := conn.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func splitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+
+	for _, line := range strings.Split(script, "\n") {
+		current.WriteString(line)
+		current.WriteString("\n")
+
+		if strings.HasSuffix(strings.TrimSpace(line), ";") {
+			if statement := strings.TrimSuffix(strings.TrimSpace(current.String()), ";"); statement != "" {
+				statements = append(statements, statement)
+			}
+
+			current.Reset()
+		}
+	}
+
+	if statement := strings.TrimSpace(current.String()); statement != "" {
+		statements = append(statements, statement)
+	}
+
+	return statements
+}
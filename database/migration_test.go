@@ -0,0 +1,241 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/waliqueiroz/devbook-api/database"
+	"github.com/waliqueiroz/devbook-api/test/mock"
+)
+
+func newMigrations() []database.Migration {
+	return []database.Migration{
+		{Version: 1, Name: "create_users", Up: "create table users (id int);\ncreate index users_id on users (id);", Down: "drop table users;"},
+		{Version: 2, Name: "create_posts", Up: "create table posts (id int);", Down: "drop table posts;"},
+	}
+}
+
+func expectSchemaMigrations(sqlMock sqlmock.Sqlmock, versions ...uint64) {
+	sqlMock.ExpectExec("create table if not exists schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	sqlMock.ExpectQuery("select version from schema_migrations").WillReturnRows(rowsOf(versions))
+}
+
+func expectLock(sqlMock sqlmock.Sqlmock) {
+	sqlMock.ExpectExec("create table if not exists schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectQuery("select get_lock\\(\\?, \\?\\)").WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+}
+
+func TestLoadMigrations(t *testing.T) {
+	subTests := []struct {
+		name               string
+		files              fstest.MapFS
+		expectedMigrations []database.Migration
+		expectError        bool
+	}{
+		{
+			name: "Load migrations ordered by version",
+			files: fstest.MapFS{
+				"0002_create_posts.up.sql":   {Data: []byte("create table posts (id int);")},
+				"0002_create_posts.down.sql": {Data: []byte("drop table posts;")},
+				"0001_create_users.up.sql":   {Data: []byte("create table users (id int);")},
+				"README.md":                  {Data: []byte("ignored")},
+			},
+			expectedMigrations: []database.Migration{
+				{Version: 1, Name: "create_users", Up: "create table users (id int);"},
+				{Version: 2, Name: "create_posts", Up: "create table posts (id int);", Down: "drop table posts;"},
+			},
+		},
+		{
+			name: "Load a migration without up file",
+			files: fstest.MapFS{
+				"0001_create_users.down.sql": {Data: []byte("drop table users;")},
+			},
+			expectError: true,
+		},
+		{
+			name: "Load a migration with different names",
+			files: fstest.MapFS{
+				"0001_create_users.up.sql":  {Data: []byte("create table users (id int);")},
+				"0001_create_people.up.sql": {Data: []byte("create table people (id int);")},
+			},
+			expectError: true,
+		},
+	}
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			migrations, err := database.LoadMigrations(subTest.files)
+
+			if subTest.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, subTest.expectedMigrations, migrations, "Migrations do not match with expected")
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	db, _ := mock.NewDatabaseConnection()
+	defer db.Close()
+
+	_, err := database.NewMigrator(db)
+
+	assert.NoError(t, err, "Embedded migrations should load")
+}
+
+func TestMigrateUp(t *testing.T) {
+	subTests := []struct {
+		name            string
+		appliedVersions []uint64
+		errorInExec     bool
+		err             error
+	}{
+		{
+			name: "Apply all migrations",
+		},
+		{
+			name:            "Apply pending migrations",
+			appliedVersions: []uint64{1},
+		},
+		{
+			name:            "Apply migrations to a schema ahead of the API",
+			appliedVersions: []uint64{1, 2, 3},
+			err:             database.ErrSchemaAhead,
+		},
+		{
+			name:        "Apply migrations - error in exec",
+			errorInExec: true,
+			err:         errors.New("some error"),
+		},
+	}
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			db, sqlMock := mock.NewDatabaseConnection()
+			defer db.Close()
+
+			expectLock(sqlMock)
+			sqlMock.ExpectQuery("select version from schema_migrations").WillReturnRows(rowsOf(subTest.appliedVersions))
+
+			if subTest.errorInExec {
+				sqlMock.ExpectExec("create table users").WillReturnError(subTest.err)
+			} else if subTest.err == nil {
+				if len(subTest.appliedVersions) == 0 {
+					sqlMock.ExpectExec("create table users \\(id int\\)").WillReturnResult(sqlmock.NewResult(0, 0))
+					sqlMock.ExpectExec("create index users_id on users \\(id\\)").WillReturnResult(sqlmock.NewResult(0, 0))
+					sqlMock.ExpectExec("insert into schema_migrations").WithArgs(1, "create_users").WillReturnResult(sqlmock.NewResult(1, 1))
+				}
+
+				sqlMock.ExpectExec("create table posts \\(id int\\)").WillReturnResult(sqlmock.NewResult(0, 0))
+				sqlMock.ExpectExec("insert into schema_migrations").WithArgs(2, "create_posts").WillReturnResult(sqlmock.NewResult(1, 1))
+			}
+
+			sqlMock.ExpectExec("select release_lock\\(\\?\\)").WillReturnResult(sqlmock.NewResult(0, 0))
+
+			migrator := database.NewMigratorWithMigrations(db, newMigrations())
+
+			err := migrator.Up(context.Background())
+
+			if subTest.err != nil {
+				assert.True(t, errors.Is(err, subTest.err), "Error does not match with expected")
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestMigrateDown(t *testing.T) {
+	db, sqlMock := mock.NewDatabaseConnection()
+	defer db.Close()
+
+	expectLock(sqlMock)
+	sqlMock.ExpectQuery("select version from schema_migrations").WillReturnRows(rowsOf([]uint64{1, 2}))
+	sqlMock.ExpectExec("drop table posts").WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectExec("delete from schema_migrations where version = \\?").WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectExec("select release_lock\\(\\?\\)").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	migrator := database.NewMigratorWithMigrations(db, newMigrations())
+
+	err := migrator.Down(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestCheckMigrations(t *testing.T) {
+	subTests := []struct {
+		name            string
+		notMigrated     bool
+		appliedVersions []uint64
+		err             error
+	}{
+		{
+			name:        "Check a database that was never migrated",
+			notMigrated: true,
+			err:         database.ErrNotMigrated,
+		},
+		{
+			name:            "Check an up to date schema",
+			appliedVersions: []uint64{1, 2},
+		},
+		{
+			name:            "Check a schema with pending migrations",
+			appliedVersions: []uint64{1},
+			err:             database.ErrPendingMigrations,
+		},
+		{
+			name:            "Check a schema ahead of the API",
+			appliedVersions: []uint64{1, 2, 3},
+			err:             database.ErrSchemaAhead,
+		},
+	}
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			db, sqlMock := mock.NewDatabaseConnection()
+			defer db.Close()
+
+			tables := sqlmock.NewRows([]string{"count"}).AddRow(1)
+			if subTest.notMigrated {
+				tables = sqlmock.NewRows([]string{"count"}).AddRow(0)
+			}
+
+			sqlMock.ExpectQuery("select count\\(\\*\\) from information_schema.tables").WillReturnRows(tables)
+
+			if !subTest.notMigrated {
+				sqlMock.ExpectQuery("select version from schema_migrations").WillReturnRows(rowsOf(subTest.appliedVersions))
+			}
+
+			migrator := database.NewMigratorWithMigrations(db, newMigrations())
+
+			err := migrator.Check(context.Background())
+
+			if subTest.err != nil {
+				assert.True(t, errors.Is(err, subTest.err), "Error does not match with expected")
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func rowsOf(versions []uint64) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"version"})
+	for _, version := range versions {
+		rows.AddRow(version)
+	}
+
+	return rows
+}
@@ -0,0 +1,5 @@
+DROP TABLE posts;
+
+DROP TABLE followers;
+
+DROP TABLE users;
@@ -0,0 +1,10 @@
+CREATE TABLE post_likes (
+    post_id int not null,
+    user_id int not null,
+    created_at timestamp default current_timestamp(),
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    primary key (post_id, user_id)
+) ENGINE = INNODB;
+
+UPDATE posts SET likes = (SELECT COUNT(*) FROM post_likes WHERE post_likes.post_id = posts.id);
@@ -0,0 +1,9 @@
+CREATE TABLE sessions (
+    id int auto_increment primary key,
+    user_id int not null,
+    refresh_token_hash char(64) not null unique,
+    expires_at timestamp not null,
+    revoked_at timestamp null default null,
+    created_at timestamp default current_timestamp(),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE = INNODB;
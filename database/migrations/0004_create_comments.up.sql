@@ -0,0 +1,9 @@
+CREATE TABLE comments (
+    id int auto_increment primary key,
+    post_id int not null,
+    author_id int not null,
+    content text not null,
+    created_at timestamp default current_timestamp(),
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+    FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE = INNODB;
@@ -0,0 +1,7 @@
+ALTER TABLE posts
+    DROP FOREIGN KEY posts_parent_post_fk,
+    DROP FOREIGN KEY posts_repost_of_fk;
+
+ALTER TABLE posts
+    DROP COLUMN parent_post_id,
+    DROP COLUMN repost_of_id;
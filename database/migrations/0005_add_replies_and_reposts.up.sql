@@ -0,0 +1,5 @@
+ALTER TABLE posts
+    ADD COLUMN parent_post_id int null default null,
+    ADD COLUMN repost_of_id int null default null,
+    ADD CONSTRAINT posts_parent_post_fk FOREIGN KEY (parent_post_id) REFERENCES posts(id) ON DELETE SET NULL,
+    ADD CONSTRAINT posts_repost_of_fk FOREIGN KEY (repost_of_id) REFERENCES posts(id) ON DELETE CASCADE;
@@ -0,0 +1 @@
+ALTER TABLE posts DROP INDEX posts_search;
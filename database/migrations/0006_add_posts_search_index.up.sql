@@ -0,0 +1 @@
+ALTER TABLE posts ADD FULLTEXT INDEX posts_search (title, content);
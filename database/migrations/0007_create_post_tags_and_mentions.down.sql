@@ -0,0 +1,3 @@
+DROP TABLE post_mentions;
+
+DROP TABLE post_tags;
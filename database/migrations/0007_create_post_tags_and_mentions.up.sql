@@ -0,0 +1,16 @@
+CREATE TABLE post_tags (
+    post_id int not null,
+    tag varchar(100) not null,
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+    primary key (post_id, tag),
+    INDEX post_tags_tag (tag, post_id)
+) ENGINE = INNODB;
+
+CREATE TABLE post_mentions (
+    post_id int not null,
+    user_id int not null,
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    primary key (post_id, user_id),
+    INDEX post_mentions_user (user_id, post_id)
+) ENGINE = INNODB;
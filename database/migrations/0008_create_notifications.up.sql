@@ -0,0 +1,15 @@
+CREATE TABLE notifications (
+    id int auto_increment primary key,
+    user_id int not null,
+    actor_id int not null,
+    type varchar(20) not null,
+    post_id int null default null,
+    comment_id int null default null,
+    read_at timestamp null default null,
+    created_at timestamp default current_timestamp(),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+    FOREIGN KEY (comment_id) REFERENCES comments(id) ON DELETE CASCADE,
+    INDEX notifications_user (user_id, id)
+) ENGINE = INNODB;
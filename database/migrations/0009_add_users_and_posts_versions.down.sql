@@ -0,0 +1,7 @@
+ALTER TABLE posts
+    DROP COLUMN updated_at,
+    DROP COLUMN version;
+
+ALTER TABLE users
+    DROP COLUMN updated_at,
+    DROP COLUMN version;
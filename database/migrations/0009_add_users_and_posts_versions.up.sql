@@ -0,0 +1,7 @@
+ALTER TABLE users
+    ADD COLUMN version int unsigned not null default 1,
+    ADD COLUMN updated_at timestamp default current_timestamp();
+
+ALTER TABLE posts
+    ADD COLUMN version int unsigned not null default 1,
+    ADD COLUMN updated_at timestamp default current_timestamp();
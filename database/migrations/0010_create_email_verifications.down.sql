@@ -0,0 +1,4 @@
+DROP TABLE email_verifications;
+
+ALTER TABLE users
+    DROP COLUMN verified_at;
@@ -0,0 +1,14 @@
+ALTER TABLE users
+    ADD COLUMN verified_at timestamp null default null;
+
+UPDATE users SET verified_at = created_at;
+
+CREATE TABLE email_verifications (
+    id int auto_increment primary key,
+    user_id int not null,
+    token_hash char(64) not null unique,
+    expires_at timestamp not null,
+    used_at timestamp null default null,
+    created_at timestamp default current_timestamp(),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE = INNODB;
@@ -0,0 +1,11 @@
+DELETE r FROM posts r
+    LEFT JOIN posts o ON r.repost_of_id = o.id
+    WHERE r.repost_of_id IS NOT NULL AND o.id IS NULL AND r.title = '' AND r.content = '';
+
+UPDATE posts r
+    LEFT JOIN posts o ON r.repost_of_id = o.id
+    SET r.repost_of_id = NULL
+    WHERE r.repost_of_id IS NOT NULL AND o.id IS NULL;
+
+ALTER TABLE posts
+    ADD CONSTRAINT posts_repost_of_fk FOREIGN KEY (repost_of_id) REFERENCES posts(id) ON DELETE CASCADE;
@@ -0,0 +1,2 @@
+ALTER TABLE posts
+    DROP FOREIGN KEY posts_repost_of_fk;
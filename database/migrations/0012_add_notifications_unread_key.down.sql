@@ -0,0 +1,5 @@
+ALTER TABLE notifications
+    DROP INDEX notifications_unread_key;
+
+ALTER TABLE notifications
+    DROP COLUMN unread_key;
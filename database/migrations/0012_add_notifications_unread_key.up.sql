@@ -0,0 +1,14 @@
+ALTER TABLE notifications
+    ADD COLUMN unread_key varchar(100) null default null;
+
+UPDATE notifications n
+    JOIN (
+        SELECT MIN(id) AS id
+        FROM notifications
+        WHERE read_at IS NULL
+        GROUP BY user_id, actor_id, type, post_id, comment_id
+    ) oldest ON n.id = oldest.id
+    SET n.unread_key = CONCAT_WS(':', n.user_id, n.actor_id, n.type, IFNULL(n.post_id, 0), IFNULL(n.comment_id, 0));
+
+ALTER TABLE notifications
+    ADD CONSTRAINT notifications_unread_key UNIQUE (unread_key);
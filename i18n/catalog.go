@@ -0,0 +1,48 @@
+package i18n
+
+import "github.com/waliqueiroz/devbook-api/model"
+
+// portugueseBR holds the messages in Brazilian Portuguese. Messages that refer to their field are
+// format strings that receive its name first, followed by the values of the error
+var portugueseBR = catalog{
+	messages: map[string]string{
+		model.CodeValidationFailed: "alguns campos não são válidos",
+		model.FieldRequired:        "o campo %s é obrigatório",
+		model.FieldInvalidEmail:    "o %s inserido é inválido",
+		model.FieldReplyAndRepost:  "uma publicação não pode ser uma resposta e um compartilhamento ao mesmo tempo",
+		model.FieldInvalidRange:    "a data final deve ser posterior à data inicial",
+		model.FieldTooLong:         "o campo %s deve ter no máximo %d caracteres",
+		model.FieldTooShort:        "o campo %s deve ter no mínimo %d caracteres",
+		model.FieldInvalidNick:     "o %s deve ter apenas letras minúsculas sem acento, números e _",
+		model.FieldReserved:        "o %s escolhido é reservado",
+		model.FieldTaken:           "o %s escolhido já está em uso",
+	},
+	fields: map[string]string{
+		"name":     "nome",
+		"password": "senha",
+		"title":    "título",
+		"content":  "conteúdo",
+		"current":  "senha atual",
+		"new":      "nova senha",
+	},
+}
+
+// english holds the messages in English, with field names that differ from the JSON ones
+var english = catalog{
+	messages: map[string]string{
+		model.CodeValidationFailed: "some fields are not valid",
+		model.FieldRequired:        "the %s field is required",
+		model.FieldInvalidEmail:    "the %s is not valid",
+		model.FieldReplyAndRepost:  "a post cannot be a reply and a repost at the same time",
+		model.FieldInvalidRange:    "the end date must be after the start date",
+		model.FieldTooLong:         "the %s field must have at most %d characters",
+		model.FieldTooShort:        "the %s field must have at least %d characters",
+		model.FieldInvalidNick:     "the %s may only have lowercase letters without accents, digits and _",
+		model.FieldReserved:        "the chosen %s is reserved",
+		model.FieldTaken:           "the chosen %s is already in use",
+	},
+	fields: map[string]string{
+		"current": "current password",
+		"new":     "new password",
+	},
+}
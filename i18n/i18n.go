@@ -0,0 +1,92 @@
+// Package i18n translates the messages sent to API clients into the language they ask for
+package i18n
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Languages with a catalog of messages
+const (
+	PortugueseBR = "pt-BR"
+	English      = "en"
+)
+
+// DefaultLanguage is used when a client does not ask for any language with a catalog
+const DefaultLanguage = PortugueseBR
+
+// catalog holds the messages of a language, along with the names of the fields they refer to
+type catalog struct {
+	messages map[string]string
+	fields   map[string]string
+}
+
+var catalogs = map[string]catalog{
+	PortugueseBR: portugueseBR,
+	English:      english,
+}
+
+// Negotiate picks the language of the best ranked Accept-Language entry that has a catalog.
+// A language matches any of its regions, so "pt-PT" and "pt" are answered in pt-BR
+func Negotiate(acceptLanguage string) string {
+	language, bestQuality := DefaultLanguage, 0.0
+
+	for _, entry := range strings.Split(acceptLanguage, ",") {
+		params := strings.Split(strings.TrimSpace(entry), ";")
+		tag := strings.ToLower(strings.TrimSpace(params[0]))
+
+		quality := 1.0
+		for _, param := range params[1:] {
+			if value, found := strings.CutPrefix(strings.TrimSpace(param), "q="); found {
+				if q, err := strconv.ParseFloat(value, 64); err == nil {
+					quality = q
+				}
+			}
+		}
+
+		matched := match(tag)
+		if matched != "" && quality > bestQuality {
+			language, bestQuality = matched, quality
+		}
+	}
+
+	return language
+}
+
+// match returns the language with a catalog that a language tag refers to, if any
+func match(tag string) string {
+	primary, _, _ := strings.Cut(tag, "-")
+
+	for language := range catalogs {
+		if strings.EqualFold(primary, strings.Split(language, "-")[0]) {
+			return language
+		}
+	}
+
+	return ""
+}
+
+// Message returns the message of a code in a language, with the name of the field and the given values
+// filled in. Codes missing from the catalog are returned as they are, as clients may still map them
+func Message(language, code, field string, args ...interface{}) string {
+	catalog, ok := catalogs[language]
+	if !ok {
+		catalog = catalogs[DefaultLanguage]
+	}
+
+	message, ok := catalog.messages[code]
+	if !ok {
+		return code
+	}
+
+	if !strings.Contains(message, "%") {
+		return message
+	}
+
+	if name, ok := catalog.fields[field]; ok {
+		field = name
+	}
+
+	return fmt.Sprintf(message, append([]interface{}{field}, args...)...)
+}
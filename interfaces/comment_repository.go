@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/waliqueiroz/devbook-api/model"
+)
+
+// CommentRepository describes a comment repository interface
+type CommentRepository interface {
+	Create(context.Context, model.Comment) (model.Comment, error)
+	FindByID(context.Context, uint64) (model.Comment, error)
+	FindByPost(context.Context, uint64, model.PageRequest) (model.CommentPage, error)
+	Update(context.Context, uint64, model.Comment) error
+	Delete(context.Context, uint64) error
+}
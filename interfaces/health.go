@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Database describes the database connection checked by the readiness probe
+type Database interface {
+	PingContext(context.Context) error
+	Stats() sql.DBStats
+}
+
+// SchemaChecker describes something that tells if the database schema matches the API
+type SchemaChecker interface {
+	Check(context.Context) error
+}
@@ -0,0 +1,12 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/waliqueiroz/devbook-api/model"
+)
+
+// Mailer describes something that delivers emails
+type Mailer interface {
+	Send(context.Context, model.Email) error
+}
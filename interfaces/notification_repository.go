@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/waliqueiroz/devbook-api/model"
+)
+
+// NotificationRepository describes a notification repository interface
+type NotificationRepository interface {
+	Create(context.Context, model.Notification) (model.Notification, bool, error)
+	CreateMentions(context.Context, uint64, uint64, []string) ([]model.Notification, error)
+	FindByUser(context.Context, uint64, bool, model.PageRequest) (model.NotificationPage, error)
+	MarkAsRead(context.Context, uint64, []uint64) error
+	CountUnread(context.Context, uint64) (uint64, error)
+}
@@ -1,15 +1,26 @@
 package interfaces
 
-import "github.com/waliqueiroz/devbook-api/model"
+import (
+	"context"
+	"time"
+
+	"github.com/waliqueiroz/devbook-api/model"
+)
 
 // PostRepository describes a post repository interface
 type PostRepository interface {
-	Create(model.Post) (model.Post, error)
-	FindByID(uint64) (model.Post, error)
-	Index(uint64) ([]model.Post, error)
-	Update(uint64, model.Post) error
-	Delete(uint64) error
-	FindByUser(uint64) ([]model.Post, error)
-	LikePost(uint64) error
-	DeslikePost(uint64) error
+	Create(context.Context, model.Post) (model.Post, error)
+	FindByID(context.Context, uint64, uint64) (model.Post, error)
+	Index(context.Context, uint64, model.PageRequest) (model.PostPage, error)
+	Update(context.Context, uint64, model.Post) error
+	Delete(context.Context, uint64) error
+	FindByUser(context.Context, uint64, uint64, model.PageRequest) (model.PostPage, error)
+	LikePost(context.Context, uint64, uint64) error
+	DeslikePost(context.Context, uint64, uint64) error
+	FindLikes(context.Context, uint64, model.PageRequest) (model.UserPage, error)
+	Thread(context.Context, uint64, uint64) ([]model.Post, error)
+	Search(context.Context, model.PostSearch, uint64, model.SearchPageRequest) (model.PostSearchPage, error)
+	FindByTag(context.Context, string, uint64, model.PageRequest) (model.PostPage, error)
+	FindByMention(context.Context, uint64, uint64, model.PageRequest) (model.PostPage, error)
+	TrendingTags(context.Context, time.Time, uint64) ([]model.TrendingTag, error)
 }
@@ -0,0 +1,6 @@
+package interfaces
+
+// Publisher describes something that delivers events to the clients listening to a topic
+type Publisher interface {
+	Publish(string, string, interface{})
+}
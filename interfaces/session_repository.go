@@ -0,0 +1,18 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/waliqueiroz/devbook-api/model"
+)
+
+// SessionRepository describes a session repository interface
+type SessionRepository interface {
+	Create(context.Context, model.Session) (uint64, error)
+	FindByRefreshToken(context.Context, string) (model.Session, error)
+	Rotate(context.Context, uint64, string, string, time.Time) error
+	Revoke(context.Context, uint64) error
+	RevokeAllByUser(context.Context, uint64) error
+	IsActive(context.Context, uint64) (bool, error)
+}
@@ -1,19 +1,25 @@
 package interfaces
 
-import "github.com/waliqueiroz/devbook-api/model"
+import (
+	"context"
+
+	"github.com/waliqueiroz/devbook-api/model"
+)
 
 // UserRepository describes a user repository interface
 type UserRepository interface {
-	Create(model.User) (model.User, error)
-	FindByNameOrNick(string) ([]model.User, error)
-	FindByID(uint64) (model.User, error)
-	Update(uint64, model.User) error
-	Delete(uint64) error
-	FindByEmail(string) (model.User, error)
-	Follow(uint64, uint64) error
-	Unfollow(uint64, uint64) error
-	SearchFollowers(uint64) ([]model.User, error)
-	SearchFollowing(uint64) ([]model.User, error)
-	FindPassword(uint64) (string, error)
-	UpdatePassword(uint64, string) error
+	Create(context.Context, model.User) (model.User, error)
+	FindByNameOrNick(context.Context, string, model.PageRequest) (model.UserPage, error)
+	FindByID(context.Context, uint64) (model.User, error)
+	Update(context.Context, uint64, model.User) error
+	Delete(context.Context, uint64) error
+	FindByEmail(context.Context, string) (model.User, error)
+	Taken(context.Context, string, string) (bool, bool, error)
+	Follow(context.Context, uint64, uint64) error
+	Unfollow(context.Context, uint64, uint64) error
+	SearchFollowers(context.Context, uint64, model.PageRequest) (model.UserPage, error)
+	SearchFollowing(context.Context, uint64, model.PageRequest) (model.UserPage, error)
+	FollowingIDs(context.Context, uint64) ([]uint64, error)
+	FindPassword(context.Context, uint64) (string, error)
+	UpdatePassword(context.Context, uint64, string) error
 }
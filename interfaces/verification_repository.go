@@ -0,0 +1,14 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/waliqueiroz/devbook-api/model"
+)
+
+// VerificationRepository describes an email verification repository interface
+type VerificationRepository interface {
+	Create(context.Context, model.Verification, time.Duration) (bool, error)
+	Consume(context.Context, uint64, string) error
+}
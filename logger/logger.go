@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	requestInfoKey
+)
+
+// Formats of the log output
+const (
+	FormatJSON   = "json"
+	FormatLogfmt = "logfmt"
+)
+
+// New creates a logger that writes records at or above a level, in JSON or in logfmt
+func New(w io.Writer, format, level string) (*slog.Logger, error) {
+	var minimum slog.Level
+	if err := minimum.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q", level)
+	}
+
+	options := &slog.HandlerOptions{Level: minimum}
+
+	switch strings.ToLower(format) {
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, options)), nil
+	case FormatLogfmt, "text":
+		return slog.New(slog.NewTextHandler(w, options)), nil
+	default:
+		return nil, fmt.Errorf("invalid log format %q, use %s or %s", format, FormatJSON, FormatLogfmt)
+	}
+}
+
+// Setup makes a logger created like New the default one, used by FromContext and by the log package
+func Setup(w io.Writer, format, level string) error {
+	logger, err := New(w, format, level)
+	if err != nil {
+		return err
+	}
+
+	slog.SetDefault(logger)
+
+	return nil
+}
+
+// WithRequestID stores the ID of a request in its context
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestID returns the ID of the request a context belongs to, or an empty string if there is none
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// FromContext returns the default logger, tagged with the ID of the request a context belongs to
+func FromContext(ctx context.Context) *slog.Logger {
+	if requestID := RequestID(ctx); requestID != "" {
+		return slog.Default().With("request_id", requestID)
+	}
+
+	return slog.Default()
+}
+
+// RequestInfo gathers what inner handlers learn about a request, such as who made it, for the access log
+type RequestInfo struct {
+	mutex  sync.Mutex
+	userID uint64
+}
+
+// WithRequestInfo stores an empty RequestInfo in a context
+func WithRequestInfo(ctx context.Context) (context.Context, *RequestInfo) {
+	info := &RequestInfo{}
+	return context.WithValue(ctx, requestInfoKey, info), info
+}
+
+// SetUserID records the authenticated user of the request a context belongs to, if it has a RequestInfo
+func SetUserID(ctx context.Context, userID uint64) {
+	if info, ok := ctx.Value(requestInfoKey).(*RequestInfo); ok {
+		info.mutex.Lock()
+		info.userID = userID
+		info.mutex.Unlock()
+	}
+}
+
+// UserID returns the authenticated user recorded for the request, 0 if it was anonymous
+func (info *RequestInfo) UserID() uint64 {
+	info.mutex.Lock()
+	defer info.mutex.Unlock()
+
+	return info.userID
+}
@@ -0,0 +1,102 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/waliqueiroz/devbook-api/logger"
+)
+
+func TestNew(t *testing.T) {
+	subTests := []struct {
+		name           string
+		format         string
+		level          string
+		expectedOutput string
+		expectError    bool
+	}{
+		{
+			name:           "Log in JSON",
+			format:         logger.FormatJSON,
+			level:          "info",
+			expectedOutput: `"msg":"hello","answer":42`,
+		},
+		{
+			name:           "Log in logfmt",
+			format:         logger.FormatLogfmt,
+			level:          "info",
+			expectedOutput: `msg=hello answer=42`,
+		},
+		{
+			name:   "Skip records below the level",
+			format: logger.FormatJSON,
+			level:  "warn",
+		},
+		{
+			name:        "Log with an invalid format",
+			format:      "xml",
+			level:       "info",
+			expectError: true,
+		},
+		{
+			name:        "Log with an invalid level",
+			format:      logger.FormatJSON,
+			level:       "loud",
+			expectError: true,
+		},
+	}
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			var output bytes.Buffer
+
+			log, err := logger.New(&output, subTest.format, subTest.level)
+			if subTest.expectError {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+
+			log.Info("hello", "answer", 42)
+
+			if subTest.expectedOutput == "" {
+				assert.Empty(t, output.String(), "Nothing should be logged")
+			} else {
+				assert.Contains(t, output.String(), subTest.expectedOutput)
+			}
+		})
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	var output bytes.Buffer
+
+	defaultLogger := slog.Default()
+	defer slog.SetDefault(defaultLogger)
+
+	assert.NoError(t, logger.Setup(&output, logger.FormatJSON, "info"))
+
+	ctx := logger.WithRequestID(context.Background(), "abc-123")
+	logger.FromContext(ctx).Info("tagged")
+
+	var record map[string]interface{}
+	assert.NoError(t, json.Unmarshal(output.Bytes(), &record))
+	assert.Equal(t, "abc-123", record["request_id"], "The record should carry the request ID")
+	assert.Equal(t, "abc-123", logger.RequestID(ctx))
+	assert.Equal(t, "", logger.RequestID(context.Background()))
+}
+
+func TestRequestInfo(t *testing.T) {
+	logger.SetUserID(context.Background(), 1)
+
+	ctx, info := logger.WithRequestInfo(context.Background())
+	assert.Equal(t, uint64(0), info.UserID(), "A new request should be anonymous")
+
+	logger.SetUserID(ctx, 7)
+	assert.Equal(t, uint64(7), info.UserID(), "The user ID should be recorded")
+}
@@ -0,0 +1,39 @@
+package mailer
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/waliqueiroz/devbook-api/model"
+)
+
+// LogMailer writes emails to a writer instead of sending them, so the links they carry can be followed during development
+type LogMailer struct {
+	mutex  *sync.Mutex
+	writer io.Writer
+	from   string
+}
+
+// NewLogMailer creates a mailer that writes every email to a given writer, followed by a blank line
+func NewLogMailer(writer io.Writer, from string) *LogMailer {
+	return &LogMailer{
+		mutex:  &sync.Mutex{},
+		writer: writer,
+		from:   from,
+	}
+}
+
+// Send writes an email to the writer of the mailer
+func (mailer LogMailer) Send(ctx context.Context, email model.Email) error {
+	mailer.mutex.Lock()
+	defer mailer.mutex.Unlock()
+
+	if _, err := mailer.writer.Write(message(mailer.from, email, time.Now())); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(mailer.writer, "\r\n\r\n")
+	return err
+}
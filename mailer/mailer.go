@@ -0,0 +1,30 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+
+	"github.com/waliqueiroz/devbook-api/model"
+)
+
+// headerReplacer drops line breaks from header values, so no value can add headers of its own
+var headerReplacer = strings.NewReplacer("\r", "", "\n", "")
+
+// message formats an email as a plain text UTF-8 message, with the headers mail servers expect
+func message(from string, email model.Email, date time.Time) []byte {
+	var buffer bytes.Buffer
+
+	fmt.Fprintf(&buffer, "From: %s\r\n", headerReplacer.Replace(from))
+	fmt.Fprintf(&buffer, "To: %s\r\n", headerReplacer.Replace(email.To))
+	fmt.Fprintf(&buffer, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", headerReplacer.Replace(email.Subject)))
+	fmt.Fprintf(&buffer, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buffer.WriteString("MIME-Version: 1.0\r\n")
+	buffer.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buffer.WriteString("\r\n")
+	buffer.WriteString(strings.ReplaceAll(strings.ReplaceAll(email.Body, "\r\n", "\n"), "\n", "\r\n"))
+
+	return buffer.Bytes()
+}
@@ -0,0 +1,105 @@
+package mailer_test
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/waliqueiroz/devbook-api/mailer"
+	"github.com/waliqueiroz/devbook-api/model"
+)
+
+func TestLogMailer(t *testing.T) {
+	subTests := []struct {
+		name             string
+		email            model.Email
+		expectedHeaders  []string
+		expectedBody     string
+		unexpectedHeader string
+	}{
+		{
+			name: "Log an email",
+			email: model.Email{
+				To:      "juliette@mail.com",
+				Subject: "Confirm your email",
+				Body:    "Hi Juliette,\n\nhttp://localhost:3000/verify?token=abc\n",
+			},
+			expectedHeaders: []string{
+				"From: DevBook <no-reply@devbook.local>\r\n",
+				"To: juliette@mail.com\r\n",
+				"Subject: Confirm your email\r\n",
+				"Content-Type: text/plain; charset=utf-8\r\n",
+			},
+			expectedBody: "\r\n\r\nHi Juliette,\r\n\r\nhttp://localhost:3000/verify?token=abc\r\n",
+		},
+		{
+			name: "Log an email with a non ASCII subject",
+			email: model.Email{
+				To:      "juliette@mail.com",
+				Subject: "Confirme seu e-mail, Julieté",
+				Body:    "Olá",
+			},
+			expectedHeaders: []string{"Subject: =?utf-8?q?Confirme_seu_e-mail,_Juliet=C3=A9?=\r\n"},
+			expectedBody:    "\r\n\r\nOlá",
+		},
+		{
+			name: "Log an email with line breaks in the headers",
+			email: model.Email{
+				To:      "juliette@mail.com\r\nBcc: romeo@mail.com",
+				Subject: "Confirm\nBcc: romeo@mail.com",
+			},
+			unexpectedHeader: "\r\nBcc:",
+		},
+	}
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			var output bytes.Buffer
+
+			err := mailer.NewLogMailer(&output, "DevBook <no-reply@devbook.local>").Send(context.Background(), subTest.email)
+			assert.NoError(t, err)
+
+			message := output.String()
+			for _, header := range subTest.expectedHeaders {
+				assert.Contains(t, message, header, "Header does not match with expected")
+			}
+
+			if subTest.expectedBody != "" {
+				assert.True(t, strings.HasSuffix(message, subTest.expectedBody+"\r\n\r\n"), "Body does not match with expected: %q", message)
+			}
+
+			if subTest.unexpectedHeader != "" {
+				assert.NotContains(t, message, subTest.unexpectedHeader, "A header was injected")
+			}
+		})
+	}
+}
+
+// TestSMTPMailerTimeout checks that a server that never answers does not hold the sender past the timeout
+func TestSMTPMailerTimeout(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer listener.Close()
+
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(time.Second)
+		}
+	}()
+
+	smtpMailer := mailer.NewSMTPMailer("127.0.0.1", listener.Addr().(*net.TCPAddr).Port, "", "", "DevBook <no-reply@devbook.local>", 100*time.Millisecond)
+
+	started := time.Now()
+	err = smtpMailer.Send(context.Background(), model.Email{To: "juliette@mail.com", Subject: "Hello", Body: "Hi"})
+
+	assert.Error(t, err, "Sending to a silent server should fail")
+	assert.Less(t, time.Since(started), time.Second, "Sending should give up after the timeout")
+}
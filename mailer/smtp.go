@@ -0,0 +1,113 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/waliqueiroz/devbook-api/model"
+)
+
+// SMTPMailer delivers emails through an SMTP server, upgrading the connection with STARTTLS when the server offers it
+type SMTPMailer struct {
+	host    string
+	address string
+	auth    smtp.Auth
+	from    string
+	timeout time.Duration
+}
+
+// NewSMTPMailer creates a mailer that sends emails through a given SMTP server. Without a username, it does not authenticate.
+// Sending an email is given up on after the timeout, so a slow server cannot hold the request that sends it
+func NewSMTPMailer(host string, port int, username, password, from string, timeout time.Duration) *SMTPMailer {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &SMTPMailer{
+		host:    host,
+		address: host + ":" + strconv.Itoa(port),
+		auth:    auth,
+		from:    from,
+		timeout: timeout,
+	}
+}
+
+// Send delivers an email. The whole conversation with the server must end within the timeout,
+// or before the context is done if that comes first
+func (mailer SMTPMailer) Send(ctx context.Context, email model.Email) error {
+	ctx, cancel := context.WithTimeout(ctx, mailer.timeout)
+	defer cancel()
+
+	sender, err := mail.ParseAddress(mailer.from)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %w", err)
+	}
+
+	var dialer net.Dialer
+
+	conn, err := dialer.DialContext(ctx, "tcp", mailer.address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	deadline, _ := ctx.Deadline()
+	if err = conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, mailer.host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	return mailer.send(client, sender.Address, email)
+}
+
+// send goes through the same steps as smtp.SendMail on a client whose connection has a deadline
+func (mailer SMTPMailer) send(client *smtp.Client, sender string, email model.Email) error {
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: mailer.host}); err != nil {
+			return err
+		}
+	}
+
+	if mailer.auth != nil {
+		if ok, _ := client.Extension("AUTH"); ok {
+			if err := client.Auth(mailer.auth); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := client.Mail(sender); err != nil {
+		return err
+	}
+
+	if err := client.Rcpt(email.To); err != nil {
+		return err
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err = writer.Write(message(mailer.from, email, time.Now())); err != nil {
+		return err
+	}
+
+	if err = writer.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
@@ -1,43 +1,214 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/waliqueiroz/devbook-api/config"
 	"github.com/waliqueiroz/devbook-api/controller"
 	"github.com/waliqueiroz/devbook-api/database"
+	"github.com/waliqueiroz/devbook-api/interfaces"
+	"github.com/waliqueiroz/devbook-api/logger"
+	"github.com/waliqueiroz/devbook-api/mailer"
+	"github.com/waliqueiroz/devbook-api/metrics"
 	"github.com/waliqueiroz/devbook-api/repository"
 	"github.com/waliqueiroz/devbook-api/router"
 	"github.com/waliqueiroz/devbook-api/router/routes"
+	"github.com/waliqueiroz/devbook-api/stream"
 )
 
 func main() {
+	if err := run(); err != nil {
+		slog.Error("the API stopped with an error", "error", err)
+		os.Exit(1)
+	}
+}
+
+// run starts the API and blocks until it is told to stop. Returning, instead of exiting right away,
+// lets the deferred cleanups run
+func run() error {
 	config.Load()
 
+	if err := logger.Setup(os.Stdout, config.LogFormat, config.LogLevel); err != nil {
+		return err
+	}
+
 	db, err := database.Connect()
 	if err != nil {
-		log.Fatalln(err)
-		return
+		return err
 	}
 	defer db.Close()
 
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		return migrate(migrator, os.Args[2:])
+	}
+
+	if config.MigrateOnStart {
+		err = migrator.Up(context.Background())
+	} else {
+		err = migrator.Check(context.Background())
+	}
+
+	if err != nil {
+		return err
+	}
+
 	userRepository := repository.NewUserRepository(db)
 	postRepository := repository.NewPostRepository(db)
+	sessionRepository := repository.NewSessionRepository(db)
+	commentRepository := repository.NewCommentRepository(db)
+	notificationRepository := repository.NewNotificationRepository(db)
+	verificationRepository := repository.NewVerificationRepository(db)
 
-	authController := controller.NewAuthController(userRepository)
-	userController := controller.NewUserController(userRepository)
-	postController := controller.NewPostController(postRepository)
+	mailSender, closeMailer, err := newMailer()
+	if err != nil {
+		return err
+	}
+	defer closeMailer()
+
+	hub := stream.NewHub()
+
+	authController := controller.NewAuthController(userRepository, sessionRepository)
+	userController := controller.NewUserController(userRepository, sessionRepository, notificationRepository, hub, verificationRepository, mailSender)
+	postController := controller.NewPostController(postRepository, notificationRepository, hub)
+	commentController := controller.NewCommentController(commentRepository, postRepository, notificationRepository, hub)
+	notificationController := controller.NewNotificationController(notificationRepository)
+	streamController := controller.NewStreamController(hub, userRepository, sessionRepository)
+	healthController := controller.NewHealthController(db, migrator)
+	metricsController := controller.NewMetricsController(metrics.Default)
+
+	metrics.Default.Register(metrics.DBStats(db.Stats))
 
 	var applicationRoutes []router.Route
 
 	applicationRoutes = append(applicationRoutes, routes.Auth(authController)...)
 	applicationRoutes = append(applicationRoutes, routes.User(userController)...)
 	applicationRoutes = append(applicationRoutes, routes.Post(postController)...)
+	applicationRoutes = append(applicationRoutes, routes.Comment(commentController)...)
+	applicationRoutes = append(applicationRoutes, routes.Notification(notificationController)...)
+	applicationRoutes = append(applicationRoutes, routes.Stream(streamController)...)
+	applicationRoutes = append(applicationRoutes, routes.Health(healthController)...)
+	applicationRoutes = append(applicationRoutes, routes.Metrics(metricsController)...)
 
-	r := router.Generate(applicationRoutes)
+	r := router.Generate(applicationRoutes, sessionRepository)
+
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", config.APIPort),
+		Handler:           r,
+		ReadTimeout:       config.HTTPReadTimeout,
+		ReadHeaderTimeout: config.HTTPReadHeaderTimeout,
+		WriteTimeout:      config.HTTPWriteTimeout,
+		IdleTimeout:       config.HTTPIdleTimeout,
+	}
 
-	fmt.Printf("Listening on port %d...\n", config.APIPort)
-	http.ListenAndServe(fmt.Sprintf(":%d", config.APIPort), r)
+	// Streams never become idle, so they are ended for the server to be able to drain
+	server.RegisterOnShutdown(hub.Close)
+
+	serverErrors := make(chan error, 1)
+
+	go func() {
+		slog.Info("listening", "port", config.APIPort)
+		serverErrors <- server.ListenAndServe()
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	select {
+	case err = <-serverErrors:
+		return err
+	case received := <-signals:
+		slog.Info("shutting down", "signal", received.String(), "drain_delay", config.ShutdownDrainDelay.String(),
+			"grace_period", config.ShutdownGracePeriod.String())
+	}
+
+	// Failing the readiness probe first and waiting lets the load balancer notice it and stop sending new
+	// requests before the listener closes
+	healthController.ShutDown()
+	time.Sleep(config.ShutdownDrainDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.ShutdownGracePeriod)
+	defer cancel()
+
+	if err = server.Shutdown(ctx); err != nil {
+		server.Close()
+		return fmt.Errorf("could not drain the connections within %s: %w", config.ShutdownGracePeriod, err)
+	}
+
+	return nil
+}
+
+// newMailer creates the mailer chosen by MAIL_DRIVER, either smtp or log. The log mailer writes to MAIL_LOG_FILE,
+// or to the standard output when it is not set. The returned function closes that file
+func newMailer() (interfaces.Mailer, func() error, error) {
+	noop := func() error { return nil }
+
+	switch config.MailDriver {
+	case "smtp":
+		if config.SMTPHost == "" {
+			return nil, nil, errors.New("the smtp mail driver requires SMTP_HOST")
+		}
+		return mailer.NewSMTPMailer(config.SMTPHost, config.SMTPPort, config.SMTPUsername, config.SMTPPassword, config.MailFrom, config.SMTPTimeout), noop, nil
+	case "log":
+		if config.MailLogFile == "" {
+			return mailer.NewLogMailer(os.Stdout, config.MailFrom), noop, nil
+		}
+
+		file, err := os.OpenFile(config.MailLogFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			return nil, nil, err
+		}
+		return mailer.NewLogMailer(file, config.MailFrom), file.Close, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown mail driver %q, use smtp or log", config.MailDriver)
+	}
+}
+
+// migrate runs the migrate subcommand: migrate [up | down [steps] | status]
+func migrate(migrator *database.Migrator, args []string) error {
+	ctx := context.Background()
+
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "up":
+		return migrator.Up(ctx)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			var err error
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps: %s", args[1])
+			}
+		}
+		return migrator.Down(ctx, steps)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied"
+			}
+			fmt.Printf("%04d_%s\t%s\n", status.Version, status.Name, state)
+		}
+		return err
+	default:
+		return fmt.Errorf("unknown migrate command %q, use up, down [steps] or status", command)
+	}
 }
@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"database/sql"
+	"io"
+)
+
+// Default is the registry exposed on /metrics
+var Default = NewRegistry()
+
+// HTTP metrics, labelled by the route template instead of the raw URI so IDs do not create a series each
+var (
+	HTTPRequests         = NewCounterVec("devbook_http_requests_total", "Number of HTTP requests handled.", "method", "route", "status")
+	HTTPRequestDuration  = NewHistogramVec("devbook_http_request_duration_seconds", "Time taken to handle HTTP requests.", DefaultBuckets, "method", "route", "status")
+	HTTPRequestsInFlight = NewGauge("devbook_http_requests_in_flight", "Number of HTTP requests being handled.")
+)
+
+// Business metrics
+var (
+	PostsCreated = NewCounterVec("devbook_posts_created_total", "Number of posts created.")
+	Logins       = NewCounterVec("devbook_logins_total", "Number of login attempts, by result.", "result")
+	Follows      = NewCounterVec("devbook_follows_total", "Number of follows.")
+)
+
+// Login results
+const (
+	LoginSucceeded  = "succeeded"
+	LoginFailed     = "failed"
+	LoginUnverified = "unverified"
+)
+
+func init() {
+	Default.Register(HTTPRequests)
+	Default.Register(HTTPRequestDuration)
+	Default.Register(HTTPRequestsInFlight)
+	Default.Register(PostsCreated)
+	Default.Register(Logins)
+	Default.Register(Follows)
+
+	// Unlabelled counters are exposed from the start, so rates work before the first increment
+	PostsCreated.Add(0)
+	Follows.Add(0)
+}
+
+// DBStats returns a collector of the connection pool gauges and counters, read when the metrics are scraped
+func DBStats(stats func() sql.DBStats) Collector {
+	return CollectorFunc(func(w io.Writer) error {
+		current := stats()
+
+		gauges := []struct {
+			name  string
+			help  string
+			value float64
+		}{
+			{"devbook_db_max_open_connections", "Maximum number of open connections to the database.", float64(current.MaxOpenConnections)},
+			{"devbook_db_open_connections", "Number of established connections to the database.", float64(current.OpenConnections)},
+			{"devbook_db_in_use_connections", "Number of connections in use.", float64(current.InUse)},
+			{"devbook_db_idle_connections", "Number of idle connections.", float64(current.Idle)},
+		}
+
+		for _, gauge := range gauges {
+			if err := WriteGauge(w, gauge.name, gauge.help, gauge.value); err != nil {
+				return err
+			}
+		}
+
+		counters := []struct {
+			name  string
+			help  string
+			value float64
+		}{
+			{"devbook_db_wait_count_total", "Total number of connections waited for.", float64(current.WaitCount)},
+			{"devbook_db_wait_duration_seconds_total", "Total time blocked waiting for a new connection.", current.WaitDuration.Seconds()},
+			{"devbook_db_max_idle_closed_total", "Total number of connections closed due to the idle limit.", float64(current.MaxIdleClosed)},
+			{"devbook_db_max_lifetime_closed_total", "Total number of connections closed due to the lifetime limit.", float64(current.MaxLifetimeClosed)},
+		}
+
+		for _, counter := range counters {
+			if err := WriteCounter(w, counter.name, counter.help, counter.value); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
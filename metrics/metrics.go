@@ -0,0 +1,331 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of the latency histograms
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Collector is a metric that can write itself in the Prometheus text format
+type Collector interface {
+	Write(w io.Writer) error
+}
+
+// CollectorFunc turns a function into a Collector
+type CollectorFunc func(w io.Writer) error
+
+// Write calls the function
+func (collect CollectorFunc) Write(w io.Writer) error {
+	return collect(w)
+}
+
+// Registry holds the metrics exposed to Prometheus, in the order they were registered
+type Registry struct {
+	mutex      sync.Mutex
+	collectors []Collector
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a collector to the registry
+func (registry *Registry) Register(collector Collector) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	registry.collectors = append(registry.collectors, collector)
+}
+
+// Write writes all the metrics in the Prometheus text format
+func (registry *Registry) Write(w io.Writer) error {
+	registry.mutex.Lock()
+	collectors := append([]Collector(nil), registry.collectors...)
+	registry.mutex.Unlock()
+
+	for _, collector := range collectors {
+		if err := collector.Write(w); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ServeHTTP exposes the metrics to a Prometheus scrape
+func (registry *Registry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	registry.Write(w)
+}
+
+// CounterVec is a counter split by a set of labels
+type CounterVec struct {
+	name   string
+	help   string
+	labels []string
+	mutex  sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64
+	buckets     []uint64
+	count       uint64
+}
+
+// NewCounterVec creates a counter split by the given labels
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{name: name, help: help, labels: labels, series: make(map[string]*series)}
+}
+
+// Add increases the counter of a set of label values, given in the order of the labels
+func (counter *CounterVec) Add(value float64, labelValues ...string) {
+	counter.mutex.Lock()
+	defer counter.mutex.Unlock()
+
+	getSeries(counter.series, labelValues).value += value
+}
+
+// Inc increases the counter of a set of label values by one
+func (counter *CounterVec) Inc(labelValues ...string) {
+	counter.Add(1, labelValues...)
+}
+
+// Value returns the counter of a set of label values
+func (counter *CounterVec) Value(labelValues ...string) float64 {
+	counter.mutex.Lock()
+	defer counter.mutex.Unlock()
+
+	if s, ok := counter.series[seriesKey(labelValues)]; ok {
+		return s.value
+	}
+
+	return 0
+}
+
+// Write writes the counter in the Prometheus text format
+func (counter *CounterVec) Write(w io.Writer) error {
+	counter.mutex.Lock()
+	defer counter.mutex.Unlock()
+
+	if err := writeHeader(w, counter.name, counter.help, "counter"); err != nil {
+		return err
+	}
+
+	for _, s := range sortedSeries(counter.series) {
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", counter.name, formatLabels(counter.labels, s.labelValues), formatValue(s.value)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Gauge is a value that goes up and down
+type Gauge struct {
+	name  string
+	help  string
+	value int64
+}
+
+// NewGauge creates a gauge
+func NewGauge(name, help string) *Gauge {
+	return &Gauge{name: name, help: help}
+}
+
+// Inc increases the gauge by one
+func (gauge *Gauge) Inc() {
+	atomic.AddInt64(&gauge.value, 1)
+}
+
+// Dec decreases the gauge by one
+func (gauge *Gauge) Dec() {
+	atomic.AddInt64(&gauge.value, -1)
+}
+
+// Value returns the gauge
+func (gauge *Gauge) Value() int64 {
+	return atomic.LoadInt64(&gauge.value)
+}
+
+// Write writes the gauge in the Prometheus text format
+func (gauge *Gauge) Write(w io.Writer) error {
+	return WriteGauge(w, gauge.name, gauge.help, float64(gauge.Value()))
+}
+
+// WriteGauge writes a gauge with a value read at scrape time in the Prometheus text format
+func WriteGauge(w io.Writer, name, help string, value float64) error {
+	return writeSingle(w, name, help, "gauge", value)
+}
+
+// WriteCounter writes a counter kept elsewhere, like by database/sql, with a value read at scrape time in the Prometheus text format
+func WriteCounter(w io.Writer, name, help string, value float64) error {
+	return writeSingle(w, name, help, "counter", value)
+}
+
+func writeSingle(w io.Writer, name, help, metricType string, value float64) error {
+	if err := writeHeader(w, name, help, metricType); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "%s %s\n", name, formatValue(value))
+
+	return err
+}
+
+// HistogramVec is a histogram split by a set of labels
+type HistogramVec struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+	mutex   sync.Mutex
+	series  map[string]*series
+}
+
+// NewHistogramVec creates a histogram with the given bucket upper bounds, split by the given labels
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return &HistogramVec{name: name, help: help, labels: labels, buckets: buckets, series: make(map[string]*series)}
+}
+
+// Observe records a value for a set of label values, given in the order of the labels
+func (histogram *HistogramVec) Observe(value float64, labelValues ...string) {
+	histogram.mutex.Lock()
+	defer histogram.mutex.Unlock()
+
+	s := getSeries(histogram.series, labelValues)
+	if s.buckets == nil {
+		s.buckets = make([]uint64, len(histogram.buckets))
+	}
+
+	for i, upperBound := range histogram.buckets {
+		if value <= upperBound {
+			s.buckets[i]++
+		}
+	}
+
+	s.value += value
+	s.count++
+}
+
+// Count returns how many values were recorded for a set of label values
+func (histogram *HistogramVec) Count(labelValues ...string) uint64 {
+	histogram.mutex.Lock()
+	defer histogram.mutex.Unlock()
+
+	if s, ok := histogram.series[seriesKey(labelValues)]; ok {
+		return s.count
+	}
+
+	return 0
+}
+
+// Write writes the histogram in the Prometheus text format
+func (histogram *HistogramVec) Write(w io.Writer) error {
+	histogram.mutex.Lock()
+	defer histogram.mutex.Unlock()
+
+	if err := writeHeader(w, histogram.name, histogram.help, "histogram"); err != nil {
+		return err
+	}
+
+	bucketLabels := append(append([]string(nil), histogram.labels...), "le")
+
+	for _, s := range sortedSeries(histogram.series) {
+		for i, upperBound := range histogram.buckets {
+			labels := formatLabels(bucketLabels, append(append([]string(nil), s.labelValues...), formatValue(upperBound)))
+			if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", histogram.name, labels, s.buckets[i]); err != nil {
+				return err
+			}
+		}
+
+		labels := formatLabels(bucketLabels, append(append([]string(nil), s.labelValues...), "+Inf"))
+		if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", histogram.name, labels, s.count); err != nil {
+			return err
+		}
+
+		labels = formatLabels(histogram.labels, s.labelValues)
+		if _, err := fmt.Fprintf(w, "%s_sum%s %s\n%s_count%s %d\n", histogram.name, labels, formatValue(s.value), histogram.name, labels, s.count); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func getSeries(all map[string]*series, labelValues []string) *series {
+	key := seriesKey(labelValues)
+
+	s, ok := all[key]
+	if !ok {
+		s = &series{labelValues: append([]string(nil), labelValues...)}
+		all[key] = s
+	}
+
+	return s
+}
+
+func seriesKey(labelValues []string) string {
+	return strings.Join(labelValues, "\xff")
+}
+
+func sortedSeries(all map[string]*series) []*series {
+	keys := make([]string, 0, len(all))
+	for key := range all {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	sorted := make([]*series, len(keys))
+	for i, key := range keys {
+		sorted[i] = all[key]
+	}
+
+	return sorted
+}
+
+func writeHeader(w io.Writer, name, help, metricType string) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+
+	return err
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatLabels(labels, values []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	pairs := make([]string, len(labels))
+	for i, label := range labels {
+		value := ""
+		if i < len(values) {
+			value = values[i]
+		}
+
+		pairs[i] = fmt.Sprintf(`%s="%s"`, label, labelValueEscaper.Replace(value))
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
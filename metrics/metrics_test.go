@@ -0,0 +1,137 @@
+package metrics_test
+
+import (
+	"bytes"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/waliqueiroz/devbook-api/metrics"
+)
+
+func TestCounterVec(t *testing.T) {
+	counter := metrics.NewCounterVec("test_requests_total", "Number of requests.", "route", "status")
+
+	counter.Inc("/posts/{postID}", "200")
+	counter.Inc("/posts/{postID}", "200")
+	counter.Add(3, "/users", "404")
+
+	var output bytes.Buffer
+	assert.NoError(t, counter.Write(&output))
+
+	expected := "# HELP test_requests_total Number of requests.\n" +
+		"# TYPE test_requests_total counter\n" +
+		"test_requests_total{route=\"/posts/{postID}\",status=\"200\"} 2\n" +
+		"test_requests_total{route=\"/users\",status=\"404\"} 3\n"
+
+	assert.Equal(t, expected, output.String(), "Counter exposition does not match with expected")
+	assert.Equal(t, float64(2), counter.Value("/posts/{postID}", "200"))
+	assert.Equal(t, float64(0), counter.Value("/posts/{postID}", "500"))
+}
+
+func TestCounterVecWithoutLabels(t *testing.T) {
+	counter := metrics.NewCounterVec("test_posts_total", "Number of posts.")
+
+	var output bytes.Buffer
+	assert.NoError(t, counter.Write(&output))
+	assert.Equal(t, "# HELP test_posts_total Number of posts.\n# TYPE test_posts_total counter\n", output.String(), "An unused counter should have no series")
+
+	counter.Inc()
+	output.Reset()
+	assert.NoError(t, counter.Write(&output))
+	assert.Contains(t, output.String(), "test_posts_total 1\n")
+}
+
+func TestLabelEscaping(t *testing.T) {
+	counter := metrics.NewCounterVec("test_total", "Escaping.", "value")
+
+	counter.Inc("a \"quoted\" \\ value\nwith a new line")
+
+	var output bytes.Buffer
+	assert.NoError(t, counter.Write(&output))
+	assert.Contains(t, output.String(), `test_total{value="a \"quoted\" \\ value\nwith a new line"} 1`)
+}
+
+func TestHistogramVec(t *testing.T) {
+	histogram := metrics.NewHistogramVec("test_duration_seconds", "Request duration.", []float64{0.1, 1}, "route")
+
+	histogram.Observe(0.05, "/posts")
+	histogram.Observe(0.5, "/posts")
+	histogram.Observe(2, "/posts")
+
+	var output bytes.Buffer
+	assert.NoError(t, histogram.Write(&output))
+
+	expected := "# HELP test_duration_seconds Request duration.\n" +
+		"# TYPE test_duration_seconds histogram\n" +
+		"test_duration_seconds_bucket{route=\"/posts\",le=\"0.1\"} 1\n" +
+		"test_duration_seconds_bucket{route=\"/posts\",le=\"1\"} 2\n" +
+		"test_duration_seconds_bucket{route=\"/posts\",le=\"+Inf\"} 3\n" +
+		"test_duration_seconds_sum{route=\"/posts\"} 2.55\n" +
+		"test_duration_seconds_count{route=\"/posts\"} 3\n"
+
+	assert.Equal(t, expected, output.String(), "Histogram exposition does not match with expected")
+	assert.Equal(t, uint64(3), histogram.Count("/posts"))
+}
+
+func TestGauge(t *testing.T) {
+	gauge := metrics.NewGauge("test_in_flight", "Requests in flight.")
+
+	gauge.Inc()
+	gauge.Inc()
+	gauge.Dec()
+
+	var output bytes.Buffer
+	assert.NoError(t, gauge.Write(&output))
+	assert.Equal(t, "# HELP test_in_flight Requests in flight.\n# TYPE test_in_flight gauge\ntest_in_flight 1\n", output.String())
+}
+
+func TestDBStats(t *testing.T) {
+	collector := metrics.DBStats(func() sql.DBStats {
+		return sql.DBStats{MaxOpenConnections: 10, OpenConnections: 4, InUse: 3, Idle: 1, WaitCount: 7, WaitDuration: 1500 * time.Millisecond}
+	})
+
+	var output bytes.Buffer
+	assert.NoError(t, collector.Write(&output))
+
+	for _, line := range []string{
+		"devbook_db_max_open_connections 10\n",
+		"devbook_db_open_connections 4\n",
+		"devbook_db_in_use_connections 3\n",
+		"devbook_db_idle_connections 1\n",
+		"# TYPE devbook_db_wait_count_total counter\ndevbook_db_wait_count_total 7\n",
+		"# TYPE devbook_db_wait_duration_seconds_total counter\ndevbook_db_wait_duration_seconds_total 1.5\n",
+		"# TYPE devbook_db_max_idle_closed_total counter\n",
+		"# TYPE devbook_db_max_lifetime_closed_total counter\n",
+	} {
+		assert.Contains(t, output.String(), line)
+	}
+}
+
+func TestDefaultCountersStartAtZero(t *testing.T) {
+	var output bytes.Buffer
+	assert.NoError(t, metrics.Default.Write(&output))
+
+	assert.Contains(t, output.String(), "devbook_posts_created_total 0\n")
+	assert.Contains(t, output.String(), "devbook_follows_total 0\n")
+}
+
+func TestRegistry(t *testing.T) {
+	registry := metrics.NewRegistry()
+
+	first := metrics.NewCounterVec("test_first_total", "First.")
+	second := metrics.NewGauge("test_second", "Second.")
+
+	registry.Register(first)
+	registry.Register(second)
+
+	response := httptest.NewRecorder()
+	registry.ServeHTTP(response, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", response.Header().Get("Content-Type"))
+	assert.Equal(t, "# HELP test_first_total First.\n# TYPE test_first_total counter\n# HELP test_second Second.\n# TYPE test_second gauge\ntest_second 0\n", response.Body.String())
+}
@@ -1,28 +1,164 @@
 package middleware
 
 import (
-	"log"
+	"crypto/rand"
+	"encoding/hex"
 	"net/http"
+	"regexp"
+	"strconv"
+	"time"
 
 	"github.com/waliqueiroz/devbook-api/authentication"
+	"github.com/waliqueiroz/devbook-api/config"
+	"github.com/waliqueiroz/devbook-api/interfaces"
+	"github.com/waliqueiroz/devbook-api/logger"
+	"github.com/waliqueiroz/devbook-api/metrics"
+	"github.com/waliqueiroz/devbook-api/model"
 	"github.com/waliqueiroz/devbook-api/response"
 )
 
-// Logger logs the request info
-func Logger(next http.HandlerFunc) http.HandlerFunc {
+// RequestIDHeader carries the ID that correlates the logs of a request, sent by the client or generated
+const RequestIDHeader = "X-Request-ID"
+
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID honours the X-Request-ID sent by the client, or generates one, stores it in the request context
+// and sends it back in the response. IDs that are too long or have odd characters are replaced
+func RequestID(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		log.Printf("\n %s %s %s", r.Method, r.RequestURI, r.Host)
-		next(w, r)
+		requestID := r.Header.Get(RequestIDHeader)
+		if !requestIDPattern.MatchString(requestID) {
+			requestID = newRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, requestID)
+
+		next(w, r.WithContext(logger.WithRequestID(r.Context(), requestID)))
 	}
 }
 
-// Authenticate verify if an user is authenticated
-func Authenticate(next http.HandlerFunc) http.HandlerFunc {
+func newRequestID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+
+	return hex.EncodeToString(id)
+}
+
+// LimitBody caps the size of the request body to config.MaxBodyBytes. Reading past it fails with an
+// *http.MaxBytesError, and the connection is closed once the response is sent
+func LimitBody(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if err := authentication.ValidateToken(r); err != nil {
-			response.Error(w, http.StatusUnauthorized, err)
-			return
+		if r.Body != nil && config.MaxBodyBytes > 0 {
+			r.Body = http.MaxBytesReader(w, r.Body, config.MaxBodyBytes)
 		}
+
 		next(w, r)
 	}
 }
+
+// Logger writes an access log record for each request of a route, once it is handled
+func Logger(route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		ctx, info := logger.WithRequestInfo(r.Context())
+		recorder := newResponseRecorder(w)
+
+		next(recorder, r.WithContext(ctx))
+
+		attributes := []any{
+			"method", r.Method,
+			"route", route,
+			"uri", redactedURI(r),
+			"status", recorder.Status(),
+			"bytes", recorder.bytes,
+			"duration_ms", float64(time.Since(start).Microseconds()) / 1000,
+			"remote_addr", r.RemoteAddr,
+		}
+
+		if userID := info.UserID(); userID != 0 {
+			attributes = append(attributes, "user_id", userID)
+		}
+
+		logger.FromContext(ctx).Info("request", attributes...)
+	}
+}
+
+// redactedURI returns the URI of a request without the value of the token query parameter, so stream tokens do not end up in the logs
+func redactedURI(r *http.Request) string {
+	query := r.URL.Query()
+	if query.Get("token") == "" {
+		return r.RequestURI
+	}
+
+	query.Set("token", "REDACTED")
+
+	redacted := *r.URL
+	redacted.RawQuery = query.Encode()
+
+	return redacted.RequestURI()
+}
+
+// Metrics counts and times the requests of a route, labelled by its template like /posts/{postID}
+func Metrics(route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		metrics.HTTPRequestsInFlight.Inc()
+		defer metrics.HTTPRequestsInFlight.Dec()
+
+		start := time.Now()
+		recorder := newResponseRecorder(w)
+
+		next(recorder, r)
+
+		status := strconv.Itoa(recorder.Status())
+		metrics.HTTPRequests.Inc(r.Method, route, status)
+		metrics.HTTPRequestDuration.Observe(time.Since(start).Seconds(), r.Method, route, status)
+	}
+}
+
+// Authenticate verify if an user is authenticated and if their session is still active.
+// The identity carried by the token is stored in the request context for the next handlers
+func Authenticate(sessionRepository interfaces.SessionRepository) func(http.HandlerFunc) http.HandlerFunc {
+	return authenticate(sessionRepository, authentication.ParseToken)
+}
+
+// AuthenticateStream works like Authenticate, but also accepts a stream token in the token query parameter,
+// as browsers cannot send the Authorization header when they open an event stream
+func AuthenticateStream(sessionRepository interfaces.SessionRepository) func(http.HandlerFunc) http.HandlerFunc {
+	return authenticate(sessionRepository, func(r *http.Request) (authentication.Principal, error) {
+		if token := r.URL.Query().Get("token"); token != "" {
+			return authentication.ParseStreamToken(token)
+		}
+
+		return authentication.ParseToken(r)
+	})
+}
+
+func authenticate(sessionRepository interfaces.SessionRepository, parse func(*http.Request) (authentication.Principal, error)) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			principal, err := parse(r)
+			if err != nil {
+				response.Error(w, r, http.StatusUnauthorized, model.NewUnauthorizedError("the access token is missing, invalid or has expired", err))
+				return
+			}
+
+			active, err := sessionRepository.IsActive(r.Context(), principal.SessionID)
+			if err != nil {
+				response.Error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+
+			if !active {
+				response.Error(w, r, http.StatusUnauthorized, model.NewUnauthorizedError("the session was revoked or has expired", nil))
+				return
+			}
+
+			logger.SetUserID(r.Context(), principal.UserID)
+
+			next(w, r.WithContext(authentication.WithPrincipal(r.Context(), principal)))
+		}
+	}
+}
@@ -0,0 +1,349 @@
+package middleware_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/waliqueiroz/devbook-api/authentication"
+	"github.com/waliqueiroz/devbook-api/config"
+	"github.com/waliqueiroz/devbook-api/logger"
+	"github.com/waliqueiroz/devbook-api/metrics"
+	"github.com/waliqueiroz/devbook-api/middleware"
+	"github.com/waliqueiroz/devbook-api/model"
+	"github.com/waliqueiroz/devbook-api/response"
+	"github.com/waliqueiroz/devbook-api/test/mock"
+)
+
+type revokedSessionRepositoryMock struct {
+	mock.SessionRepositoryMock
+}
+
+func (repository revokedSessionRepositoryMock) IsActive(ctx context.Context, sessionID uint64) (bool, error) {
+	return false, nil
+}
+
+func TestAuthenticate(t *testing.T) {
+	token, _ := authentication.CreateToken(1, 2)
+
+	subTests := []struct {
+		name               string
+		token              string
+		revoked            bool
+		expectedStatusCode int
+	}{
+		{
+			name:               "Authenticate with a valid token",
+			token:              token,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Authenticate with an invalid token",
+			token:              "teste=",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "Authenticate with a revoked session",
+			token:              token,
+			revoked:            true,
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			authenticate := middleware.Authenticate(mock.NewSessionRepository())
+			if subTest.revoked {
+				authenticate = middleware.Authenticate(revokedSessionRepositoryMock{})
+			}
+
+			var principal authentication.Principal
+			var authenticated bool
+
+			handler := authenticate(func(w http.ResponseWriter, r *http.Request) {
+				principal, authenticated = authentication.PrincipalFromContext(r.Context())
+				w.WriteHeader(http.StatusOK)
+			})
+
+			request := httptest.NewRequest("GET", "/posts", nil)
+			request.Header.Add("Authorization", fmt.Sprintf("Bearer %s", subTest.token))
+
+			response := httptest.NewRecorder()
+
+			handler(response, request)
+
+			assert.Equal(t, subTest.expectedStatusCode, response.Code, "Status code does not match with expected")
+
+			if subTest.expectedStatusCode == http.StatusOK {
+				assert.True(t, authenticated, "Principal was not stored in the request context")
+				assert.Equal(t, uint64(1), principal.UserID, "User ID does not match with expected")
+				assert.Equal(t, uint64(2), principal.SessionID, "Session ID does not match with expected")
+				assert.NotEmpty(t, principal.TokenID, "Token ID is empty")
+				assert.Equal(t, []string{"api"}, principal.Scopes, "Scopes do not match with expected")
+			} else {
+				assert.False(t, authenticated, "Next handler should not be called")
+			}
+		})
+	}
+}
+
+func TestAuthenticateStream(t *testing.T) {
+	accessToken, expiresAt, _ := authentication.CreateTokenWithExpiration(1, 2)
+	streamToken, _, _ := authentication.CreateStreamToken(authentication.Principal{UserID: 1, SessionID: 2, ExpiresAt: expiresAt})
+
+	subTests := []struct {
+		name               string
+		bearerToken        string
+		queryToken         string
+		expectedStatusCode int
+	}{
+		{
+			name:               "Authenticate a stream with a stream token in the query",
+			queryToken:         streamToken,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Authenticate a stream with an access token in the header",
+			bearerToken:        accessToken,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Authenticate a stream with an access token in the query",
+			queryToken:         accessToken,
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "Authenticate with a stream token in the header",
+			bearerToken:        streamToken,
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			var principal authentication.Principal
+
+			handler := middleware.AuthenticateStream(mock.NewSessionRepository())(func(w http.ResponseWriter, r *http.Request) {
+				principal, _ = authentication.PrincipalFromContext(r.Context())
+				w.WriteHeader(http.StatusOK)
+			})
+
+			request := httptest.NewRequest("GET", "/stream?token="+subTest.queryToken, nil)
+			if subTest.bearerToken != "" {
+				request.Header.Add("Authorization", fmt.Sprintf("Bearer %s", subTest.bearerToken))
+			}
+
+			response := httptest.NewRecorder()
+
+			handler(response, request)
+
+			assert.Equal(t, subTest.expectedStatusCode, response.Code, "Status code does not match with expected")
+
+			if subTest.expectedStatusCode == http.StatusOK {
+				assert.Equal(t, uint64(1), principal.UserID, "User ID does not match with expected")
+				assert.Equal(t, uint64(2), principal.SessionID, "Session ID does not match with expected")
+				assert.Equal(t, expiresAt, principal.ExpiresAt, "The stream should expire along with the access token")
+			}
+		})
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	route := "/test/metrics/{postID}"
+
+	subTests := []struct {
+		name           string
+		handler        http.HandlerFunc
+		expectedStatus string
+	}{
+		{
+			name: "Count a request with an explicit status",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			},
+			expectedStatus: "404",
+		},
+		{
+			name: "Count a request with an implicit status",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("ok"))
+			},
+			expectedStatus: "200",
+		},
+		{
+			name: "Count a streamed request",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				flusher, ok := w.(http.Flusher)
+				assert.True(t, ok, "The response writer should still support flushing")
+				flusher.Flush()
+			},
+			expectedStatus: "200",
+		},
+	}
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			before := metrics.HTTPRequests.Value(http.MethodGet, route, subTest.expectedStatus)
+			observed := metrics.HTTPRequestDuration.Count(http.MethodGet, route, subTest.expectedStatus)
+
+			handler := middleware.Metrics(route, func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, int64(1), metrics.HTTPRequestsInFlight.Value(), "The request should be in flight")
+				subTest.handler(w, r)
+			})
+
+			handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/test/metrics/1", nil))
+
+			assert.Equal(t, before+1, metrics.HTTPRequests.Value(http.MethodGet, route, subTest.expectedStatus), "Request should be counted by its route template")
+			assert.Equal(t, observed+1, metrics.HTTPRequestDuration.Count(http.MethodGet, route, subTest.expectedStatus), "Request duration should be observed")
+			assert.Equal(t, int64(0), metrics.HTTPRequestsInFlight.Value(), "No request should be in flight")
+		})
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	subTests := []struct {
+		name              string
+		requestID         string
+		expectedRequestID string
+	}{
+		{
+			name:              "Honour the request ID sent by the client",
+			requestID:         "client-id-123",
+			expectedRequestID: "client-id-123",
+		},
+		{
+			name: "Generate a request ID",
+		},
+		{
+			name:      "Replace an invalid request ID",
+			requestID: "not valid\nid",
+		},
+	}
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			request := httptest.NewRequest("GET", "/", nil)
+			if subTest.requestID != "" {
+				request.Header.Set(middleware.RequestIDHeader, subTest.requestID)
+			}
+
+			response := httptest.NewRecorder()
+
+			var requestID string
+			middleware.RequestID(func(w http.ResponseWriter, r *http.Request) {
+				requestID = logger.RequestID(r.Context())
+			})(response, request)
+
+			assert.NotEmpty(t, requestID, "The request ID should be in the context")
+			assert.Equal(t, requestID, response.Header().Get(middleware.RequestIDHeader), "The request ID should be sent back")
+
+			if subTest.expectedRequestID != "" {
+				assert.Equal(t, subTest.expectedRequestID, requestID)
+			} else {
+				assert.Regexp(t, "^[0-9a-f]{32}$", requestID, "The request ID should be generated")
+			}
+		})
+	}
+}
+
+func TestLogger(t *testing.T) {
+	var output bytes.Buffer
+
+	defaultLogger := slog.Default()
+	defer slog.SetDefault(defaultLogger)
+
+	assert.NoError(t, logger.Setup(&output, logger.FormatJSON, "info"))
+
+	handler := middleware.RequestID(middleware.Logger("/posts/{postID}", func(w http.ResponseWriter, r *http.Request) {
+		logger.SetUserID(r.Context(), 3)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	}))
+
+	request := httptest.NewRequest("POST", "/posts/1", nil)
+	request.Header.Set(middleware.RequestIDHeader, "abc")
+
+	handler(httptest.NewRecorder(), request)
+
+	var record map[string]interface{}
+	assert.NoError(t, json.Unmarshal(output.Bytes(), &record))
+
+	assert.Equal(t, "request", record["msg"])
+	assert.Equal(t, "abc", record["request_id"])
+	assert.Equal(t, "POST", record["method"])
+	assert.Equal(t, "/posts/{postID}", record["route"])
+	assert.Equal(t, "/posts/1", record["uri"])
+	assert.Equal(t, float64(http.StatusCreated), record["status"])
+	assert.Equal(t, float64(len("created")), record["bytes"])
+	assert.Equal(t, float64(3), record["user_id"])
+	assert.Contains(t, record, "duration_ms")
+}
+
+func TestLoggerRedactsStreamTokens(t *testing.T) {
+	var output bytes.Buffer
+
+	defaultLogger := slog.Default()
+	defer slog.SetDefault(defaultLogger)
+
+	assert.NoError(t, logger.Setup(&output, logger.FormatJSON, "info"))
+
+	handler := middleware.Logger("/stream", func(w http.ResponseWriter, r *http.Request) {})
+
+	handler(httptest.NewRecorder(), httptest.NewRequest("GET", "/stream?last_event_id=4&token=secret", nil))
+
+	var record map[string]interface{}
+	assert.NoError(t, json.Unmarshal(output.Bytes(), &record))
+
+	assert.Equal(t, "/stream?last_event_id=4&token=REDACTED", record["uri"])
+}
+
+func TestLimitBody(t *testing.T) {
+	defaultMaxBodyBytes := config.MaxBodyBytes
+	config.MaxBodyBytes = 16
+	defer func() { config.MaxBodyBytes = defaultMaxBodyBytes }()
+
+	subTests := []struct {
+		name               string
+		body               string
+		expectedStatusCode int
+	}{
+		{
+			name:               "Body within the limit",
+			body:               `{"title": "ok"}`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Body over the limit",
+			body:               `{"title": "this is way too long"}`,
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	handler := middleware.LimitBody(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := ioutil.ReadAll(r.Body); err != nil {
+			response.Error(w, r, http.StatusUnprocessableEntity, model.NewUnreadableBodyError(err))
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	})
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			request := httptest.NewRequest("POST", "/posts", strings.NewReader(subTest.body))
+			recorder := httptest.NewRecorder()
+
+			handler(recorder, request)
+
+			assert.Equal(t, subTest.expectedStatusCode, recorder.Code, "Status code does not match with expected")
+		})
+	}
+}
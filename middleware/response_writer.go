@@ -0,0 +1,60 @@
+package middleware
+
+import "net/http"
+
+// responseRecorder records the status code and size of a response while writing it
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func newResponseRecorder(w http.ResponseWriter) *responseRecorder {
+	return &responseRecorder{ResponseWriter: w}
+}
+
+// WriteHeader records the status code before sending it
+func (recorder *responseRecorder) WriteHeader(status int) {
+	if recorder.status == 0 {
+		recorder.status = status
+	}
+
+	recorder.ResponseWriter.WriteHeader(status)
+}
+
+// Write records the size of the body, and the implicit 200 when no status was sent
+func (recorder *responseRecorder) Write(body []byte) (int, error) {
+	if recorder.status == 0 {
+		recorder.status = http.StatusOK
+	}
+
+	n, err := recorder.ResponseWriter.Write(body)
+	recorder.bytes += n
+
+	return n, err
+}
+
+// Flush sends the buffered data to the client, which keeps server-sent events streaming
+func (recorder *responseRecorder) Flush() {
+	if flusher, ok := recorder.ResponseWriter.(http.Flusher); ok {
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+
+		flusher.Flush()
+	}
+}
+
+// Unwrap gives http.ResponseController access to the original writer
+func (recorder *responseRecorder) Unwrap() http.ResponseWriter {
+	return recorder.ResponseWriter
+}
+
+// Status returns the status code sent, 200 if the handler wrote nothing
+func (recorder *responseRecorder) Status() int {
+	if recorder.status == 0 {
+		return http.StatusOK
+	}
+
+	return recorder.status
+}
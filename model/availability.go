@@ -0,0 +1,52 @@
+package model
+
+// Availability tells if the nick and the email a new user wants can be used. Only the values asked for are set
+type Availability struct {
+	Nick  *ValueAvailability `json:"nick,omitempty"`
+	Email *ValueAvailability `json:"email,omitempty"`
+}
+
+// ValueAvailability tells if a normalized value can be used, and the code of the reason when it cannot
+type ValueAvailability struct {
+	Value     string `json:"value"`
+	Available bool   `json:"available"`
+	Code      string `json:"code,omitempty"`
+}
+
+// NewAvailability normalizes and validates a nick and an email, leaving out the ones that are empty.
+// The values that pass the validation are still to be checked against the stored users
+func NewAvailability(nick, email string) Availability {
+	var availability Availability
+
+	if nick != "" {
+		availability.Nick = checkValue(NormalizeNick(nick), validateNick)
+	}
+
+	if email != "" {
+		availability.Email = checkValue(NormalizeEmail(email), validateEmail)
+	}
+
+	return availability
+}
+
+// MarkTaken records that a value is already used by another user
+func (value *ValueAvailability) MarkTaken() {
+	value.Available = false
+	value.Code = FieldTaken
+}
+
+// Pending tells if a value passed the validation and must be looked up
+func (value *ValueAvailability) Pending() bool {
+	return value != nil && value.Available
+}
+
+func checkValue(value string, validate func(*FieldErrors, string)) *ValueAvailability {
+	var fields FieldErrors
+	validate(&fields, value)
+
+	if len(fields) > 0 {
+		return &ValueAvailability{Value: value, Code: fields[0].Code}
+	}
+
+	return &ValueAvailability{Value: value, Available: true}
+}
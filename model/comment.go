@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+
+	"github.com/waliqueiroz/devbook-api/config"
+)
+
+// Comment represents a comment made by a user in a post
+type Comment struct {
+	ID         uint64    `json:"id,omitempty"`
+	PostID     uint64    `json:"post_id,omitempty"`
+	AuthorID   uint64    `json:"author_id,omitempty"`
+	AuthorNick string    `json:"author_nick,omitempty"`
+	Content    string    `json:"content,omitempty"`
+	CreatedAt  time.Time `json:"created_at,omitempty"`
+}
+
+// Prepare call methods to format and validate the data of a comment. HTML and control characters
+// are stripped before the validation, so a content made only of them is rejected
+func (comment *Comment) Prepare() error {
+	comment.format()
+
+	return comment.validate()
+}
+
+func (comment *Comment) validate() error {
+	var fields FieldErrors
+
+	if comment.Content == "" {
+		fields.Add("content", FieldRequired)
+	} else {
+		fields.checkLength("content", comment.Content, config.MaxCommentLength)
+	}
+
+	return fields.Err()
+}
+
+func (comment *Comment) format() {
+	comment.Content = cleanText(comment.Content)
+}
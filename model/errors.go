@@ -0,0 +1,223 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// Error codes sent to API clients, so they do not have to parse messages
+const (
+	CodeBadRequest       = "bad_request"
+	CodeInvalidBody      = "invalid_body"
+	CodeUnreadableBody   = "unreadable_body"
+	CodeBodyTooLarge     = "body_too_large"
+	CodeValidationFailed = "validation_failed"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotVerified      = "email_not_verified"
+	CodeInvalidToken     = "invalid_token"
+	CodeNotFound         = "not_found"
+	CodeConflict         = "conflict"
+	CodeVersionMismatch  = "version_mismatch"
+	CodeUnsupportedMedia = "unsupported_media_type"
+	CodeUnavailable      = "unavailable"
+	CodeClientClosed     = "client_closed_request"
+	CodeInternal         = "internal_error"
+)
+
+// Codes of the problems found in a field, so clients can map them to their own messages
+const (
+	FieldRequired       = "required"
+	FieldInvalidEmail   = "invalid_email"
+	FieldReplyAndRepost = "reply_and_repost"
+	FieldInvalidRange   = "invalid_range"
+	FieldTooLong        = "too_long"
+	FieldTooShort       = "too_short"
+	FieldInvalidNick    = "invalid_nick"
+	FieldReserved       = "reserved"
+	FieldTaken          = "taken"
+)
+
+// AppError is an error that is safe to show to API clients. Its message is public,
+// while its cause, if any, holds the details that should only go to the logs
+type AppError struct {
+	Code    string
+	Status  int
+	Message string
+	Field   string
+	Fields  []FieldError
+	Cause   error
+}
+
+// FieldError is a problem found in a field of the data sent by a client. The message is left
+// empty by the validations and is filled in the language of the client when the error is sent
+type FieldError struct {
+	Field   string        `json:"field"`
+	Code    string        `json:"code"`
+	Message string        `json:"message"`
+	Args    []interface{} `json:"-"`
+}
+
+// FieldErrors collects the problems found while validating some data
+type FieldErrors []FieldError
+
+// Add records a problem found in a field, along with the values its message refers to
+func (fields *FieldErrors) Add(field, code string, args ...interface{}) {
+	*fields = append(*fields, FieldError{Field: field, Code: code, Args: args})
+}
+
+// checkLength records a problem if a field has more characters than allowed
+func (fields *FieldErrors) checkLength(field, value string, max int) {
+	if utf8.RuneCountInString(value) > max {
+		fields.Add(field, FieldTooLong, max)
+	}
+}
+
+// Err returns a validation error with every problem found, or nil if there is none
+func (fields FieldErrors) Err() error {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	return NewValidationError(fields...)
+}
+
+func (err *AppError) Error() string {
+	if len(err.Fields) > 0 {
+		codes := make([]string, len(err.Fields))
+		for i, field := range err.Fields {
+			codes[i] = field.Field + ": " + field.Code
+		}
+
+		return fmt.Sprintf("%s (%s)", err.Message, strings.Join(codes, ", "))
+	}
+
+	if err.Cause != nil {
+		return fmt.Sprintf("%s: %v", err.Message, err.Cause)
+	}
+
+	return err.Message
+}
+
+// Unwrap returns the cause of the error
+func (err *AppError) Unwrap() error {
+	return err.Cause
+}
+
+// NewAppError creates an error with a public code and message for a given HTTP status
+func NewAppError(status int, code, message string, cause error) *AppError {
+	return &AppError{Code: code, Status: status, Message: message, Cause: cause}
+}
+
+// NewBadRequestError creates an error for a request with invalid parameters
+func NewBadRequestError(message string) *AppError {
+	return NewAppError(http.StatusBadRequest, CodeBadRequest, message, nil)
+}
+
+// NewInvalidBodyError creates an error for a request body that is not valid JSON
+func NewInvalidBodyError(cause error) *AppError {
+	return NewAppError(http.StatusBadRequest, CodeInvalidBody, "the request body is not valid JSON", cause)
+}
+
+// NewUnreadableBodyError creates an error for a request body that could not be read,
+// telling apart the bodies that were cut for going over the size limit
+func NewUnreadableBodyError(cause error) *AppError {
+	var maxBytesError *http.MaxBytesError
+	if errors.As(cause, &maxBytesError) {
+		return NewAppError(http.StatusRequestEntityTooLarge, CodeBodyTooLarge, fmt.Sprintf("the request body must have at most %d bytes", maxBytesError.Limit), cause)
+	}
+
+	return NewAppError(http.StatusUnprocessableEntity, CodeUnreadableBody, "the request body could not be read", cause)
+}
+
+// NewValidationError creates an error for data that breaks the rules of the model in some fields
+func NewValidationError(fields ...FieldError) *AppError {
+	validationError := NewAppError(http.StatusUnprocessableEntity, CodeValidationFailed, "some fields are not valid", nil)
+	validationError.Fields = fields
+
+	return validationError
+}
+
+// NewUnauthorizedError creates an error for a request that could not be authenticated
+func NewUnauthorizedError(message string, cause error) *AppError {
+	return NewAppError(http.StatusUnauthorized, CodeUnauthorized, message, cause)
+}
+
+// NewForbiddenError creates an error for an action the authenticated user is not allowed to do
+func NewForbiddenError(message string) *AppError {
+	return NewAppError(http.StatusForbidden, CodeForbidden, message, nil)
+}
+
+// NewNotVerifiedError creates an error for a user that tries to log in before confirming the email of the account
+func NewNotVerifiedError() *AppError {
+	return NewAppError(http.StatusForbidden, CodeNotVerified, "the email of the account was not verified yet", nil)
+}
+
+// NewInvalidTokenError creates an error for a single-use token that is malformed, expired or was already used
+func NewInvalidTokenError(cause error) *AppError {
+	return NewAppError(http.StatusBadRequest, CodeInvalidToken, "the token is invalid, expired or was already used", cause)
+}
+
+// NewConflictError creates an error for a value of a field that must be unique and is already in use
+func NewConflictError(field string, cause error) *AppError {
+	conflictError := NewAppError(http.StatusConflict, CodeConflict, fmt.Sprintf("the %s is already in use", field), cause)
+	conflictError.Field = field
+
+	return conflictError
+}
+
+// NewVersionMismatchError creates an error for an update based on a version of a resource that is no longer the current one
+func NewVersionMismatchError(resource string) *AppError {
+	return NewAppError(http.StatusPreconditionFailed, CodeVersionMismatch, fmt.Sprintf("the %s was changed by another request, fetch it again before updating", resource), nil)
+}
+
+// NewUnsupportedMediaError creates an error for a request body in a format the endpoint does not accept
+func NewUnsupportedMediaError(message string) *AppError {
+	return NewAppError(http.StatusUnsupportedMediaType, CodeUnsupportedMedia, message, nil)
+}
+
+// NewInternalError creates an error for a failure the client can do nothing about
+func NewInternalError(cause error) *AppError {
+	return NewAppError(http.StatusInternalServerError, CodeInternal, "internal server error", cause)
+}
+
+// NewNotFoundError creates an error for a requested resource that does not exist
+func NewNotFoundError(resource string) *AppError {
+	return NewAppError(http.StatusNotFound, CodeNotFound, fmt.Sprintf("%s not found", resource), nil)
+}
+
+// IsNotFound tells if an error is about a resource that does not exist
+func IsNotFound(err error) bool {
+	var appError *AppError
+
+	return errors.As(err, &appError) && appError.Code == CodeNotFound
+}
+
+// ToAppError returns the AppError found in the chain of an error. Errors the API does not know are
+// turned into a generic error for a given status, so their message never reaches the clients
+func ToAppError(err error, status int) *AppError {
+	var appError *AppError
+	if errors.As(err, &appError) {
+		return appError
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return NewAppError(http.StatusServiceUnavailable, CodeUnavailable, "the service is temporarily unavailable, try again later", err)
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return NewAppError(status, CodeClientClosed, "the request was cancelled", err)
+	}
+
+	if status >= http.StatusInternalServerError || http.StatusText(status) == "" {
+		return NewInternalError(err)
+	}
+
+	text := strings.ToLower(http.StatusText(status))
+
+	return NewAppError(status, strings.ReplaceAll(text, " ", "_"), text, err)
+}
@@ -0,0 +1,32 @@
+package model
+
+// Health statuses
+const (
+	HealthOK       = "ok"
+	HealthReady    = "ready"
+	HealthNotReady = "not_ready"
+	HealthFailing  = "failing"
+)
+
+// Health reports if the API is alive or ready to receive traffic, along with the checks that led to it
+type Health struct {
+	Status string                 `json:"status"`
+	Checks map[string]HealthCheck `json:"checks,omitempty"`
+}
+
+// HealthCheck reports the result of checking a dependency of the API
+type HealthCheck struct {
+	Status string             `json:"status"`
+	Error  string             `json:"error,omitempty"`
+	Pool   *DatabasePoolStats `json:"pool,omitempty"`
+}
+
+// DatabasePoolStats reports the state of the database connection pool
+type DatabasePoolStats struct {
+	MaxOpenConnections int   `json:"max_open_connections"`
+	OpenConnections    int   `json:"open_connections"`
+	InUse              int   `json:"in_use"`
+	Idle               int   `json:"idle"`
+	WaitCount          int64 `json:"wait_count"`
+	WaitDurationMillis int64 `json:"wait_duration_ms"`
+}
@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+// Types of notification
+const (
+	NotificationFollow  = "follow"
+	NotificationLike    = "like"
+	NotificationComment = "comment"
+	NotificationMention = "mention"
+)
+
+// Notification represents something another user did that concerns a user
+type Notification struct {
+	ID        uint64    `json:"id,omitempty"`
+	UserID    uint64    `json:"-"`
+	ActorID   uint64    `json:"actor_id,omitempty"`
+	ActorNick string    `json:"actor_nick,omitempty"`
+	Type      string    `json:"type,omitempty"`
+	PostID    *uint64   `json:"post_id,omitempty"`
+	CommentID *uint64   `json:"comment_id,omitempty"`
+	Read      bool      `json:"read"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
+
+// IsSelfAction tells if the user was notified about something they did themselves
+func (notification Notification) IsSelfAction() bool {
+	return notification.UserID == notification.ActorID
+}
+
+// ReadNotifications represents a request to mark notifications as read. No IDs means all of them
+type ReadNotifications struct {
+	IDs []uint64 `json:"ids"`
+}
+
+// UnreadNotifications represents how many notifications a user has not read yet
+type UnreadNotifications struct {
+	Unread uint64 `json:"unread"`
+}
@@ -0,0 +1,114 @@
+package model
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+)
+
+// PageRequest holds the parameters used to fetch a page of a list
+type PageRequest struct {
+	Limit  uint64
+	Cursor uint64
+}
+
+// SearchPageRequest holds the parameters used to fetch a page of search results. As they are ranked
+// by relevance instead of by ID, the next page starts after the score and the ID of the last result
+type SearchPageRequest struct {
+	Limit uint64
+	After *SearchCursor
+}
+
+// SearchCursor points to a search result by its relevance and its ID
+type SearchCursor struct {
+	Score float64
+	ID    uint64
+}
+
+// PostPage represents a page of posts and the cursor to fetch the next one
+type PostPage struct {
+	Data       []Post `json:"data"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// UserPage represents a page of users and the cursor to fetch the next one
+type UserPage struct {
+	Data       []User `json:"data"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// CommentPage represents a page of comments and the cursor to fetch the next one
+type CommentPage struct {
+	Data       []Comment `json:"data"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+// PostSearchPage represents a page of search results and the cursor to fetch the next one
+type PostSearchPage struct {
+	Data       []PostSearchResult `json:"data"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+// NotificationPage represents a page of notifications and the cursor to fetch the next one
+type NotificationPage struct {
+	Data       []Notification `json:"data"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// EncodeCursor turns the ID of the last item of a page into an opaque cursor
+func EncodeCursor(id uint64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(id, 10)))
+}
+
+// DecodeCursor returns the item ID hidden inside an opaque cursor. An empty cursor points to the first page
+func DecodeCursor(cursor string) (uint64, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, NewBadRequestError("invalid cursor")
+	}
+
+	id, err := strconv.ParseUint(string(decoded), 10, 64)
+	if err != nil || id == 0 {
+		return 0, NewBadRequestError("invalid cursor")
+	}
+
+	return id, nil
+}
+
+// EncodeSearchCursor turns the score and the ID of the last result of a page into an opaque cursor
+func EncodeSearchCursor(score float64, id uint64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatFloat(score, 'g', -1, 64) + ":" + strconv.FormatUint(id, 10)))
+}
+
+// DecodeSearchCursor returns the search result pointed by an opaque cursor. An empty cursor points to the first page
+func DecodeSearchCursor(cursor string) (*SearchCursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, NewBadRequestError("invalid cursor")
+	}
+
+	score, id, found := strings.Cut(string(decoded), ":")
+	if !found {
+		return nil, NewBadRequestError("invalid cursor")
+	}
+
+	parsedScore, err := strconv.ParseFloat(score, 64)
+	if err != nil {
+		return nil, NewBadRequestError("invalid cursor")
+	}
+
+	parsedID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil || parsedID == 0 {
+		return nil, NewBadRequestError("invalid cursor")
+	}
+
+	return &SearchCursor{Score: parsedScore, ID: parsedID}, nil
+}
@@ -1,6 +1,20 @@
 package model
 
+// Password holds the current password of a user and the one that will replace it
 type Password struct {
 	New     string `json:"new"`
 	Current string `json:"current"`
 }
+
+// Validate checks the passwords sent to replace the one of a user
+func (password Password) Validate() error {
+	var fields FieldErrors
+
+	if password.Current == "" {
+		fields.Add("current", FieldRequired)
+	}
+
+	validatePassword(&fields, "new", password.New)
+
+	return fields.Err()
+}
@@ -1,24 +1,40 @@
 package model
 
 import (
-	"errors"
 	"strings"
 	"time"
+
+	"github.com/waliqueiroz/devbook-api/config"
 )
 
 // Post represents a post made by a user
 type Post struct {
-	ID         uint64    `json:"id,omitempty"`
-	Title      string    `json:"title,omitempty"`
-	Content    string    `json:"content,omitempty"`
-	AuthorID   uint64    `json:"author_id,omitempty"`
-	AuthorNick string    `json:"author_nick,omitempty"`
-	Likes      uint64    `json:"likes"`
-	CreatedAt  time.Time `json:"created_at,omitempty"`
+	ID           uint64    `json:"id,omitempty"`
+	Title        string    `json:"title,omitempty"`
+	Content      string    `json:"content,omitempty"`
+	AuthorID     uint64    `json:"author_id,omitempty"`
+	AuthorNick   string    `json:"author_nick,omitempty"`
+	Likes        uint64    `json:"likes"`
+	LikedByMe    bool      `json:"liked_by_me"`
+	CommentCount uint64    `json:"comment_count"`
+	ReplyCount   uint64    `json:"reply_count"`
+	RepostCount  uint64    `json:"repost_count"`
+	ParentPostID *uint64   `json:"parent_post_id,omitempty"`
+	RepostOfID   *uint64   `json:"repost_of_id,omitempty"`
+	RepostOf     *Post     `json:"repost_of,omitempty"`
+	Unavailable  bool      `json:"unavailable,omitempty"`
+	Tags         []string  `json:"-"`
+	Mentions     []string  `json:"-"`
+	Version      uint64    `json:"-"`
+	CreatedAt    time.Time `json:"created_at,omitempty"`
+	UpdatedAt    time.Time `json:"updated_at,omitempty"`
 }
 
-// Prepare call methods to validate and format the data of a post
+// Prepare call methods to sanitize, validate and format the data of a post. HTML and control
+// characters are stripped before the validation, so a content made only of them is rejected
 func (post *Post) Prepare() error {
+	post.sanitize()
+
 	if err := post.validate(); err != nil {
 		return err
 	}
@@ -27,19 +43,71 @@ func (post *Post) Prepare() error {
 	return nil
 }
 
+// IsRepost tells if the post re-shares another one
+func (post Post) IsRepost() bool {
+	return post.RepostOfID != nil
+}
+
+// IsPlainRepost tells if the post re-shares another one without adding anything to it
+func (post Post) IsPlainRepost() bool {
+	return post.IsRepost() && strings.TrimSpace(post.Title) == "" && strings.TrimSpace(post.Content) == ""
+}
+
+// AddedMentions returns the nicks the post mentions that a previous version of it did not
+func (post Post) AddedMentions(previous Post) []string {
+	mentionedBefore := make(map[string]bool)
+	for _, nick := range extractMentions(previous.Content) {
+		mentionedBefore[strings.ToLower(nick)] = true
+	}
+
+	var added []string
+
+	for _, nick := range post.Mentions {
+		if !mentionedBefore[strings.ToLower(nick)] {
+			added = append(added, nick)
+		}
+	}
+
+	return added
+}
+
+func (post *Post) sanitize() {
+	post.Title = cleanLine(htmlPattern.ReplaceAllString(post.Title, ""))
+	post.Content = cleanText(post.Content)
+}
+
 func (post *Post) validate() error {
-	if post.Title == "" {
-		return errors.New("o campo título é obrigatório")
+	var fields FieldErrors
+
+	if post.ParentPostID != nil && post.RepostOfID != nil {
+		fields.Add("repost_of_id", FieldReplyAndRepost)
+	}
+
+	fields.checkLength("title", post.Title, config.MaxTitleLength)
+	fields.checkLength("content", post.Content, config.MaxPostContentLength)
+
+	// a reply or a quote only needs its content, and a plain repost does not need anything
+	if post.IsRepost() {
+		return fields.Err()
+	}
+
+	if post.Title == "" && post.ParentPostID == nil {
+		fields.Add("title", FieldRequired)
 	}
 
 	if post.Content == "" {
-		return errors.New("o campo conteúdo é obrigatório")
+		fields.Add("content", FieldRequired)
 	}
 
-	return nil
+	return fields.Err()
 }
 
 func (post *Post) format() {
-	post.Title = strings.TrimSpace(post.Title)
-	post.Content = strings.TrimSpace(post.Content)
+	post.ExtractReferences()
+}
+
+// ExtractReferences fills the tags and the nicks mentioned in the content of the post
+func (post *Post) ExtractReferences() {
+	post.Tags = extractTags(post.Content)
+	post.Mentions = extractMentions(post.Content)
 }
@@ -0,0 +1,143 @@
+package model
+
+import (
+	"html"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// snippetLength is the maximum number of characters of a post shown in a search result
+const snippetLength = 160
+
+// PostSearch holds the filters of a full-text search over posts
+type PostSearch struct {
+	Query    string
+	AuthorID *uint64
+	From     *time.Time
+	Until    *time.Time
+}
+
+// PostSearchResult represents a post found by a search, along with its relevance and a highlighted snippet
+type PostSearchResult struct {
+	Post
+	Score   float64 `json:"score"`
+	Snippet string  `json:"snippet"`
+}
+
+// Prepare call methods to validate and format the filters of a search
+func (search *PostSearch) Prepare() error {
+	search.Query = strings.TrimSpace(search.Query)
+
+	var fields FieldErrors
+
+	if search.Query == "" {
+		fields.Add("q", FieldRequired)
+	}
+
+	if search.From != nil && search.Until != nil && search.Until.Before(*search.From) {
+		fields.Add("until", FieldInvalidRange)
+	}
+
+	return fields.Err()
+}
+
+// Terms returns the lower case words of the search query
+func (search PostSearch) Terms() []string {
+	var terms []string
+
+	for _, field := range strings.Fields(strings.ToLower(search.Query)) {
+		term := strings.TrimFunc(field, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+		})
+
+		if term != "" {
+			terms = append(terms, term)
+		}
+	}
+
+	return terms
+}
+
+// Snippet returns an HTML escaped excerpt of a text around the first search term found in it,
+// with every term wrapped in <mark> tags
+func (search PostSearch) Snippet(text string) string {
+	terms := search.Terms()
+
+	original := []rune(text)
+	lower := make([]rune, len(original))
+	for i, r := range original {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	start := 0
+	for i := range lower {
+		if matchTerm(lower, i, terms) > 0 {
+			start = i - snippetLength/4
+			break
+		}
+	}
+
+	if start < 0 {
+		start = 0
+	}
+
+	end := start + snippetLength
+	if end > len(original) {
+		end = len(original)
+	}
+
+	var snippet strings.Builder
+
+	if start > 0 {
+		snippet.WriteString("…")
+	}
+
+	for i := start; i < end; {
+		length := matchTerm(lower[:end], i, terms)
+		if length == 0 {
+			snippet.WriteString(html.EscapeString(string(original[i])))
+			i++
+			continue
+		}
+
+		snippet.WriteString("<mark>")
+		snippet.WriteString(html.EscapeString(string(original[i : i+length])))
+		snippet.WriteString("</mark>")
+		i += length
+	}
+
+	if end < len(original) {
+		snippet.WriteString("…")
+	}
+
+	return snippet.String()
+}
+
+// matchTerm returns the length of the term that appears as a whole word at a given position, or 0 if there is none
+func matchTerm(text []rune, position int, terms []string) int {
+	if position > 0 && isWordRune(text[position-1]) {
+		return 0
+	}
+
+	for _, term := range terms {
+		termRunes := []rune(term)
+		end := position + len(termRunes)
+
+		if end > len(text) || string(text[position:end]) != term {
+			continue
+		}
+
+		if end < len(text) && isWordRune(text[end]) {
+			continue
+		}
+
+		return len(termRunes)
+	}
+
+	return 0
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r)
+}
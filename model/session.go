@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// Session represents a login of a user, kept alive by a rotating refresh token
+type Session struct {
+	ID               uint64
+	UserID           uint64
+	RefreshTokenHash string
+	ExpiresAt        time.Time
+}
+
+// AuthTokens holds the tokens issued when a session is created or refreshed
+type AuthTokens struct {
+	AccessToken  string    `json:"access_token"`
+	TokenType    string    `json:"token_type"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	RefreshToken string    `json:"refresh_token"`
+}
+
+// Login holds the tokens of a new session along with the profile of the logged user
+type Login struct {
+	AuthTokens
+	User User `json:"user"`
+}
+
+// RefreshRequest holds the refresh token sent by a client to renew its access token
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// StreamToken opens the event stream from clients that cannot send the Authorization header, passed in the token query parameter
+type StreamToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxTagLength is the size of the tag column
+const maxTagLength = 100
+
+// tags and mentions must not be glued to a previous word, so e-mails and URL fragments are left alone
+var (
+	tagPattern     = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&/])#([\p{L}\p{N}_]+)`)
+	mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_.@])@([\p{L}\p{N}_.]+)`)
+)
+
+// TrendingTag represents a tag and how many recent posts used it
+type TrendingTag struct {
+	Tag   string `json:"tag"`
+	Posts uint64 `json:"posts"`
+}
+
+// NormalizeTag returns a tag the way it is stored, in lower case and without the leading #
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+}
+
+// extractTags returns the distinct #tags of a text, normalized
+func extractTags(text string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+
+	for _, match := range tagPattern.FindAllStringSubmatch(text, -1) {
+		tag := NormalizeTag(match[1])
+
+		if seen[tag] || utf8.RuneCountInString(tag) > maxTagLength {
+			continue
+		}
+
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+
+	return tags
+}
+
+// extractMentions returns the distinct nicks mentioned with @ in a text
+func extractMentions(text string) []string {
+	var nicks []string
+	seen := make(map[string]bool)
+
+	for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		nick := strings.TrimRight(match[1], ".")
+		key := strings.ToLower(nick)
+
+		if nick == "" || seen[key] {
+			continue
+		}
+
+		seen[key] = true
+		nicks = append(nicks, nick)
+	}
+
+	return nicks
+}
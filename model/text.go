@@ -0,0 +1,82 @@
+package model
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// minNickLength is the shortest nick accepted
+const minNickLength = 3
+
+// maxPasswordBytes is the longest password bcrypt takes into account, anything after it would be ignored
+const maxPasswordBytes = 72
+
+// nickPattern is the grammar of nicks, checked after they are normalized
+var nickPattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// htmlPattern matches HTML comments and tags, which are stripped from the text of posts and comments.
+// Scripts and styles are dropped along with what is inside them
+var htmlPattern = regexp.MustCompile(`(?is)<script\b.*?</script\s*>|<style\b.*?</style\s*>|<!--.*?-->|</?[a-z][^>]*>`)
+
+// reservedNicks cannot be taken by users, as they could be mistaken for the staff or for routes of the clients
+var reservedNicks = map[string]bool{
+	"admin":         true,
+	"administrator": true,
+	"api":           true,
+	"devbook":       true,
+	"help":          true,
+	"login":         true,
+	"logout":        true,
+	"me":            true,
+	"moderator":     true,
+	"null":          true,
+	"register":      true,
+	"root":          true,
+	"settings":      true,
+	"staff":         true,
+	"support":       true,
+	"system":        true,
+	"undefined":     true,
+}
+
+// NormalizeNick returns a nick the way it is stored. The compatibility normalization folds characters that
+// look the same, like full-width letters, into their plain form, so they cannot be used to impersonate someone
+func NormalizeNick(nick string) string {
+	return strings.ToLower(norm.NFKC.String(strings.TrimSpace(nick)))
+}
+
+// NormalizeEmail returns an email the way it is stored, normalized like a nick
+func NormalizeEmail(email string) string {
+	return strings.ToLower(norm.NFKC.String(strings.TrimSpace(email)))
+}
+
+// cleanLine normalizes a single line text, like a name or a title, dropping any control character
+func cleanLine(text string) string {
+	return strings.TrimSpace(stripControlCharacters(norm.NFC.String(text), false))
+}
+
+// cleanText normalizes a text written by a user, removing HTML and control characters but keeping line breaks and tabs
+func cleanText(text string) string {
+	text = strings.ReplaceAll(norm.NFC.String(text), "\r\n", "\n")
+
+	return strings.TrimSpace(stripControlCharacters(htmlPattern.ReplaceAllString(text, ""), true))
+}
+
+// stripControlCharacters removes control characters from a text, along with the invisible ones that can
+// hide or reorder what is shown. Zero width joiners are kept, as emojis are made of them
+func stripControlCharacters(text string, keepLineBreaks bool) string {
+	return strings.Map(func(r rune) rune {
+		if keepLineBreaks && (r == '\n' || r == '\t') {
+			return r
+		}
+
+		if unicode.IsControl(r) || unicode.Is(unicode.Bidi_Control, r) || r == '\u200b' || r == '\u2060' || r == '\ufeff' {
+			return -1
+		}
+
+		return r
+	}, text)
+}
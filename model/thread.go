@@ -0,0 +1,37 @@
+package model
+
+// PostThread represents a post along with the tree of replies below it
+type PostThread struct {
+	Post
+	Replies []PostThread `json:"replies"`
+}
+
+// NewPostThread builds the conversation tree rooted at a given post from a flat list of posts
+func NewPostThread(posts []Post, rootID uint64) PostThread {
+	replies := make(map[uint64][]Post)
+
+	var root Post
+
+	for _, post := range posts {
+		if post.ID == rootID {
+			root = post
+			continue
+		}
+
+		if post.ParentPostID != nil {
+			replies[*post.ParentPostID] = append(replies[*post.ParentPostID], post)
+		}
+	}
+
+	return buildPostThread(root, replies)
+}
+
+func buildPostThread(post Post, replies map[uint64][]Post) PostThread {
+	thread := PostThread{Post: post, Replies: []PostThread{}}
+
+	for _, reply := range replies[post.ID] {
+		thread.Replies = append(thread.Replies, buildPostThread(reply, replies))
+	}
+
+	return thread
+}
@@ -1,26 +1,37 @@
 package model
 
 import (
-	"errors"
-	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/badoux/checkmail"
+	"github.com/waliqueiroz/devbook-api/config"
 	"github.com/waliqueiroz/devbook-api/security"
 )
 
 // User represents an User
 type User struct {
-	ID        uint64    `json:"id,omitempty"`
-	Name      string    `json:"name,omitempty"`
-	Nick      string    `json:"nick,omitempty"`
-	Email     string    `json:"email,omitempty"`
-	Password  string    `json:"password,omitempty"`
-	CreatedAt time.Time `json:"created_at,omitempty"`
+	ID         uint64     `json:"id,omitempty"`
+	Name       string     `json:"name,omitempty"`
+	Nick       string     `json:"nick,omitempty"`
+	Email      string     `json:"email,omitempty"`
+	Password   string     `json:"password,omitempty"`
+	Version    uint64     `json:"-"`
+	CreatedAt  time.Time  `json:"created_at,omitempty"`
+	UpdatedAt  time.Time  `json:"updated_at,omitempty"`
+	VerifiedAt *time.Time `json:"-"`
 }
 
-// Prepare call methods to validate and format the data of user
+// Verified tells if the user confirmed the email of the account
+func (user User) Verified() bool {
+	return user.VerifiedAt != nil
+}
+
+// Prepare call methods to normalize, validate and format the data of user.
+// Fields are normalized first, so the limits apply to what is stored
 func (user *User) Prepare(step string) error {
+	user.normalize()
+
 	if err := user.validate(step); err != nil {
 		return err
 	}
@@ -32,39 +43,37 @@ func (user *User) Prepare(step string) error {
 	return nil
 }
 
+func (user *User) normalize() {
+	user.Name = cleanLine(user.Name)
+	user.Nick = NormalizeNick(user.Nick)
+	user.Email = NormalizeEmail(user.Email)
+}
+
 func (user *User) validate(step string) error {
-	if user.Name == "" {
-		return errors.New("o campo nome é obrigatório")
-	}
+	var fields FieldErrors
 
-	if user.Nick == "" {
-		return errors.New("o campo nick é obrigatório")
+	if user.Name == "" {
+		fields.Add("name", FieldRequired)
+	} else {
+		fields.checkLength("name", user.Name, config.MaxNameLength)
 	}
 
-	if user.Email == "" {
-		return errors.New("o campo email é obrigatório")
-	}
+	validateNick(&fields, user.Nick)
 
-	if err := checkmail.ValidateFormat(user.Email); err != nil {
-		return errors.New("o email inserido é inválido")
-	}
+	validateEmail(&fields, user.Email)
 
-	if step == "register" && user.Password == "" {
-		return errors.New("o campo senha é obrigatório")
+	if step == "register" {
+		validatePassword(&fields, "password", user.Password)
 	}
 
-	return nil
+	return fields.Err()
 }
 
 func (user *User) format(step string) error {
-	user.Name = strings.TrimSpace(user.Name)
-	user.Nick = strings.TrimSpace(user.Nick)
-	user.Email = strings.TrimSpace(user.Email)
-
 	if step == "register" {
 		hashedPassword, err := security.Hash(user.Password)
 		if err != nil {
-			return err
+			return NewInternalError(err)
 		}
 
 		user.Password = string(hashedPassword)
@@ -72,3 +81,40 @@ func (user *User) format(step string) error {
 
 	return nil
 }
+
+// validateNick checks a normalized nick against its grammar and the reserved nicks
+func validateNick(fields *FieldErrors, nick string) {
+	switch {
+	case nick == "":
+		fields.Add("nick", FieldRequired)
+	case len(nick) < minNickLength:
+		fields.Add("nick", FieldTooShort, minNickLength)
+	case len(nick) > config.MaxNickLength:
+		fields.Add("nick", FieldTooLong, config.MaxNickLength)
+	case !nickPattern.MatchString(nick):
+		fields.Add("nick", FieldInvalidNick)
+	case reservedNicks[nick]:
+		fields.Add("nick", FieldReserved)
+	}
+}
+
+// validateEmail checks a normalized email
+func validateEmail(fields *FieldErrors, email string) {
+	switch {
+	case email == "":
+		fields.Add("email", FieldRequired)
+	case utf8.RuneCountInString(email) > config.MaxEmailLength:
+		fields.Add("email", FieldTooLong, config.MaxEmailLength)
+	case checkmail.ValidateFormat(email) != nil:
+		fields.Add("email", FieldInvalidEmail)
+	}
+}
+
+// validatePassword checks a password that is about to be hashed
+func validatePassword(fields *FieldErrors, field, password string) {
+	if password == "" {
+		fields.Add(field, FieldRequired)
+	} else if len(password) > maxPasswordBytes {
+		fields.Add(field, FieldTooLong, maxPasswordBytes)
+	}
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// Verification is a pending confirmation of the email of a user, proven by a single-use token
+type Verification struct {
+	UserID    uint64
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+// VerifyRequest holds the token a user received by email to confirm the address
+type VerifyRequest struct {
+	Token string `json:"token"`
+}
+
+// ResendVerificationRequest holds the email of an account that needs a new verification token
+type ResendVerificationRequest struct {
+	Email string `json:"email"`
+}
+
+// Email is a plain text message sent to a single address
+type Email struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// NewVerificationEmail creates the email that asks a new user to confirm the address through a link
+func NewVerificationEmail(user User, link string, expiresAt time.Time) Email {
+	return Email{
+		To:      user.Email,
+		Subject: "Confirm your email",
+		Body: fmt.Sprintf("Hi %s,\n\nConfirm the email of your DevBook account by opening the link below before %s:\n\n%s\n\nIf you did not create an account, ignore this message.\n",
+			user.Name, expiresAt.UTC().Format(time.RFC1123), link),
+	}
+}
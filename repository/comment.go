@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/waliqueiroz/devbook-api/model"
+)
+
+type CommentRepository struct {
+	db *sql.DB
+}
+
+// NewCommentRepository creates a new comment repository
+func NewCommentRepository(db *sql.DB) *CommentRepository {
+	return &CommentRepository{db}
+}
+
+// Create inserts a comment into database
+func (repository CommentRepository) Create(ctx context.Context, comment model.Comment) (model.Comment, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, err := repository.db.PrepareContext(ctx, "insert into comments (post_id, author_id, content) values (?, ?, ?)")
+	if err != nil {
+		return model.Comment{}, err
+	}
+	defer statement.Close()
+
+	result, err := statement.ExecContext(ctx, comment.PostID, comment.AuthorID, comment.Content)
+	if err != nil {
+		return model.Comment{}, err
+	}
+
+	lastInsertID, err := result.LastInsertId()
+	if err != nil {
+		return model.Comment{}, err
+	}
+
+	newComment, err := repository.FindByID(ctx, uint64(lastInsertID))
+	if err != nil {
+		return model.Comment{}, err
+	}
+
+	return newComment, nil
+}
+
+// FindByID returns a comment that match with a given ID or a not found error if there is none
+func (repository CommentRepository) FindByID(ctx context.Context, commentID uint64) (model.Comment, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := repository.db.QueryContext(ctx, `select c.id, c.post_id, c.author_id, u.nick, c.content, c.created_at
+									from comments c join users u on c.author_id = u.id where c.id = ?`, commentID)
+	if err != nil {
+		return model.Comment{}, err
+	}
+
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return model.Comment{}, err
+		}
+
+		return model.Comment{}, model.NewNotFoundError("comment")
+	}
+
+	var comment model.Comment
+
+	err = rows.Scan(&comment.ID, &comment.PostID, &comment.AuthorID, &comment.AuthorNick, &comment.Content, &comment.CreatedAt)
+	if err != nil {
+		return model.Comment{}, err
+	}
+
+	return comment, nil
+}
+
+// FindByPost returns a page of comments of a given post, from the oldest to the newest
+func (repository CommentRepository) FindByPost(ctx context.Context, postID uint64, page model.PageRequest) (model.CommentPage, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := repository.db.QueryContext(ctx, `select c.id, c.post_id, c.author_id, u.nick, c.content, c.created_at
+									from comments c join users u on c.author_id = u.id
+									where c.post_id = ? and c.id > ?
+									order by c.id limit ?`, postID, page.Cursor, page.Limit+1)
+	if err != nil {
+		return model.CommentPage{}, err
+	}
+
+	defer rows.Close()
+
+	var comments []model.Comment
+
+	for rows.Next() {
+		var comment model.Comment
+
+		err = rows.Scan(&comment.ID, &comment.PostID, &comment.AuthorID, &comment.AuthorNick, &comment.Content, &comment.CreatedAt)
+		if err != nil {
+			return model.CommentPage{}, err
+		}
+
+		comments = append(comments, comment)
+	}
+
+	if err = rows.Err(); err != nil {
+		return model.CommentPage{}, err
+	}
+
+	return newCommentPage(comments, page), nil
+}
+
+// Update updates a comment in database
+func (repository CommentRepository) Update(ctx context.Context, commentID uint64, comment model.Comment) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, err := repository.db.PrepareContext(ctx, "update comments set content = ? where id = ?")
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	_, err = statement.ExecContext(ctx, comment.Content, commentID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Delete deletes a comment from database
+func (repository CommentRepository) Delete(ctx context.Context, commentID uint64) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, err := repository.db.PrepareContext(ctx, "delete from comments where id = ?")
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	_, err = statement.ExecContext(ctx, commentID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
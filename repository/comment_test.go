@@ -0,0 +1,392 @@
+package repository_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/waliqueiroz/devbook-api/model"
+	"github.com/waliqueiroz/devbook-api/repository"
+	"github.com/waliqueiroz/devbook-api/test/mock"
+)
+
+func TestCreateComment(t *testing.T) {
+	commentJson, _ := ioutil.ReadFile("../test/resource/json/created_comment.json")
+
+	var comment model.Comment
+	json.Unmarshal(commentJson, &comment)
+
+	db, mock := mock.NewDatabaseConnection()
+	defer db.Close()
+
+	subTests := []struct {
+		name           string
+		errorInPrepare bool
+		errorInExec    bool
+		errorInResult  bool
+		errorInScanRow bool
+		err            error
+	}{
+		{
+			name: "Create comment",
+		},
+		{
+			name:           "Create comment - error in prepare",
+			errorInPrepare: true,
+			err:            errors.New("some error"),
+		},
+		{
+			name:        "Create comment - error in exec",
+			errorInExec: true,
+			err:         errors.New("some error"),
+		},
+		{
+			name:          "Create comment - error in result",
+			errorInResult: true,
+			err:           errors.New("some error"),
+		},
+		{
+			name:           "Create comment - error in scan row",
+			errorInScanRow: true,
+			err:            errors.New("some error"),
+		},
+	}
+
+	repository := repository.NewCommentRepository(db)
+
+	insertQuery := "insert into comments \\(post_id, author_id, content\\) values \\(\\?, \\?, \\?\\)"
+	selectQuery := "select c.id, c.post_id, c.author_id, u.nick, c.content, c.created_at from comments c join users u on c.author_id = u.id where c.id = \\?"
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+
+			if subTest.errorInPrepare {
+				mock.ExpectPrepare(insertQuery).WillReturnError(subTest.err)
+
+				_, err := repository.Create(context.Background(), comment)
+				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.errorInExec {
+				prep := mock.ExpectPrepare(insertQuery)
+				prep.ExpectExec().WithArgs(comment.PostID, comment.AuthorID, comment.Content).WillReturnError(subTest.err)
+
+				_, err := repository.Create(context.Background(), comment)
+				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.errorInResult {
+				prep := mock.ExpectPrepare(insertQuery)
+				prep.ExpectExec().WithArgs(comment.PostID, comment.AuthorID, comment.Content).WillReturnResult(sqlmock.NewErrorResult(subTest.err))
+
+				_, err := repository.Create(context.Background(), comment)
+				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.errorInScanRow {
+				prep := mock.ExpectPrepare(insertQuery)
+				prep.ExpectExec().WithArgs(comment.PostID, comment.AuthorID, comment.Content).WillReturnResult(sqlmock.NewResult(1, 1))
+
+				rows := sqlmock.NewRows([]string{"id"}).
+					AddRow(-1)
+
+				mock.ExpectQuery(selectQuery).WithArgs(comment.ID).WillReturnRows(rows)
+
+				_, err := repository.Create(context.Background(), comment)
+				assert.Error(t, err)
+			} else {
+				prep := mock.ExpectPrepare(insertQuery)
+				prep.ExpectExec().WithArgs(comment.PostID, comment.AuthorID, comment.Content).WillReturnResult(sqlmock.NewResult(1, 1))
+
+				rows := sqlmock.NewRows([]string{"id", "post_id", "author_id", "nick", "content", "created_at"}).
+					AddRow(comment.ID, comment.PostID, comment.AuthorID, comment.AuthorNick, comment.Content, comment.CreatedAt)
+
+				mock.ExpectQuery(selectQuery).WithArgs(comment.ID).WillReturnRows(rows)
+
+				createdComment, _ := repository.Create(context.Background(), comment)
+				assert.Equal(t, comment, createdComment)
+			}
+		})
+	}
+}
+
+func TestFindCommentByID(t *testing.T) {
+	commentJson, _ := ioutil.ReadFile("../test/resource/json/created_comment.json")
+
+	var comment model.Comment
+	json.Unmarshal(commentJson, &comment)
+
+	db, mock := mock.NewDatabaseConnection()
+	defer db.Close()
+
+	subTests := []struct {
+		name           string
+		notFound       bool
+		errorInExec    bool
+		errorInScanRow bool
+		err            error
+	}{
+		{
+			name: "Find by ID",
+		},
+		{
+			name:     "Find by ID - not found",
+			notFound: true,
+		},
+		{
+			name:        "Find by ID - error in exec query",
+			errorInExec: true,
+			err:         errors.New("some error"),
+		},
+		{
+			name:           "Find by ID - error in scan row",
+			errorInScanRow: true,
+			err:            errors.New("some error"),
+		},
+	}
+
+	repository := repository.NewCommentRepository(db)
+
+	query := "select c.id, c.post_id, c.author_id, u.nick, c.content, c.created_at from comments c join users u on c.author_id = u.id where c.id = \\?"
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+
+			if subTest.notFound {
+				rows := sqlmock.NewRows([]string{"id", "post_id", "author_id", "nick", "content", "created_at"})
+
+				mock.ExpectQuery(query).WithArgs(comment.ID).WillReturnRows(rows)
+
+				_, err := repository.FindByID(context.Background(), comment.ID)
+				assert.True(t, model.IsNotFound(err), "Error should be a not found error")
+			} else if subTest.errorInExec {
+				mock.ExpectQuery(query).WillReturnError(subTest.err)
+
+				_, err := repository.FindByID(context.Background(), comment.ID)
+				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.errorInScanRow {
+				rows := sqlmock.NewRows([]string{"id"}).
+					AddRow(-1)
+
+				mock.ExpectQuery(query).WithArgs(comment.ID).WillReturnRows(rows)
+
+				_, err := repository.FindByID(context.Background(), comment.ID)
+				assert.Error(t, err)
+			} else {
+				rows := sqlmock.NewRows([]string{"id", "post_id", "author_id", "nick", "content", "created_at"}).
+					AddRow(comment.ID, comment.PostID, comment.AuthorID, comment.AuthorNick, comment.Content, comment.CreatedAt)
+
+				mock.ExpectQuery(query).WithArgs(comment.ID).WillReturnRows(rows)
+
+				storedComment, _ := repository.FindByID(context.Background(), comment.ID)
+				assert.Equal(t, comment, storedComment)
+			}
+		})
+	}
+}
+
+func TestFindCommentsByPost(t *testing.T) {
+	commentJson, _ := ioutil.ReadFile("../test/resource/json/created_comment.json")
+
+	var comment model.Comment
+	json.Unmarshal(commentJson, &comment)
+
+	db, mock := mock.NewDatabaseConnection()
+	defer db.Close()
+
+	subTests := []struct {
+		name           string
+		hasNextPage    bool
+		errorInExec    bool
+		errorInScanRow bool
+		errorInRows    bool
+		err            error
+	}{
+		{
+			name: "Find by post",
+		},
+		{
+			name:        "Find by post - with next page",
+			hasNextPage: true,
+		},
+		{
+			name:        "Find by post - error in exec query",
+			errorInExec: true,
+			err:         errors.New("some error"),
+		},
+		{
+			name:           "Find by post - error in scan row",
+			errorInScanRow: true,
+			err:            errors.New("some error"),
+		},
+		{
+			name:        "Find by post - error in reading rows",
+			errorInRows: true,
+			err:         errors.New("some error"),
+		},
+	}
+
+	repository := repository.NewCommentRepository(db)
+
+	page := model.PageRequest{Limit: 1}
+
+	query := "select c.id, c.post_id, c.author_id, u.nick, c.content, c.created_at from comments c join users u on c.author_id = u.id where c.post_id = \\? and c.id > \\? order by c.id limit \\?"
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+
+			if subTest.errorInExec {
+				mock.ExpectQuery(query).WillReturnError(subTest.err)
+
+				_, err := repository.FindByPost(context.Background(), comment.PostID, page)
+				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.errorInScanRow {
+				rows := sqlmock.NewRows([]string{"id"}).
+					AddRow(-1)
+
+				mock.ExpectQuery(query).WithArgs(comment.PostID, page.Cursor, page.Limit+1).WillReturnRows(rows)
+
+				_, err := repository.FindByPost(context.Background(), comment.PostID, page)
+				assert.Error(t, err)
+			} else if subTest.errorInRows {
+				rows := sqlmock.NewRows([]string{"id", "post_id", "author_id", "nick", "content", "created_at"}).
+					AddRow(comment.ID, comment.PostID, comment.AuthorID, comment.AuthorNick, comment.Content, comment.CreatedAt).
+					AddRow(comment.ID+1, comment.PostID, comment.AuthorID, comment.AuthorNick, comment.Content, comment.CreatedAt).
+					RowError(1, subTest.err)
+
+				mock.ExpectQuery(query).WithArgs(comment.PostID, page.Cursor, page.Limit+1).WillReturnRows(rows)
+
+				_, err := repository.FindByPost(context.Background(), comment.PostID, page)
+				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.hasNextPage {
+				rows := sqlmock.NewRows([]string{"id", "post_id", "author_id", "nick", "content", "created_at"}).
+					AddRow(comment.ID, comment.PostID, comment.AuthorID, comment.AuthorNick, comment.Content, comment.CreatedAt).
+					AddRow(comment.ID+1, comment.PostID, comment.AuthorID, comment.AuthorNick, comment.Content, comment.CreatedAt)
+
+				mock.ExpectQuery(query).WithArgs(comment.PostID, page.Cursor, page.Limit+1).WillReturnRows(rows)
+
+				commentPage, _ := repository.FindByPost(context.Background(), comment.PostID, page)
+				assert.Equal(t, model.CommentPage{Data: []model.Comment{comment}, NextCursor: model.EncodeCursor(comment.ID)}, commentPage)
+			} else {
+				rows := sqlmock.NewRows([]string{"id", "post_id", "author_id", "nick", "content", "created_at"}).
+					AddRow(comment.ID, comment.PostID, comment.AuthorID, comment.AuthorNick, comment.Content, comment.CreatedAt)
+
+				mock.ExpectQuery(query).WithArgs(comment.PostID, page.Cursor, page.Limit+1).WillReturnRows(rows)
+
+				commentPage, _ := repository.FindByPost(context.Background(), comment.PostID, page)
+				assert.Equal(t, model.CommentPage{Data: []model.Comment{comment}}, commentPage)
+			}
+		})
+	}
+}
+
+func TestUpdateComment(t *testing.T) {
+	commentJson, _ := ioutil.ReadFile("../test/resource/json/created_comment.json")
+
+	var comment model.Comment
+	json.Unmarshal(commentJson, &comment)
+
+	db, mock := mock.NewDatabaseConnection()
+	defer db.Close()
+
+	subTests := []struct {
+		name           string
+		errorInPrepare bool
+		errorInExec    bool
+		err            error
+	}{
+		{
+			name: "Update comment",
+		},
+		{
+			name:           "Update comment - error in prepare",
+			errorInPrepare: true,
+			err:            errors.New("some error"),
+		},
+		{
+			name:        "Update comment - error in exec",
+			errorInExec: true,
+			err:         errors.New("some error"),
+		},
+	}
+
+	repository := repository.NewCommentRepository(db)
+
+	query := "update comments set content = \\? where id = \\?"
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+
+			if subTest.errorInPrepare {
+				mock.ExpectPrepare(query).WillReturnError(subTest.err)
+
+				err := repository.Update(context.Background(), comment.ID, comment)
+				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.errorInExec {
+				prep := mock.ExpectPrepare(query)
+				prep.ExpectExec().WithArgs(comment.Content, comment.ID).WillReturnError(subTest.err)
+
+				err := repository.Update(context.Background(), comment.ID, comment)
+				assert.ErrorIs(t, err, subTest.err)
+			} else {
+				prep := mock.ExpectPrepare(query)
+				prep.ExpectExec().WithArgs(comment.Content, comment.ID).WillReturnResult(sqlmock.NewResult(1, 1))
+
+				err := repository.Update(context.Background(), comment.ID, comment)
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDeleteComment(t *testing.T) {
+	db, mock := mock.NewDatabaseConnection()
+	defer db.Close()
+
+	subTests := []struct {
+		name           string
+		errorInPrepare bool
+		errorInExec    bool
+		err            error
+	}{
+		{
+			name: "Delete comment",
+		},
+		{
+			name:           "Delete comment - error in prepare",
+			errorInPrepare: true,
+			err:            errors.New("some error"),
+		},
+		{
+			name:        "Delete comment - error in exec",
+			errorInExec: true,
+			err:         errors.New("some error"),
+		},
+	}
+
+	repository := repository.NewCommentRepository(db)
+
+	query := "delete from comments where id = \\?"
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+
+			if subTest.errorInPrepare {
+				mock.ExpectPrepare(query).WillReturnError(subTest.err)
+
+				err := repository.Delete(context.Background(), 1)
+				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.errorInExec {
+				prep := mock.ExpectPrepare(query)
+				prep.ExpectExec().WithArgs(1).WillReturnError(subTest.err)
+
+				err := repository.Delete(context.Background(), 1)
+				assert.ErrorIs(t, err, subTest.err)
+			} else {
+				prep := mock.ExpectPrepare(query)
+				prep.ExpectExec().WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
+
+				err := repository.Delete(context.Background(), 1)
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
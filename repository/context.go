@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/waliqueiroz/devbook-api/config"
+)
+
+// withQueryTimeout bounds a repository call by config.DBQueryTimeout, on top of any deadline the caller already set
+func withQueryTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if config.DBQueryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, config.DBQueryTimeout)
+}
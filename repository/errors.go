@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"errors"
+	"regexp"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/waliqueiroz/devbook-api/model"
+)
+
+// mysqlDuplicateEntry is the number of the error MySQL returns when a unique key is violated
+const mysqlDuplicateEntry = 1062
+
+// duplicateKeyPattern reads the key from messages like "Duplicate entry 'x' for key 'users.nick'".
+// Older versions of MySQL leave the table name out
+var duplicateKeyPattern = regexp.MustCompile(`for key '(?:[^'.]+\.)?([^'.]+)'$`)
+
+// uniqueUserKeys maps the unique keys of users to the fields of model.User they guard
+var uniqueUserKeys = map[string]string{
+	"nick":  "nick",
+	"email": "email",
+}
+
+// userConflictError turns a violation of a unique key of users into a conflict naming the field.
+// Any other error is returned as it is
+func userConflictError(err error) error {
+	var mysqlError *mysql.MySQLError
+	if !errors.As(err, &mysqlError) || mysqlError.Number != mysqlDuplicateEntry {
+		return err
+	}
+
+	match := duplicateKeyPattern.FindStringSubmatch(mysqlError.Message)
+	if match == nil {
+		return err
+	}
+
+	field, ok := uniqueUserKeys[match[1]]
+	if !ok {
+		return err
+	}
+
+	return model.NewConflictError(field, err)
+}
@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/waliqueiroz/devbook-api/model"
+)
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// userColumns are the columns of users read into a model.User, in the order scanUser expects them.
+// The password is left out on purpose, it is only read by FindByEmail and FindPassword. So is the verification time, which only FindByEmail reads
+var userColumns = []string{"id", "name", "nick", "email", "created_at", "version", "updated_at"}
+
+// postColumns are the columns of posts read into a model.Post, in the order scanPost expects them
+var postColumns = []string{"id", "title", "content", "author_id", "likes", "created_at", "parent_post_id", "repost_of_id", "version", "updated_at"}
+
+// plainRepostCondition matches the posts p that re-share another one without adding anything to it
+const plainRepostCondition = "p.repost_of_id is not null and p.title = '' and p.content = ''"
+
+// postDetailsSelect are the values computed for every post read, right after its columns. Its placeholder takes the viewer ID
+const postDetailsSelect = `u.nick,
+	exists(select 1 from post_likes pl where pl.post_id = p.id and pl.user_id = ?),
+	(select count(*) from comments c where c.post_id = p.id),
+	(select count(*) from posts r where r.parent_post_id = p.id),
+	(select count(*) from posts r where r.repost_of_id = p.id)`
+
+// repostedPostSelect are the columns of the post re-shared by a repost, in the order scanPostWithRepost expects them
+const repostedPostSelect = `o.title,
+	o.content,
+	o.author_id,
+	ou.nick,
+	o.created_at`
+
+// postSelect selects a post and its details, scanned by scanPost
+var postSelect = selectColumns("p", postColumns) + ",\n" + postDetailsSelect
+
+// postWithRepostSelect selects a post, its details and the post it re-shares, scanned by scanPostWithRepost
+var postWithRepostSelect = postSelect + ",\n" + repostedPostSelect
+
+// postWithRepostFrom joins the posts with their author and the post they re-share
+const postWithRepostFrom = `posts p
+	join users u on
+		p.author_id = u.id
+	left join posts o on
+		p.repost_of_id = o.id
+	left join users ou on
+		o.author_id = ou.id`
+
+// selectColumns qualifies a list of columns with a table alias, like "u.id, u.name"
+func selectColumns(alias string, columns []string) string {
+	qualified := make([]string, len(columns))
+	for i, column := range columns {
+		qualified[i] = alias + "." + column
+	}
+
+	return strings.Join(qualified, ", ")
+}
+
+// scanUser reads a row selected with userColumns
+func scanUser(scanner rowScanner) (model.User, error) {
+	var user model.User
+
+	err := scanner.Scan(userDestinations(&user)...)
+
+	return user, err
+}
+
+// userDestinations returns where each of userColumns is scanned into
+func userDestinations(user *model.User) []interface{} {
+	return []interface{}{&user.ID, &user.Name, &user.Nick, &user.Email, &user.CreatedAt, &user.Version, &user.UpdatedAt}
+}
+
+// scanUsers reads all the rows selected with userColumns
+func scanUsers(rows *sql.Rows) ([]model.User, error) {
+	var users []model.User
+
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+// scanPost reads a row selected with postSelect, followed by any extra values
+func scanPost(scanner rowScanner, extra ...interface{}) (model.Post, error) {
+	var post model.Post
+
+	dest := append(postDestinations(&post), extra...)
+
+	err := scanner.Scan(dest...)
+
+	return post, err
+}
+
+// scanPostWithRepost reads a row selected with postWithRepostSelect
+func scanPostWithRepost(scanner rowScanner) (model.Post, error) {
+	var original repostedPost
+
+	post, err := scanPost(scanner, original.destinations()...)
+	if err != nil {
+		return model.Post{}, err
+	}
+
+	post.RepostOf = original.toPost(post.RepostOfID)
+
+	return post, nil
+}
+
+// scanPostsWithRepost reads all the rows selected with postWithRepostSelect
+func scanPostsWithRepost(rows *sql.Rows) ([]model.Post, error) {
+	var posts []model.Post
+
+	for rows.Next() {
+		post, err := scanPostWithRepost(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		posts = append(posts, post)
+	}
+
+	return posts, rows.Err()
+}
+
+// postDestinations returns where each value of postSelect is scanned into
+func postDestinations(post *model.Post) []interface{} {
+	return []interface{}{
+		&post.ID, &post.Title, &post.Content, &post.AuthorID, &post.Likes, &post.CreatedAt, &post.ParentPostID, &post.RepostOfID, &post.Version, &post.UpdatedAt,
+		&post.AuthorNick, &post.LikedByMe, &post.CommentCount, &post.ReplyCount, &post.RepostCount,
+	}
+}
+
+// repostedPost holds the columns of the post re-shared by a repost, which are null for any other post
+type repostedPost struct {
+	title      *string
+	content    *string
+	authorID   *uint64
+	authorNick *string
+	createdAt  *time.Time
+}
+
+// destinations returns where each value of repostedPostSelect is scanned into
+func (original *repostedPost) destinations() []interface{} {
+	return []interface{}{&original.title, &original.content, &original.authorID, &original.authorNick, &original.createdAt}
+}
+
+// toPost returns the re-shared post, or nil if there is none. A quote outlives the post it re-shares,
+// which is then returned as a stub flagged as unavailable
+func (original repostedPost) toPost(id *uint64) *model.Post {
+	if id == nil {
+		return nil
+	}
+
+	if original.authorID == nil {
+		return &model.Post{ID: *id, Unavailable: true}
+	}
+
+	post := model.Post{ID: *id, AuthorID: *original.authorID}
+
+	if original.title != nil {
+		post.Title = *original.title
+	}
+
+	if original.content != nil {
+		post.Content = *original.content
+	}
+
+	if original.authorNick != nil {
+		post.AuthorNick = *original.authorNick
+	}
+
+	if original.createdAt != nil {
+		post.CreatedAt = *original.createdAt
+	}
+
+	return &post
+}
@@ -0,0 +1,207 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/waliqueiroz/devbook-api/database"
+	"github.com/waliqueiroz/devbook-api/repository"
+)
+
+var createTablePattern = regexp.MustCompile(`(?is)create table (?:if not exists )?(\w+) \((.*?)\)\s*engine`)
+var alterTablePattern = regexp.MustCompile(`(?is)alter table (\w+)\s+(.*?);`)
+var addColumnPattern = regexp.MustCompile(`(?i)add column (\w+)`)
+var dropColumnPattern = regexp.MustCompile(`(?i)drop column (\w+)`)
+
+// schemaColumns replays the up migrations and returns the columns of each table, in the order they were created
+func schemaColumns(t *testing.T) map[string][]string {
+	migrations, err := database.LoadMigrations(os.DirFS("../database/migrations"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tables := make(map[string][]string)
+
+	for _, migration := range migrations {
+		for _, match := range createTablePattern.FindAllStringSubmatch(migration.Up, -1) {
+			var columns []string
+
+			for _, line := range strings.Split(match[2], "\n") {
+				fields := strings.Fields(strings.TrimSpace(line))
+				if len(fields) < 2 {
+					continue
+				}
+
+				switch strings.ToLower(fields[0]) {
+				case "primary", "foreign", "unique", "key", "index", "fulltext", "constraint":
+					continue
+				}
+
+				columns = append(columns, fields[0])
+			}
+
+			tables[strings.ToLower(match[1])] = columns
+		}
+
+		for _, match := range alterTablePattern.FindAllStringSubmatch(migration.Up, -1) {
+			table := strings.ToLower(match[1])
+
+			for _, column := range addColumnPattern.FindAllStringSubmatch(match[2], -1) {
+				tables[table] = append(tables[table], column[1])
+			}
+
+			for _, column := range dropColumnPattern.FindAllStringSubmatch(match[2], -1) {
+				tables[table] = without(tables[table], column[1])
+			}
+		}
+	}
+
+	return tables
+}
+
+func without(columns []string, excluded ...string) []string {
+	var remaining []string
+	for _, c := range columns {
+		if !slices.Contains(excluded, c) {
+			remaining = append(remaining, c)
+		}
+	}
+
+	return remaining
+}
+
+// recordingDatabase returns a mocked database that records the next query it receives and fails it
+func recordingDatabase(t *testing.T, query *string) *sql.DB {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherFunc(func(expectedSQL, actualSQL string) error {
+		*query = actualSQL
+		return nil
+	})))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectQuery("").WillReturnError(errors.New("query recorded"))
+
+	return db
+}
+
+// selectedValues returns the values of the select list of a query, ignoring the commas inside parentheses
+func selectedValues(query string) []string {
+	query = strings.Join(strings.Fields(query), " ")
+	query = strings.TrimPrefix(strings.TrimPrefix(query, "select "), "distinct ")
+
+	var values []string
+	depth, start := 0, 0
+
+	for i, char := range query {
+		switch char {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				values = append(values, strings.TrimSpace(query[start:i]))
+				start = i + 1
+			}
+		}
+
+		if depth == 0 && strings.HasPrefix(query[i:], " from ") {
+			return append(values, strings.TrimSpace(query[start:i]))
+		}
+	}
+
+	return values
+}
+
+// columnsOf returns the columns of a table alias found among some selected values, like name for u.name
+func columnsOf(alias string, values []string) []string {
+	var columns []string
+	for _, value := range values {
+		if column, ok := strings.CutPrefix(value, alias+"."); ok {
+			columns = append(columns, column)
+		}
+	}
+
+	return columns
+}
+
+// rowOf returns a row with a plausible value for each selected value, so it can be scanned into a model
+func rowOf(values []string) *sqlmock.Rows {
+	row := make([]driver.Value, len(values))
+
+	for i, value := range values {
+		column := value[strings.LastIndex(value, ".")+1:]
+
+		switch {
+		case strings.HasPrefix(value, "o.") || strings.HasPrefix(value, "ou."):
+			row[i] = nil
+		case strings.HasPrefix(value, "exists("):
+			row[i] = false
+		case strings.HasPrefix(value, "(select count"):
+			row[i] = 0
+		case column == "created_at" || column == "updated_at":
+			row[i] = time.Date(2021, time.April, 6, 13, 34, 50, 0, time.UTC)
+		case column == "parent_post_id" || column == "repost_of_id":
+			row[i] = nil
+		case column == "id" || strings.HasSuffix(column, "_id") || column == "likes" || column == "version":
+			row[i] = 1
+		default:
+			row[i] = "text"
+		}
+	}
+
+	return sqlmock.NewRows(values).AddRow(row...)
+}
+
+func TestUserColumnsMatchSchema(t *testing.T) {
+	users := schemaColumns(t)["users"]
+	assert.NotEmpty(t, users, "The migrations should create the users table")
+
+	var query string
+	db := recordingDatabase(t, &query)
+	defer db.Close()
+
+	repository.NewUserRepository(db).FindByID(context.Background(), 1)
+	values := selectedValues(query)
+
+	assert.ElementsMatch(t, without(users, "password", "verified_at"), columnsOf("u", values), "A column was added to or removed from users, update userColumns and userDestinations")
+
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	defer db.Close()
+	mock.ExpectQuery(query).WillReturnRows(rowOf(values))
+
+	_, err := repository.NewUserRepository(db).FindByID(context.Background(), 1)
+	assert.NoError(t, err, "userDestinations does not scan every column of userColumns")
+}
+
+func TestPostColumnsMatchSchema(t *testing.T) {
+	posts := schemaColumns(t)["posts"]
+	assert.NotEmpty(t, posts, "The migrations should create the posts table")
+
+	var query string
+	db := recordingDatabase(t, &query)
+	defer db.Close()
+
+	repository.NewPostRepository(db).FindByID(context.Background(), 1, 1)
+	values := selectedValues(query)
+
+	assert.ElementsMatch(t, posts, columnsOf("p", values), "A column was added to or removed from posts, update postColumns and postDestinations")
+
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	defer db.Close()
+	mock.ExpectQuery(query).WillReturnRows(rowOf(values))
+
+	_, err := repository.NewPostRepository(db).FindByID(context.Background(), 1, 1)
+	assert.NoError(t, err, "postDestinations or repostedPost does not scan every value of postWithRepostSelect")
+}
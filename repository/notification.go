@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/waliqueiroz/devbook-api/model"
+)
+
+type NotificationRepository struct {
+	db *sql.DB
+}
+
+// NewNotificationRepository creates a new notification repository
+func NewNotificationRepository(db *sql.DB) *NotificationRepository {
+	return &NotificationRepository{db}
+}
+
+// notificationSelect selects a notification and the nick of the user who caused it, scanned by scanNotification
+const notificationSelect = `n.id,
+	n.actor_id,
+	u.nick,
+	n.type,
+	n.post_id,
+	n.comment_id,
+	n.read_at is not null,
+	n.created_at`
+
+// Create inserts a notification into database, unless the user still has an unread notification about the
+// same action. The unread key enforces it, so following, liking or mentioning again after undoing it does not
+// notify twice until the first notification is read. It returns the stored notification and tells if it was inserted
+func (repository NotificationRepository) Create(ctx context.Context, notification model.Notification) (model.Notification, bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, err := repository.db.PrepareContext(ctx, `insert into notifications (user_id, actor_id, type, post_id, comment_id, unread_key) 
+											values (?, ?, ?, ?, ?, ?) 
+											on duplicate key update id = id`)
+	if err != nil {
+		return model.Notification{}, false, err
+	}
+	defer statement.Close()
+
+	result, err := statement.ExecContext(ctx, notification.UserID, notification.ActorID, notification.Type,
+		notification.PostID, notification.CommentID, unreadKey(notification))
+	if err != nil {
+		return model.Notification{}, false, err
+	}
+
+	affectedRows, err := result.RowsAffected()
+	if err != nil {
+		return model.Notification{}, false, err
+	}
+
+	if affectedRows == 0 {
+		return model.Notification{}, false, nil
+	}
+
+	lastInsertID, err := result.LastInsertId()
+	if err != nil {
+		return model.Notification{}, false, err
+	}
+
+	storedNotification, err := repository.findByID(ctx, uint64(lastInsertID), notification.UserID)
+	if err != nil {
+		return model.Notification{}, false, err
+	}
+
+	return storedNotification, true, nil
+}
+
+// findByID returns a notification of a given user
+func (repository NotificationRepository) findByID(ctx context.Context, notificationID, userID uint64) (model.Notification, error) {
+	row := repository.db.QueryRowContext(ctx, `select
+										`+notificationSelect+`
+									from
+										notifications n
+									join users u on
+										n.actor_id = u.id
+									where
+										n.id = ?`, notificationID)
+
+	return scanNotification(row, userID)
+}
+
+// scanNotification reads a row selected with notificationSelect. The user is not selected, as it is always known
+func scanNotification(scanner rowScanner, userID uint64) (model.Notification, error) {
+	notification := model.Notification{UserID: userID}
+
+	err := scanner.Scan(&notification.ID, &notification.ActorID, &notification.ActorNick, &notification.Type,
+		&notification.PostID, &notification.CommentID, &notification.Read, &notification.CreatedAt)
+
+	return notification, err
+}
+
+// unreadKey identifies the action a notification is about, like 1:2:like:3:0. It is unique among the unread notifications
+func unreadKey(notification model.Notification) string {
+	var postID, commentID uint64
+
+	if notification.PostID != nil {
+		postID = *notification.PostID
+	}
+
+	if notification.CommentID != nil {
+		commentID = *notification.CommentID
+	}
+
+	return fmt.Sprintf("%d:%d:%s:%d:%d", notification.UserID, notification.ActorID, notification.Type, postID, commentID)
+}
+
+// CreateMentions notifies the users with the given nicks that they were mentioned in a post, returning the stored
+// notifications. Unknown nicks, the author of the post and users with an unread notification of it are skipped
+func (repository NotificationRepository) CreateMentions(ctx context.Context, actorID, postID uint64, nicks []string) ([]model.Notification, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	if len(nicks) == 0 {
+		return nil, nil
+	}
+
+	args := []interface{}{actorID}
+	for _, nick := range nicks {
+		args = append(args, nick)
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(nicks)), ", ")
+
+	rows, err := repository.db.QueryContext(ctx, "select id from users where id <> ? and nick in ("+placeholders+")", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var mentionedIDs []uint64
+
+	for rows.Next() {
+		var id uint64
+
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		mentionedIDs = append(mentionedIDs, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var notifications []model.Notification
+
+	for _, userID := range mentionedIDs {
+		notification, created, err := repository.Create(ctx, model.Notification{UserID: userID, ActorID: actorID, Type: model.NotificationMention, PostID: &postID})
+		if err != nil {
+			return nil, err
+		}
+
+		if created {
+			notifications = append(notifications, notification)
+		}
+	}
+
+	return notifications, nil
+}
+
+// FindByUser returns a page of notifications of a given user, from the newest to the oldest
+func (repository NotificationRepository) FindByUser(ctx context.Context, userID uint64, unreadOnly bool, page model.PageRequest) (model.NotificationPage, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := repository.db.QueryContext(ctx, `select
+										`+notificationSelect+`
+									from
+										notifications n
+									join users u on
+										n.actor_id = u.id
+									where
+										n.user_id = ? and (? = false or n.read_at is null) and n.id < ?
+									order by n.id desc
+									limit ?`, userID, unreadOnly, descendingCursor(page), page.Limit+1)
+	if err != nil {
+		return model.NotificationPage{}, err
+	}
+
+	defer rows.Close()
+
+	var notifications []model.Notification
+
+	for rows.Next() {
+		notification, err := scanNotification(rows, userID)
+		if err != nil {
+			return model.NotificationPage{}, err
+		}
+
+		notifications = append(notifications, notification)
+	}
+
+	if err = rows.Err(); err != nil {
+		return model.NotificationPage{}, err
+	}
+
+	return newNotificationPage(notifications, page), nil
+}
+
+// MarkAsRead marks the given notifications of a user as read, releasing their unread keys. When no ID is given, all of them are marked
+func (repository NotificationRepository) MarkAsRead(ctx context.Context, userID uint64, notificationIDs []uint64) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := "update notifications set read_at = current_timestamp(), unread_key = null where user_id = ? and read_at is null"
+	args := []interface{}{userID}
+
+	if len(notificationIDs) > 0 {
+		query += " and id in (" + strings.TrimSuffix(strings.Repeat("?, ", len(notificationIDs)), ", ") + ")"
+
+		for _, notificationID := range notificationIDs {
+			args = append(args, notificationID)
+		}
+	}
+
+	statement, err := repository.db.PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	_, err = statement.ExecContext(ctx, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CountUnread returns how many notifications a given user has not read yet
+func (repository NotificationRepository) CountUnread(ctx context.Context, userID uint64) (uint64, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	var unread uint64
+
+	err := repository.db.QueryRowContext(ctx, "select count(*) from notifications where user_id = ? and read_at is null", userID).Scan(&unread)
+	if err != nil {
+		return 0, err
+	}
+
+	return unread, nil
+}
@@ -0,0 +1,412 @@
+package repository_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/waliqueiroz/devbook-api/model"
+	"github.com/waliqueiroz/devbook-api/repository"
+	"github.com/waliqueiroz/devbook-api/test/mock"
+)
+
+func TestCreateNotification(t *testing.T) {
+	db, mock := mock.NewDatabaseConnection()
+	defer db.Close()
+
+	postID := uint64(1)
+	notification := model.Notification{UserID: 1, ActorID: 2, Type: model.NotificationLike, PostID: &postID}
+
+	subTests := []struct {
+		name            string
+		alreadyNotified bool
+		errorInPrepare  bool
+		errorInExec     bool
+		errorInResult   bool
+		err             error
+	}{
+		{
+			name: "Create notification",
+		},
+		{
+			name:            "Create notification - already notified",
+			alreadyNotified: true,
+		},
+		{
+			name:           "Create notification - error in prepare",
+			errorInPrepare: true,
+			err:            errors.New("some error"),
+		},
+		{
+			name:        "Create notification - error in exec",
+			errorInExec: true,
+			err:         errors.New("some error"),
+		},
+		{
+			name:          "Create notification - error in result",
+			errorInResult: true,
+			err:           errors.New("some error"),
+		},
+	}
+
+	repository := repository.NewNotificationRepository(db)
+
+	query := "insert into notifications \\(user_id, actor_id, type, post_id, comment_id, unread_key\\) values \\(\\?, \\?, \\?, \\?, \\?, \\?\\) on duplicate key update id = id"
+
+	selectQuery := "select n.id, n.actor_id, u.nick, n.type, n.post_id, n.comment_id, n.read_at is not null, n.created_at from notifications n join users u on n.actor_id = u.id where n.id = \\?"
+
+	createdAt := time.Date(2021, time.April, 6, 13, 34, 50, 0, time.UTC)
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+
+			if subTest.errorInPrepare {
+				mock.ExpectPrepare(query).WillReturnError(subTest.err)
+
+				_, _, err := repository.Create(context.Background(), notification)
+				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.errorInExec {
+				prep := mock.ExpectPrepare(query)
+				prep.ExpectExec().WillReturnError(subTest.err)
+
+				_, _, err := repository.Create(context.Background(), notification)
+				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.errorInResult {
+				prep := mock.ExpectPrepare(query)
+				prep.ExpectExec().WillReturnResult(sqlmock.NewErrorResult(subTest.err))
+
+				_, _, err := repository.Create(context.Background(), notification)
+				assert.ErrorIs(t, err, subTest.err)
+			} else {
+				affectedRows := int64(1)
+				if subTest.alreadyNotified {
+					affectedRows = 0
+				}
+
+				prep := mock.ExpectPrepare(query)
+				prep.ExpectExec().
+					WithArgs(notification.UserID, notification.ActorID, notification.Type, postID, nil, "1:2:like:1:0").
+					WillReturnResult(sqlmock.NewResult(5, affectedRows))
+
+				expected := model.Notification{}
+
+				if !subTest.alreadyNotified {
+					expected = notification
+					expected.ID = 5
+					expected.ActorNick = "user2"
+					expected.CreatedAt = createdAt
+
+					mock.ExpectQuery(selectQuery).WithArgs(5).WillReturnRows(
+						sqlmock.NewRows([]string{"id", "actor_id", "nick", "type", "post_id", "comment_id", "read", "created_at"}).
+							AddRow(5, notification.ActorID, "user2", notification.Type, postID, nil, false, createdAt))
+				}
+
+				storedNotification, created, err := repository.Create(context.Background(), notification)
+				assert.NoError(t, err)
+				assert.Equal(t, !subTest.alreadyNotified, created)
+				assert.Equal(t, expected, storedNotification)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			}
+		})
+	}
+}
+
+func TestCreateMentionNotifications(t *testing.T) {
+	db, mock := mock.NewDatabaseConnection()
+	defer db.Close()
+
+	subTests := []struct {
+		name        string
+		nicks       []string
+		errorInExec bool
+		errorInRows bool
+		err         error
+	}{
+		{
+			name:  "Create mention notifications",
+			nicks: []string{"user2", "user3", "ninguem"},
+		},
+		{
+			name: "Create mention notifications - no mentions",
+		},
+		{
+			name:        "Create mention notifications - error in exec query",
+			nicks:       []string{"user2"},
+			errorInExec: true,
+			err:         errors.New("some error"),
+		},
+		{
+			name:        "Create mention notifications - error in reading rows",
+			nicks:       []string{"user2", "user3", "ninguem"},
+			errorInRows: true,
+			err:         errors.New("some error"),
+		},
+	}
+
+	repository := repository.NewNotificationRepository(db)
+
+	selectQuery := "select id from users where id <> \\? and nick in \\(\\?, \\?, \\?\\)"
+	postID := uint64(1)
+	createdAt := time.Date(2021, time.April, 6, 13, 34, 50, 0, time.UTC)
+
+	insertQuery := "insert into notifications \\(user_id, actor_id, type, post_id, comment_id, unread_key\\) values"
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+
+			if len(subTest.nicks) == 0 {
+				notifications, err := repository.CreateMentions(context.Background(), 1, 1, subTest.nicks)
+				assert.NoError(t, err)
+				assert.Empty(t, notifications)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			} else if subTest.errorInExec {
+				mock.ExpectQuery("select id from users").WillReturnError(subTest.err)
+
+				_, err := repository.CreateMentions(context.Background(), 1, 1, subTest.nicks)
+				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.errorInRows {
+				mock.ExpectQuery(selectQuery).
+					WithArgs(1, "user2", "user3", "ninguem").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2).AddRow(3).RowError(1, subTest.err))
+
+				_, err := repository.CreateMentions(context.Background(), 1, 1, subTest.nicks)
+				assert.ErrorIs(t, err, subTest.err)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			} else {
+				mock.ExpectQuery(selectQuery).
+					WithArgs(1, "user2", "user3", "ninguem").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2).AddRow(3))
+
+				mock.ExpectPrepare(insertQuery).ExpectExec().
+					WithArgs(2, 1, model.NotificationMention, 1, nil, "2:1:mention:1:0").
+					WillReturnResult(sqlmock.NewResult(7, 1))
+
+				mock.ExpectQuery("select n.id").WithArgs(7).WillReturnRows(
+					sqlmock.NewRows([]string{"id", "actor_id", "nick", "type", "post_id", "comment_id", "read", "created_at"}).
+						AddRow(7, 1, "user1", model.NotificationMention, 1, nil, false, createdAt))
+
+				mock.ExpectPrepare(insertQuery).ExpectExec().
+					WithArgs(3, 1, model.NotificationMention, 1, nil, "3:1:mention:1:0").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+
+				notifications, err := repository.CreateMentions(context.Background(), 1, 1, subTest.nicks)
+				assert.NoError(t, err)
+				assert.Len(t, notifications, 1)
+				assert.Equal(t, model.Notification{ID: 7, UserID: 2, ActorID: 1, ActorNick: "user1", Type: model.NotificationMention, PostID: &postID, CreatedAt: createdAt}, notifications[0])
+			}
+		})
+	}
+}
+
+func TestFindNotificationsByUser(t *testing.T) {
+	notificationListJson, _ := ioutil.ReadFile("../test/resource/json/stored_notification_list.json")
+
+	var notifications []model.Notification
+	json.Unmarshal(notificationListJson, &notifications)
+
+	for i := range notifications {
+		notifications[i].UserID = 1
+	}
+
+	db, mock := mock.NewDatabaseConnection()
+	defer db.Close()
+
+	subTests := []struct {
+		name           string
+		hasNextPage    bool
+		errorInExec    bool
+		errorInScanRow bool
+		errorInRows    bool
+		err            error
+	}{
+		{
+			name: "Find by user",
+		},
+		{
+			name:        "Find by user - with next page",
+			hasNextPage: true,
+		},
+		{
+			name:        "Find by user - error in exec query",
+			errorInExec: true,
+			err:         errors.New("some error"),
+		},
+		{
+			name:           "Find by user - error in scan row",
+			errorInScanRow: true,
+			err:            errors.New("some error"),
+		},
+		{
+			name:        "Find by user - error in reading rows",
+			errorInRows: true,
+			err:         errors.New("some error"),
+		},
+	}
+
+	repository := repository.NewNotificationRepository(db)
+
+	query := "select n.id, n.actor_id, u.nick, n.type, n.post_id, n.comment_id, n.read_at is not null, n.created_at from notifications n join users u on n.actor_id = u.id where n.user_id = \\? and \\(\\? = false or n.read_at is null\\) and n.id < \\? order by n.id desc limit \\?"
+
+	columns := []string{"id", "actor_id", "nick", "type", "post_id", "comment_id", "read", "created_at"}
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+
+			if subTest.errorInExec {
+				mock.ExpectQuery(query).WillReturnError(subTest.err)
+
+				_, err := repository.FindByUser(context.Background(), 1, false, model.PageRequest{Limit: 2})
+				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.errorInScanRow {
+				rows := sqlmock.NewRows([]string{"id"}).
+					AddRow(-1)
+
+				mock.ExpectQuery(query).WithArgs(1, true, math.MaxInt64, 3).WillReturnRows(rows)
+
+				_, err := repository.FindByUser(context.Background(), 1, true, model.PageRequest{Limit: 2})
+				assert.Error(t, err)
+			} else if subTest.errorInRows {
+				rows := sqlmock.NewRows(columns)
+				for _, notification := range notifications {
+					rows.AddRow(notification.ID, notification.ActorID, notification.ActorNick, notification.Type, notification.PostID, notification.CommentID, notification.Read, notification.CreatedAt)
+				}
+				rows.RowError(1, subTest.err)
+
+				mock.ExpectQuery(query).WithArgs(1, false, math.MaxInt64, 3).WillReturnRows(rows)
+
+				_, err := repository.FindByUser(context.Background(), 1, false, model.PageRequest{Limit: 2})
+				assert.ErrorIs(t, err, subTest.err)
+			} else {
+				rows := sqlmock.NewRows(columns)
+				for _, notification := range notifications {
+					rows.AddRow(notification.ID, notification.ActorID, notification.ActorNick, notification.Type, notification.PostID, notification.CommentID, notification.Read, notification.CreatedAt)
+				}
+
+				page := model.PageRequest{Limit: 2}
+				expectedPage := model.NotificationPage{Data: notifications}
+
+				if subTest.hasNextPage {
+					page.Limit = 1
+					expectedPage = model.NotificationPage{Data: notifications[:1], NextCursor: model.EncodeCursor(notifications[0].ID)}
+				}
+
+				mock.ExpectQuery(query).WithArgs(1, false, math.MaxInt64, page.Limit+1).WillReturnRows(rows)
+
+				notificationPage, _ := repository.FindByUser(context.Background(), 1, false, page)
+				assert.Equal(t, expectedPage, notificationPage)
+			}
+		})
+	}
+}
+
+func TestMarkNotificationsAsRead(t *testing.T) {
+	db, mock := mock.NewDatabaseConnection()
+	defer db.Close()
+
+	subTests := []struct {
+		name           string
+		ids            []uint64
+		errorInPrepare bool
+		errorInExec    bool
+		err            error
+	}{
+		{
+			name: "Mark all as read",
+		},
+		{
+			name: "Mark some as read",
+			ids:  []uint64{1, 2},
+		},
+		{
+			name:           "Mark as read - error in prepare",
+			errorInPrepare: true,
+			err:            errors.New("some error"),
+		},
+		{
+			name:        "Mark as read - error in exec",
+			errorInExec: true,
+			err:         errors.New("some error"),
+		},
+	}
+
+	repository := repository.NewNotificationRepository(db)
+
+	query := "update notifications set read_at = current_timestamp\\(\\), unread_key = null where user_id = \\? and read_at is null"
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+
+			if subTest.errorInPrepare {
+				mock.ExpectPrepare(query).WillReturnError(subTest.err)
+
+				err := repository.MarkAsRead(context.Background(), 1, subTest.ids)
+				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.errorInExec {
+				prep := mock.ExpectPrepare(query)
+				prep.ExpectExec().WillReturnError(subTest.err)
+
+				err := repository.MarkAsRead(context.Background(), 1, subTest.ids)
+				assert.ErrorIs(t, err, subTest.err)
+			} else if len(subTest.ids) > 0 {
+				prep := mock.ExpectPrepare(query + " and id in \\(\\?, \\?\\)")
+				prep.ExpectExec().WithArgs(1, 1, 2).WillReturnResult(sqlmock.NewResult(0, 2))
+
+				err := repository.MarkAsRead(context.Background(), 1, subTest.ids)
+				assert.NoError(t, err)
+			} else {
+				prep := mock.ExpectPrepare(query + "$")
+				prep.ExpectExec().WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 2))
+
+				err := repository.MarkAsRead(context.Background(), 1, subTest.ids)
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCountUnreadNotifications(t *testing.T) {
+	db, mock := mock.NewDatabaseConnection()
+	defer db.Close()
+
+	subTests := []struct {
+		name        string
+		errorInExec bool
+		err         error
+	}{
+		{
+			name: "Count unread",
+		},
+		{
+			name:        "Count unread - error in exec query",
+			errorInExec: true,
+			err:         errors.New("some error"),
+		},
+	}
+
+	repository := repository.NewNotificationRepository(db)
+
+	query := "select count\\(\\*\\) from notifications where user_id = \\? and read_at is null"
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+
+			if subTest.errorInExec {
+				mock.ExpectQuery(query).WillReturnError(subTest.err)
+
+				_, err := repository.CountUnread(context.Background(), 1)
+				assert.ErrorIs(t, err, subTest.err)
+			} else {
+				mock.ExpectQuery(query).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+				unread, _ := repository.CountUnread(context.Background(), 1)
+				assert.Equal(t, uint64(3), unread)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"math"
+
+	"github.com/waliqueiroz/devbook-api/model"
+)
+
+// newPostPage builds a page of posts. Lists are fetched with one extra row, so we know if
+// there is a next page without running a count query
+func newPostPage(posts []model.Post, page model.PageRequest) model.PostPage {
+	if uint64(len(posts)) <= page.Limit {
+		return model.PostPage{Data: posts}
+	}
+
+	posts = posts[:page.Limit]
+
+	return model.PostPage{
+		Data:       posts,
+		NextCursor: model.EncodeCursor(posts[len(posts)-1].ID),
+	}
+}
+
+// newUserPage builds a page of users the same way as newPostPage
+func newUserPage(users []model.User, page model.PageRequest) model.UserPage {
+	if uint64(len(users)) <= page.Limit {
+		return model.UserPage{Data: users}
+	}
+
+	users = users[:page.Limit]
+
+	return model.UserPage{
+		Data:       users,
+		NextCursor: model.EncodeCursor(users[len(users)-1].ID),
+	}
+}
+
+// newCommentPage builds a page of comments the same way as newPostPage
+func newCommentPage(comments []model.Comment, page model.PageRequest) model.CommentPage {
+	if uint64(len(comments)) <= page.Limit {
+		return model.CommentPage{Data: comments}
+	}
+
+	comments = comments[:page.Limit]
+
+	return model.CommentPage{
+		Data:       comments,
+		NextCursor: model.EncodeCursor(comments[len(comments)-1].ID),
+	}
+}
+
+// newNotificationPage builds a page of notifications the same way as newPostPage
+func newNotificationPage(notifications []model.Notification, page model.PageRequest) model.NotificationPage {
+	if uint64(len(notifications)) <= page.Limit {
+		return model.NotificationPage{Data: notifications}
+	}
+
+	notifications = notifications[:page.Limit]
+
+	return model.NotificationPage{
+		Data:       notifications,
+		NextCursor: model.EncodeCursor(notifications[len(notifications)-1].ID),
+	}
+}
+
+// newPostSearchPage builds a page of search results. As they are ranked by relevance instead of
+// by ID, the cursor holds both the score and the ID of the last result
+func newPostSearchPage(results []model.PostSearchResult, page model.SearchPageRequest) model.PostSearchPage {
+	if uint64(len(results)) <= page.Limit {
+		return model.PostSearchPage{Data: results}
+	}
+
+	results = results[:page.Limit]
+	last := results[len(results)-1]
+
+	return model.PostSearchPage{
+		Data:       results,
+		NextCursor: model.EncodeSearchCursor(last.Score, last.ID),
+	}
+}
+
+// descendingCursor returns the upper bound for lists ordered from the newest to the oldest ID
+func descendingCursor(page model.PageRequest) uint64 {
+	if page.Cursor == 0 {
+		return math.MaxInt64
+	}
+
+	return page.Cursor
+}
@@ -1,8 +1,12 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"strings"
+	"time"
 
+	"github.com/waliqueiroz/devbook-api/config"
 	"github.com/waliqueiroz/devbook-api/model"
 )
 
@@ -15,15 +19,24 @@ func NewPostRepository(db *sql.DB) *PostRepository {
 	return &PostRepository{db}
 }
 
-// Create inserts a post into database
-func (repository PostRepository) Create(post model.Post) (model.Post, error) {
-	statement, err := repository.db.Prepare("insert into posts (title, content, author_id) values (?, ?, ?)")
+// Create inserts a post into database, along with its tags and mentions
+func (repository PostRepository) Create(ctx context.Context, post model.Post) (model.Post, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := repository.db.BeginTx(ctx, nil)
+	if err != nil {
+		return model.Post{}, err
+	}
+	defer tx.Rollback()
+
+	statement, err := tx.PrepareContext(ctx, "insert into posts (title, content, author_id, parent_post_id, repost_of_id) values (?, ?, ?, ?, ?)")
 	if err != nil {
 		return model.Post{}, err
 	}
 	defer statement.Close()
 
-	result, err := statement.Exec(post.Title, post.Content, post.AuthorID)
+	result, err := statement.ExecContext(ctx, post.Title, post.Content, post.AuthorID, post.ParentPostID, post.RepostOfID)
 	if err != nil {
 		return model.Post{}, err
 	}
@@ -33,7 +46,15 @@ func (repository PostRepository) Create(post model.Post) (model.Post, error) {
 		return model.Post{}, err
 	}
 
-	newPost, err := repository.FindByID(uint64(lastInsertID))
+	if err = saveReferences(ctx, tx, uint64(lastInsertID), post); err != nil {
+		return model.Post{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return model.Post{}, err
+	}
+
+	newPost, err := repository.FindByID(ctx, uint64(lastInsertID), post.AuthorID)
 	if err != nil {
 		return model.Post{}, err
 	}
@@ -41,171 +62,500 @@ func (repository PostRepository) Create(post model.Post) (model.Post, error) {
 	return newPost, nil
 }
 
-// FindByID returns a post that match with a given ID
-func (repository PostRepository) FindByID(postID uint64) (model.Post, error) {
+// saveReferences stores the tags and the mentions of a post. Mentions of nicks that no user has are ignored
+func saveReferences(ctx context.Context, tx *sql.Tx, postID uint64, post model.Post) error {
+	for _, tag := range post.Tags {
+		if _, err := tx.ExecContext(ctx, "insert ignore into post_tags (post_id, tag) values (?, ?)", postID, tag); err != nil {
+			return err
+		}
+	}
+
+	if len(post.Mentions) == 0 {
+		return nil
+	}
+
+	args := []interface{}{postID}
+	for _, nick := range post.Mentions {
+		args = append(args, nick)
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(post.Mentions)), ", ")
+
+	_, err := tx.ExecContext(ctx, "insert ignore into post_mentions (post_id, user_id) select ?, id from users where nick in ("+placeholders+")", args...)
+
+	return err
+}
+
+// FindByID returns a post that match with a given ID, flagging if the given user liked it.
+// It returns a not found error if there is no such post
+func (repository PostRepository) FindByID(ctx context.Context, postID, userID uint64) (model.Post, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	row := repository.db.QueryRowContext(ctx, `select
+										`+postWithRepostSelect+`
+									from
+										`+postWithRepostFrom+`
+									where
+										p.id = ?`, userID, postID)
 
-	rows, err := repository.db.Query("select p.*, u.nick from posts p join users u on p.author_id = u.id where p.id = ?", postID)
+	post, err := scanPostWithRepost(row)
+	if err == sql.ErrNoRows {
+		return model.Post{}, model.NewNotFoundError("post")
+	}
 
 	if err != nil {
 		return model.Post{}, err
 	}
 
+	return post, nil
+}
+
+// Index returns a page of posts by a user and from who they are following, from the newest to the oldest.
+// Reposts come along with the post they re-share
+func (repository PostRepository) Index(ctx context.Context, userID uint64, page model.PageRequest) (model.PostPage, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := repository.db.QueryContext(ctx, `select distinct
+										`+postWithRepostSelect+`
+									from
+										`+postWithRepostFrom+`
+									join followers f on
+										p.author_id = f.user_id
+									where
+										(u.id = ? or f.follower_id = ?) and p.id < ?
+									order by p.id desc
+									limit ?`, userID, userID, userID, descendingCursor(page), page.Limit+1)
+
+	if err != nil {
+		return model.PostPage{}, err
+	}
+
 	defer rows.Close()
 
-	var post model.Post
+	posts, err := scanPostsWithRepost(rows)
+	if err != nil {
+		return model.PostPage{}, err
+	}
+
+	return newPostPage(posts, page), nil
+}
+
+// Thread returns a post and the replies below it, down to config.MaxThreadDepth levels, from the oldest to the newest.
+// It returns a not found error if there is no such post
+func (repository PostRepository) Thread(ctx context.Context, postID, userID uint64) ([]model.Post, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := repository.db.QueryContext(ctx, `with recursive thread (id, depth) as (
+										select id, 0 from posts where id = ?
+										union all
+										select r.id, t.depth + 1 from posts r join thread t on r.parent_post_id = t.id where t.depth < ?
+									)
+									select
+										`+postSelect+`
+									from
+										posts p
+									join thread t on
+										p.id = t.id
+									join users u on
+										p.author_id = u.id
+									order by p.id`, postID, config.MaxThreadDepth, userID)
+
+	if err != nil {
+		return nil, err
+	}
 
-	if rows.Next() {
+	defer rows.Close()
 
-		err = rows.Scan(&post.ID, &post.Title, &post.Content, &post.AuthorID, &post.Likes, &post.CreatedAt, &post.AuthorNick)
+	var posts []model.Post
 
+	for rows.Next() {
+		post, err := scanPost(rows)
 		if err != nil {
-			return model.Post{}, err
+			return nil, err
 		}
 
+		posts = append(posts, post)
 	}
 
-	return post, nil
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(posts) == 0 {
+		return nil, model.NewNotFoundError("post")
+	}
+
+	return posts, nil
 }
 
-// Index returns all posts by a user and from who they are following
-func (repository PostRepository) Index(userID uint64) ([]model.Post, error) {
+// Search returns a page of posts that match a full-text search, from the most to the least relevant.
+// Posts as relevant as each other come from the newest to the oldest
+func (repository PostRepository) Search(ctx context.Context, search model.PostSearch, viewerID uint64, page model.SearchPageRequest) (model.PostSearchPage, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
 
-	rows, err := repository.db.Query(`select distinct
-										p.*,
-										u.nick
+	var afterScore, afterID interface{}
+	if page.After != nil {
+		afterScore, afterID = page.After.Score, page.After.ID
+	}
+
+	rows, err := repository.db.QueryContext(ctx, `select
+										`+postSelect+`,
+										match(p.title, p.content) against (? in natural language mode) as score
 									from
 										posts p
 									join users u on
 										p.author_id = u.id
-									join followers f on 
-										p.author_id = f.user_id 
 									where
-										u.id = ? or f.follower_id = ? order by p.id desc`, userID, userID)
+										match(p.title, p.content) against (? in natural language mode)
+										and (? is null or p.author_id = ?)
+										and (? is null or p.created_at >= ?)
+										and (? is null or p.created_at < ?)
+										and (? is null or (match(p.title, p.content) against (? in natural language mode), p.id) < (?, ?))
+									order by score desc, p.id desc
+									limit ?`,
+		viewerID, search.Query, search.Query,
+		search.AuthorID, search.AuthorID,
+		search.From, search.From,
+		search.Until, search.Until,
+		afterScore, search.Query, afterScore, afterID,
+		page.Limit+1)
 
 	if err != nil {
-		return nil, err
+		return model.PostSearchPage{}, err
 	}
 
 	defer rows.Close()
 
-	var posts []model.Post
+	var results []model.PostSearchResult
 
 	for rows.Next() {
-		var post model.Post
-		err = rows.Scan(&post.ID, &post.Title, &post.Content, &post.AuthorID, &post.Likes, &post.CreatedAt, &post.AuthorNick)
+		var result model.PostSearchResult
 
+		result.Post, err = scanPost(rows, &result.Score)
 		if err != nil {
-			return nil, err
+			return model.PostSearchPage{}, err
 		}
 
-		posts = append(posts, post)
+		result.Snippet = search.Snippet(result.Content)
+		results = append(results, result)
 	}
 
-	return posts, nil
+	if err = rows.Err(); err != nil {
+		return model.PostSearchPage{}, err
+	}
+
+	return newPostSearchPage(results, page), nil
 }
 
-// Update updates a post in database
-func (repository PostRepository) Update(postID uint64, post model.Post) error {
+// Update updates a post in database, replacing its tags and mentions. The post must hold the version
+// it was read with, and a version mismatch error is returned if it was changed since then
+func (repository PostRepository) Update(ctx context.Context, postID uint64, post model.Post) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := repository.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
-	statement, err := repository.db.Prepare("update posts set title = ?, content = ? where id = ?")
+	statement, err := tx.PrepareContext(ctx, "update posts set title = ?, content = ?, version = version + 1, updated_at = current_timestamp() where id = ? and version = ?")
 
 	if err != nil {
 		return err
 	}
 	defer statement.Close()
 
-	_, err = statement.Exec(post.Title, post.Content, postID)
+	result, err := statement.ExecContext(ctx, post.Title, post.Content, postID, post.Version)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	if err = checkVersionUpdated(result, "post"); err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, "delete from post_tags where post_id = ?", postID); err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, "delete from post_mentions where post_id = ?", postID); err != nil {
+		return err
+	}
+
+	if err = saveReferences(ctx, tx, postID, post); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-// Update deletes a post from database
-func (repository PostRepository) Delete(postID uint64) error {
-	statement, err := repository.db.Prepare("delete from posts where id = ?")
+// Delete deletes a post from database, along with its plain reposts. Quotes are kept, as they have content of their own
+func (repository PostRepository) Delete(ctx context.Context, postID uint64) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := repository.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, "delete p from posts p where p.repost_of_id = ? and "+plainRepostCondition, postID); err != nil {
+		return err
+	}
+
+	statement, err := tx.PrepareContext(ctx, "delete from posts where id = ?")
 
 	if err != nil {
 		return err
 	}
 	defer statement.Close()
 
-	_, err = statement.Exec(postID)
+	_, err = statement.ExecContext(ctx, postID)
 	if err != nil {
 		return err
 	}
 
+	return tx.Commit()
+}
+
+// FindByUser returns a page of posts from a given user, flagging the ones liked by the viewer.
+// It returns a not found error if there is no such user
+func (repository PostRepository) FindByUser(ctx context.Context, userID, viewerID uint64, page model.PageRequest) (model.PostPage, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := repository.db.QueryContext(ctx, `select distinct
+										`+postWithRepostSelect+`
+									from
+										`+postWithRepostFrom+`
+									where
+										u.id = ? and p.id < ?
+									order by p.id desc
+									limit ?`, viewerID, userID, descendingCursor(page), page.Limit+1)
+
+	if err != nil {
+		return model.PostPage{}, err
+	}
+
+	defer rows.Close()
+
+	posts, err := scanPostsWithRepost(rows)
+	if err != nil {
+		return model.PostPage{}, err
+	}
+
+	if len(posts) == 0 {
+		if err = repository.checkUserExists(ctx, userID); err != nil {
+			return model.PostPage{}, err
+		}
+	}
+
+	return newPostPage(posts, page), nil
+}
+
+// checkUserExists returns a not found error if there is no user with a given ID
+func (repository PostRepository) checkUserExists(ctx context.Context, userID uint64) error {
+	var exists bool
+
+	if err := repository.db.QueryRowContext(ctx, "select exists(select 1 from users where id = ?)", userID).Scan(&exists); err != nil {
+		return err
+	}
+
+	if !exists {
+		return model.NewNotFoundError("user")
+	}
+
 	return nil
 }
 
-// FindByUser returns all posts from a given user
-func (repository PostRepository) FindByUser(userID uint64) ([]model.Post, error) {
+// FindByTag returns a page of posts that use a given tag, from the newest to the oldest
+func (repository PostRepository) FindByTag(ctx context.Context, tag string, viewerID uint64, page model.PageRequest) (model.PostPage, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
 
-	rows, err := repository.db.Query(`select distinct
-										p.*,
-										u.nick
+	rows, err := repository.db.QueryContext(ctx, `select
+										`+postWithRepostSelect+`
 									from
-										posts p
-									join users u on
-										p.author_id = u.id
+										`+postWithRepostFrom+`
+									join post_tags pt on
+										p.id = pt.post_id
 									where
-										u.id = ?`, userID)
+										pt.tag = ? and p.id < ?
+									order by p.id desc
+									limit ?`, viewerID, tag, descendingCursor(page), page.Limit+1)
+
+	if err != nil {
+		return model.PostPage{}, err
+	}
+
+	defer rows.Close()
+
+	posts, err := scanPostsWithRepost(rows)
+	if err != nil {
+		return model.PostPage{}, err
+	}
+
+	return newPostPage(posts, page), nil
+}
+
+// FindByMention returns a page of posts that mention a given user, from the newest to the oldest.
+// It returns a not found error if there is no such user
+func (repository PostRepository) FindByMention(ctx context.Context, userID, viewerID uint64, page model.PageRequest) (model.PostPage, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := repository.db.QueryContext(ctx, `select
+										`+postWithRepostSelect+`
+									from
+										`+postWithRepostFrom+`
+									join post_mentions pm on
+										p.id = pm.post_id
+									where
+										pm.user_id = ? and p.id < ?
+									order by p.id desc
+									limit ?`, viewerID, userID, descendingCursor(page), page.Limit+1)
+
+	if err != nil {
+		return model.PostPage{}, err
+	}
+
+	defer rows.Close()
+
+	posts, err := scanPostsWithRepost(rows)
+	if err != nil {
+		return model.PostPage{}, err
+	}
+
+	if len(posts) == 0 {
+		if err = repository.checkUserExists(ctx, userID); err != nil {
+			return model.PostPage{}, err
+		}
+	}
+
+	return newPostPage(posts, page), nil
+}
 
+// TrendingTags returns the tags most used by the posts created since a given time
+func (repository PostRepository) TrendingTags(ctx context.Context, since time.Time, limit uint64) ([]model.TrendingTag, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := repository.db.QueryContext(ctx, `select pt.tag, count(*) as uses 
+									from post_tags pt join posts p on pt.post_id = p.id 
+									where p.created_at >= ? 
+									group by pt.tag 
+									order by uses desc, pt.tag 
+									limit ?`, since, limit)
 	if err != nil {
 		return nil, err
 	}
 
 	defer rows.Close()
 
-	var posts []model.Post
+	var tags []model.TrendingTag
 
 	for rows.Next() {
-		var post model.Post
-		err = rows.Scan(&post.ID, &post.Title, &post.Content, &post.AuthorID, &post.Likes, &post.CreatedAt, &post.AuthorNick)
+		var tag model.TrendingTag
 
-		if err != nil {
+		if err = rows.Scan(&tag.Tag, &tag.Posts); err != nil {
 			return nil, err
 		}
 
-		posts = append(posts, post)
+		tags = append(tags, tag)
 	}
 
-	return posts, nil
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
 }
 
-// LikePost increases the number of likes in a post
-func (repository PostRepository) LikePost(postID uint64) error {
+// LikePost registers that a given user liked a post. Liking the same post twice has no effect
+func (repository PostRepository) LikePost(ctx context.Context, postID, userID uint64) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
 
-	statement, err := repository.db.Prepare("update posts set likes = likes + 1 where id = ?")
+	tx, err := repository.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
+	result, err := tx.ExecContext(ctx, "insert ignore into post_likes (post_id, user_id) values (?, ?)", postID, userID)
 	if err != nil {
 		return err
 	}
-	defer statement.Close()
 
-	_, err = statement.Exec(postID)
+	affectedRows, err := result.RowsAffected()
 	if err != nil {
 		return err
 	}
 
-	return nil
+	if affectedRows > 0 {
+		if _, err = tx.ExecContext(ctx, "update posts set likes = likes + 1 where id = ?", postID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
 
-// DeslikePost decreases the number of likes in a post
-func (repository PostRepository) DeslikePost(postID uint64) error {
+// DeslikePost removes the like of a given user from a post. Removing a like that does not exist has no effect
+func (repository PostRepository) DeslikePost(ctx context.Context, postID, userID uint64) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
 
-	statement, err := repository.db.Prepare("update posts set likes = case when likes > 0 then likes - 1 else 0 end where id = ?")
+	tx, err := repository.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
+	result, err := tx.ExecContext(ctx, "delete from post_likes where post_id = ? and user_id = ?", postID, userID)
 	if err != nil {
 		return err
 	}
-	defer statement.Close()
 
-	_, err = statement.Exec(postID)
+	affectedRows, err := result.RowsAffected()
 	if err != nil {
 		return err
 	}
 
-	return nil
+	if affectedRows > 0 {
+		if _, err = tx.ExecContext(ctx, "update posts set likes = case when likes > 0 then likes - 1 else 0 end where id = ?", postID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// FindLikes returns a page of the users that liked a given post
+func (repository PostRepository) FindLikes(ctx context.Context, postID uint64, page model.PageRequest) (model.UserPage, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := repository.db.QueryContext(ctx, `select `+selectColumns("u", userColumns)+`
+									from users u join post_likes pl on u.id = pl.user_id where pl.post_id = ? and u.id > ?
+									order by u.id limit ?`,
+		postID, page.Cursor, page.Limit+1)
+	if err != nil {
+		return model.UserPage{}, err
+	}
+
+	defer rows.Close()
+
+	users, err := scanUsers(rows)
+	if err != nil {
+		return model.UserPage{}, err
+	}
+
+	return newUserPage(users, page), nil
 }
@@ -1,13 +1,17 @@
 package repository_test
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"io/ioutil"
+	"math"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
+	"github.com/waliqueiroz/devbook-api/config"
 	"github.com/waliqueiroz/devbook-api/model"
 	"github.com/waliqueiroz/devbook-api/repository"
 	"github.com/waliqueiroz/devbook-api/test/mock"
@@ -28,11 +32,16 @@ func TestCreatePost(t *testing.T) {
 		errorInExec    bool
 		errorInResult  bool
 		errorInScanRow bool
+		hasReferences  bool
 		err            error
 	}{
 		{
 			name: "Create post",
 		},
+		{
+			name:          "Create post with tags and mentions",
+			hasReferences: true,
+		},
 		{
 			name:           "Create post - error in prepare",
 			errorInPrepare: true,
@@ -57,50 +66,83 @@ func TestCreatePost(t *testing.T) {
 
 	repository := repository.NewPostRepository(db)
 
-	insertQuery := "insert into posts \\(title, content, author_id\\) values \\(\\?, \\?, \\?\\)"
-	selectQuery := "select p.*, u.nick from posts p join users u on p.author_id = u.id where p.id = \\?"
+	insertQuery := "insert into posts \\(title, content, author_id, parent_post_id, repost_of_id\\) values \\(\\?, \\?, \\?, \\?, \\?\\)"
+	tagQuery := "insert ignore into post_tags \\(post_id, tag\\) values \\(\\?, \\?\\)"
+	mentionQuery := "insert ignore into post_mentions \\(post_id, user_id\\) select \\?, id from users where nick in \\(\\?, \\?\\)"
+	selectQuery := "select p.id, p.title, p.content, p.author_id, p.likes, p.created_at, p.parent_post_id, p.repost_of_id, p.version, p.updated_at, u.nick, exists\\(select 1 from post_likes pl where pl.post_id = p.id and pl.user_id = \\?\\), \\(select count\\(\\*\\) from comments c where c.post_id = p.id\\), \\(select count\\(\\*\\) from posts r where r.parent_post_id = p.id\\), \\(select count\\(\\*\\) from posts r where r.repost_of_id = p.id\\), o.title, o.content, o.author_id, ou.nick, o.created_at from posts p join users u on p.author_id = u.id left join posts o on p.repost_of_id = o.id left join users ou on o.author_id = ou.id where p.id = \\?"
 
 	for _, subTest := range subTests {
 		t.Run(subTest.name, func(t *testing.T) {
 
 			if subTest.errorInPrepare {
+				mock.ExpectBegin()
 				mock.ExpectPrepare(insertQuery).WillReturnError(subTest.err)
+				mock.ExpectRollback()
 
-				_, err := repository.Create(post)
+				_, err := repository.Create(context.Background(), post)
 				assert.ErrorIs(t, err, subTest.err)
 			} else if subTest.errorInExec {
+				mock.ExpectBegin()
 				prep := mock.ExpectPrepare(insertQuery)
-				prep.ExpectExec().WithArgs(post.Title, post.Content, post.AuthorID).WillReturnError(subTest.err)
+				prep.ExpectExec().WithArgs(post.Title, post.Content, post.AuthorID, post.ParentPostID, post.RepostOfID).WillReturnError(subTest.err)
+				mock.ExpectRollback()
 
-				_, err := repository.Create(post)
+				_, err := repository.Create(context.Background(), post)
 				assert.ErrorIs(t, err, subTest.err)
 			} else if subTest.errorInResult {
+				mock.ExpectBegin()
 				prep := mock.ExpectPrepare(insertQuery)
-				prep.ExpectExec().WithArgs(post.Title, post.Content, post.AuthorID).WillReturnResult(sqlmock.NewErrorResult(subTest.err))
+				prep.ExpectExec().WithArgs(post.Title, post.Content, post.AuthorID, post.ParentPostID, post.RepostOfID).WillReturnResult(sqlmock.NewErrorResult(subTest.err))
+				mock.ExpectRollback()
 
-				_, err := repository.Create(post)
+				_, err := repository.Create(context.Background(), post)
 				assert.ErrorIs(t, err, subTest.err)
 			} else if subTest.errorInScanRow {
+				mock.ExpectBegin()
 				prep := mock.ExpectPrepare(insertQuery)
-				prep.ExpectExec().WithArgs(post.Title, post.Content, post.AuthorID).WillReturnResult(sqlmock.NewResult(1, 1))
+				prep.ExpectExec().WithArgs(post.Title, post.Content, post.AuthorID, post.ParentPostID, post.RepostOfID).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
 
 				rows := sqlmock.NewRows([]string{"id"}).
 					AddRow(-1)
 
-				mock.ExpectQuery(selectQuery).WithArgs(post.ID).WillReturnRows(rows)
+				mock.ExpectQuery(selectQuery).WithArgs(post.AuthorID, post.ID).WillReturnRows(rows)
 
-				_, err := repository.Create(post)
+				_, err := repository.Create(context.Background(), post)
 				assert.Error(t, err)
+			} else if subTest.hasReferences {
+				taggedPost := post
+				taggedPost.Content = "Falando de #Go e #go com @user2 e @ninguem."
+				taggedPost.ExtractReferences()
+
+				mock.ExpectBegin()
+				prep := mock.ExpectPrepare(insertQuery)
+				prep.ExpectExec().WithArgs(taggedPost.Title, taggedPost.Content, taggedPost.AuthorID, taggedPost.ParentPostID, taggedPost.RepostOfID).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(tagQuery).WithArgs(1, "go").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(mentionQuery).WithArgs(1, "user2", "ninguem").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+
+				rows := sqlmock.NewRows([]string{"id", "title", "content", "author_id", "likes", "created_at", "parent_post_id", "repost_of_id", "version", "updated_at", "nick", "liked_by_me", "comment_count", "reply_count", "repost_count", "original_title", "original_content", "original_author_id", "original_nick", "original_created_at"}).
+					AddRow(post.ID, post.Title, taggedPost.Content, post.AuthorID, post.Likes, post.CreatedAt, post.ParentPostID, post.RepostOfID, post.Version, post.UpdatedAt, post.AuthorNick, post.LikedByMe, post.CommentCount, post.ReplyCount, post.RepostCount, nil, nil, nil, nil, nil)
+
+				mock.ExpectQuery(selectQuery).WithArgs(post.AuthorID, post.ID).WillReturnRows(rows)
+
+				createdPost, err := repository.Create(context.Background(), taggedPost)
+				assert.NoError(t, err)
+				assert.Equal(t, taggedPost.Content, createdPost.Content)
+				assert.NoError(t, mock.ExpectationsWereMet())
 			} else {
+				mock.ExpectBegin()
 				prep := mock.ExpectPrepare(insertQuery)
-				prep.ExpectExec().WithArgs(post.Title, post.Content, post.AuthorID).WillReturnResult(sqlmock.NewResult(1, 1))
+				prep.ExpectExec().WithArgs(post.Title, post.Content, post.AuthorID, post.ParentPostID, post.RepostOfID).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
 
-				rows := sqlmock.NewRows([]string{"id", "title", "content", "author_id", "likes", "created_at", "nick"}).
-					AddRow(post.ID, post.Title, post.Content, post.AuthorID, post.Likes, post.CreatedAt, post.AuthorNick)
+				rows := sqlmock.NewRows([]string{"id", "title", "content", "author_id", "likes", "created_at", "parent_post_id", "repost_of_id", "version", "updated_at", "nick", "liked_by_me", "comment_count", "reply_count", "repost_count", "original_title", "original_content", "original_author_id", "original_nick", "original_created_at"}).
+					AddRow(post.ID, post.Title, post.Content, post.AuthorID, post.Likes, post.CreatedAt, post.ParentPostID, post.RepostOfID, post.Version, post.UpdatedAt, post.AuthorNick, post.LikedByMe, post.CommentCount, post.ReplyCount, post.RepostCount, nil, nil, nil, nil, nil)
 
-				mock.ExpectQuery(selectQuery).WithArgs(post.ID).WillReturnRows(rows)
+				mock.ExpectQuery(selectQuery).WithArgs(post.AuthorID, post.ID).WillReturnRows(rows)
 
-				createdPost, _ := repository.Create(post)
+				createdPost, _ := repository.Create(context.Background(), post)
 				assert.Equal(t, post, createdPost)
 			}
 		})
@@ -118,6 +160,7 @@ func TestFindPostByID(t *testing.T) {
 
 	subTests := []struct {
 		name           string
+		notFound       bool
 		errorInExec    bool
 		errorInScanRow bool
 		err            error
@@ -126,6 +169,10 @@ func TestFindPostByID(t *testing.T) {
 			name:        "Find by ID",
 			errorInExec: false,
 		},
+		{
+			name:     "Find by ID - not found",
+			notFound: true,
+		},
 		{
 			name:        "Find by ID - error in exec query",
 			errorInExec: true,
@@ -140,31 +187,38 @@ func TestFindPostByID(t *testing.T) {
 
 	repository := repository.NewPostRepository(db)
 
-	query := "select p.*, u.nick from posts p join users u on p.author_id = u.id where p.id = \\?"
+	query := "select p.id, p.title, p.content, p.author_id, p.likes, p.created_at, p.parent_post_id, p.repost_of_id, p.version, p.updated_at, u.nick, exists\\(select 1 from post_likes pl where pl.post_id = p.id and pl.user_id = \\?\\), \\(select count\\(\\*\\) from comments c where c.post_id = p.id\\), \\(select count\\(\\*\\) from posts r where r.parent_post_id = p.id\\), \\(select count\\(\\*\\) from posts r where r.repost_of_id = p.id\\), o.title, o.content, o.author_id, ou.nick, o.created_at from posts p join users u on p.author_id = u.id left join posts o on p.repost_of_id = o.id left join users ou on o.author_id = ou.id where p.id = \\?"
 
 	for _, subTest := range subTests {
 		t.Run(subTest.name, func(t *testing.T) {
 
-			if subTest.errorInExec {
+			if subTest.notFound {
+				rows := sqlmock.NewRows([]string{"id", "title", "content", "author_id", "likes", "created_at", "parent_post_id", "repost_of_id", "version", "updated_at", "nick", "liked_by_me", "comment_count", "reply_count", "repost_count", "original_title", "original_content", "original_author_id", "original_nick", "original_created_at"})
+
+				mock.ExpectQuery(query).WithArgs(post.AuthorID, post.ID).WillReturnRows(rows)
+
+				_, err := repository.FindByID(context.Background(), post.ID, post.AuthorID)
+				assert.True(t, model.IsNotFound(err), "Error should be a not found error")
+			} else if subTest.errorInExec {
 				mock.ExpectQuery(query).WillReturnError(subTest.err)
 
-				_, err := repository.FindByID(post.ID)
+				_, err := repository.FindByID(context.Background(), post.ID, post.AuthorID)
 				assert.ErrorIs(t, err, subTest.err)
 			} else if subTest.errorInScanRow {
 				rows := sqlmock.NewRows([]string{"id"}).
 					AddRow(-1)
 
-				mock.ExpectQuery(query).WithArgs(post.ID).WillReturnRows(rows)
+				mock.ExpectQuery(query).WithArgs(post.AuthorID, post.ID).WillReturnRows(rows)
 
-				_, err := repository.FindByID(post.ID)
+				_, err := repository.FindByID(context.Background(), post.ID, post.AuthorID)
 				assert.Error(t, err)
 			} else {
-				rows := sqlmock.NewRows([]string{"id", "title", "content", "author_id", "likes", "created_at", "nick"}).
-					AddRow(post.ID, post.Title, post.Content, post.AuthorID, post.Likes, post.CreatedAt, post.AuthorNick)
+				rows := sqlmock.NewRows([]string{"id", "title", "content", "author_id", "likes", "created_at", "parent_post_id", "repost_of_id", "version", "updated_at", "nick", "liked_by_me", "comment_count", "reply_count", "repost_count", "original_title", "original_content", "original_author_id", "original_nick", "original_created_at"}).
+					AddRow(post.ID, post.Title, post.Content, post.AuthorID, post.Likes, post.CreatedAt, post.ParentPostID, post.RepostOfID, post.Version, post.UpdatedAt, post.AuthorNick, post.LikedByMe, post.CommentCount, post.ReplyCount, post.RepostCount, nil, nil, nil, nil, nil)
 
-				mock.ExpectQuery(query).WithArgs(post.ID).WillReturnRows(rows)
+				mock.ExpectQuery(query).WithArgs(post.AuthorID, post.ID).WillReturnRows(rows)
 
-				createdPost, _ := repository.FindByID(post.ID)
+				createdPost, _ := repository.FindByID(context.Background(), post.ID, post.AuthorID)
 				assert.Equal(t, post, createdPost)
 			}
 		})
@@ -182,6 +236,9 @@ func TestIndexPost(t *testing.T) {
 
 	subTests := []struct {
 		name           string
+		hasNextPage    bool
+		hasRepost      bool
+		hasQuoteOfGone bool
 		errorInExec    bool
 		errorInScanRow bool
 		err            error
@@ -190,6 +247,18 @@ func TestIndexPost(t *testing.T) {
 			name:        "Index",
 			errorInExec: false,
 		},
+		{
+			name:        "Index - with next page",
+			hasNextPage: true,
+		},
+		{
+			name:      "Index - with a repost",
+			hasRepost: true,
+		},
+		{
+			name:           "Index - with a quote of a deleted post",
+			hasQuoteOfGone: true,
+		},
 		{
 			name:        "Index - error in exec query",
 			errorInExec: true,
@@ -204,7 +273,9 @@ func TestIndexPost(t *testing.T) {
 
 	repository := repository.NewPostRepository(db)
 
-	query := "select distinct p.*, u.nick from posts p join users u on p.author_id = u.id join followers f on p.author_id = f.user_id where u.id = \\? or f.follower_id = \\? order by p.id desc"
+	page := model.PageRequest{Limit: 1}
+
+	query := "select distinct p.id, p.title, p.content, p.author_id, p.likes, p.created_at, p.parent_post_id, p.repost_of_id, p.version, p.updated_at, u.nick, exists\\(select 1 from post_likes pl where pl.post_id = p.id and pl.user_id = \\?\\), \\(select count\\(\\*\\) from comments c where c.post_id = p.id\\), \\(select count\\(\\*\\) from posts r where r.parent_post_id = p.id\\), \\(select count\\(\\*\\) from posts r where r.repost_of_id = p.id\\), o.title, o.content, o.author_id, ou.nick, o.created_at from posts p join users u on p.author_id = u.id left join posts o on p.repost_of_id = o.id left join users ou on o.author_id = ou.id join followers f on p.author_id = f.user_id where \\(u.id = \\? or f.follower_id = \\?\\) and p.id < \\? order by p.id desc limit \\?"
 
 	for _, subTest := range subTests {
 		t.Run(subTest.name, func(t *testing.T) {
@@ -212,24 +283,58 @@ func TestIndexPost(t *testing.T) {
 			if subTest.errorInExec {
 				mock.ExpectQuery(query).WillReturnError(subTest.err)
 
-				_, err := repository.Index(post.AuthorID)
+				_, err := repository.Index(context.Background(), post.AuthorID, page)
 				assert.ErrorIs(t, err, subTest.err)
 			} else if subTest.errorInScanRow {
 				rows := sqlmock.NewRows([]string{"id"}).
 					AddRow(-1)
 
-				mock.ExpectQuery(query).WithArgs(post.AuthorID, post.AuthorID).WillReturnRows(rows)
+				mock.ExpectQuery(query).WithArgs(post.AuthorID, post.AuthorID, post.AuthorID, math.MaxInt64, page.Limit+1).WillReturnRows(rows)
 
-				_, err := repository.Index(post.AuthorID)
+				_, err := repository.Index(context.Background(), post.AuthorID, page)
 				assert.Error(t, err)
+			} else if subTest.hasRepost {
+				repostID := post.ID + 1
+				originalAuthorID := uint64(2)
+
+				rows := sqlmock.NewRows([]string{"id", "title", "content", "author_id", "likes", "created_at", "parent_post_id", "repost_of_id", "version", "updated_at", "nick", "liked_by_me", "comment_count", "reply_count", "repost_count", "original_title", "original_content", "original_author_id", "original_nick", "original_created_at"}).
+					AddRow(repostID, "", "", post.AuthorID, 0, post.CreatedAt, nil, post.ID, 1, post.CreatedAt, post.AuthorNick, false, 0, 0, 0, post.Title, post.Content, originalAuthorID, "user2", post.CreatedAt)
+
+				mock.ExpectQuery(query).WithArgs(post.AuthorID, post.AuthorID, post.AuthorID, math.MaxInt64, page.Limit+1).WillReturnRows(rows)
+
+				postPage, _ := repository.Index(context.Background(), post.AuthorID, page)
+				assert.Len(t, postPage.Data, 1)
+				assert.Equal(t, &post.ID, postPage.Data[0].RepostOfID)
+				assert.Equal(t, &model.Post{ID: post.ID, Title: post.Title, Content: post.Content, AuthorID: originalAuthorID, AuthorNick: "user2", CreatedAt: post.CreatedAt}, postPage.Data[0].RepostOf)
+			} else if subTest.hasQuoteOfGone {
+				deletedID := post.ID + 1
+
+				rows := sqlmock.NewRows([]string{"id", "title", "content", "author_id", "likes", "created_at", "parent_post_id", "repost_of_id", "version", "updated_at", "nick", "liked_by_me", "comment_count", "reply_count", "repost_count", "original_title", "original_content", "original_author_id", "original_nick", "original_created_at"}).
+					AddRow(post.ID, "", post.Content, post.AuthorID, 0, post.CreatedAt, nil, deletedID, 1, post.CreatedAt, post.AuthorNick, false, 0, 0, 0, nil, nil, nil, nil, nil)
+
+				mock.ExpectQuery(query).WithArgs(post.AuthorID, post.AuthorID, post.AuthorID, math.MaxInt64, page.Limit+1).WillReturnRows(rows)
+
+				postPage, _ := repository.Index(context.Background(), post.AuthorID, page)
+				assert.Len(t, postPage.Data, 1)
+				assert.Equal(t, &model.Post{ID: deletedID, Unavailable: true}, postPage.Data[0].RepostOf)
+			} else if subTest.hasNextPage {
+				rows := sqlmock.NewRows([]string{"id", "title", "content", "author_id", "likes", "created_at", "parent_post_id", "repost_of_id", "version", "updated_at", "nick", "liked_by_me", "comment_count", "reply_count", "repost_count", "original_title", "original_content", "original_author_id", "original_nick", "original_created_at"}).
+					AddRow(post.ID+1, post.Title, post.Content, post.AuthorID, post.Likes, post.CreatedAt, post.ParentPostID, post.RepostOfID, post.Version, post.UpdatedAt, post.AuthorNick, post.LikedByMe, post.CommentCount, post.ReplyCount, post.RepostCount, nil, nil, nil, nil, nil).
+					AddRow(post.ID, post.Title, post.Content, post.AuthorID, post.Likes, post.CreatedAt, post.ParentPostID, post.RepostOfID, post.Version, post.UpdatedAt, post.AuthorNick, post.LikedByMe, post.CommentCount, post.ReplyCount, post.RepostCount, nil, nil, nil, nil, nil)
+
+				mock.ExpectQuery(query).WithArgs(post.AuthorID, post.AuthorID, post.AuthorID, math.MaxInt64, page.Limit+1).WillReturnRows(rows)
+
+				postPage, _ := repository.Index(context.Background(), post.AuthorID, page)
+				assert.Len(t, postPage.Data, 1)
+				assert.Equal(t, model.EncodeCursor(post.ID+1), postPage.NextCursor)
 			} else {
-				rows := sqlmock.NewRows([]string{"id", "title", "content", "author_id", "likes", "created_at", "nick"}).
-					AddRow(post.ID, post.Title, post.Content, post.AuthorID, post.Likes, post.CreatedAt, post.AuthorNick)
+				rows := sqlmock.NewRows([]string{"id", "title", "content", "author_id", "likes", "created_at", "parent_post_id", "repost_of_id", "version", "updated_at", "nick", "liked_by_me", "comment_count", "reply_count", "repost_count", "original_title", "original_content", "original_author_id", "original_nick", "original_created_at"}).
+					AddRow(post.ID, post.Title, post.Content, post.AuthorID, post.Likes, post.CreatedAt, post.ParentPostID, post.RepostOfID, post.Version, post.UpdatedAt, post.AuthorNick, post.LikedByMe, post.CommentCount, post.ReplyCount, post.RepostCount, nil, nil, nil, nil, nil)
 
-				mock.ExpectQuery(query).WithArgs(post.AuthorID, post.AuthorID).WillReturnRows(rows)
+				mock.ExpectQuery(query).WithArgs(post.AuthorID, post.AuthorID, post.AuthorID, math.MaxInt64, page.Limit+1).WillReturnRows(rows)
 
-				createdPosts, _ := repository.Index(post.AuthorID)
-				assert.Equal(t, []model.Post{post}, createdPosts)
+				postPage, _ := repository.Index(context.Background(), post.AuthorID, page)
+				assert.Equal(t, model.PostPage{Data: []model.Post{post}}, postPage)
 			}
 		})
 	}
@@ -248,11 +353,17 @@ func TestUpdatePost(t *testing.T) {
 		name           string
 		errorInPrepare bool
 		errorInExec    bool
+		hasReferences  bool
+		staleVersion   bool
 		err            error
 	}{
 		{
 			name: "Update post",
 		},
+		{
+			name:          "Update post with tags and mentions",
+			hasReferences: true,
+		},
 		{
 			name:           "Update post - error in prepare",
 			errorInPrepare: true,
@@ -263,31 +374,75 @@ func TestUpdatePost(t *testing.T) {
 			errorInExec: true,
 			err:         errors.New("some error"),
 		},
+		{
+			name:         "Update post - changed since it was read",
+			staleVersion: true,
+		},
 	}
 
 	repository := repository.NewPostRepository(db)
 
-	query := "update posts set title = \\?, content = \\? where id = \\?"
+	query := "update posts set title = \\?, content = \\?, version = version \\+ 1, updated_at = current_timestamp\\(\\) where id = \\? and version = \\?"
+	deleteTagsQuery := "delete from post_tags where post_id = \\?"
+	deleteMentionsQuery := "delete from post_mentions where post_id = \\?"
+	tagQuery := "insert ignore into post_tags \\(post_id, tag\\) values \\(\\?, \\?\\)"
+	mentionQuery := "insert ignore into post_mentions \\(post_id, user_id\\) select \\?, id from users where nick in \\(\\?\\)"
 
 	for _, subTest := range subTests {
 		t.Run(subTest.name, func(t *testing.T) {
 
 			if subTest.errorInPrepare {
+				mock.ExpectBegin()
 				mock.ExpectPrepare(query).WillReturnError(subTest.err)
+				mock.ExpectRollback()
 
-				err := repository.Update(post.ID, post)
+				err := repository.Update(context.Background(), post.ID, post)
 				assert.ErrorIs(t, err, subTest.err)
 			} else if subTest.errorInExec {
+				mock.ExpectBegin()
 				prep := mock.ExpectPrepare(query)
-				prep.ExpectExec().WithArgs(post.Title, post.Content, post.ID).WillReturnError(subTest.err)
+				prep.ExpectExec().WithArgs(post.Title, post.Content, post.ID, post.Version).WillReturnError(subTest.err)
+				mock.ExpectRollback()
 
-				err := repository.Update(post.ID, post)
+				err := repository.Update(context.Background(), post.ID, post)
 				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.staleVersion {
+				mock.ExpectBegin()
+				prep := mock.ExpectPrepare(query)
+				prep.ExpectExec().WithArgs(post.Title, post.Content, post.ID, post.Version).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+
+				var appError *model.AppError
+				err := repository.Update(context.Background(), post.ID, post)
+				assert.ErrorAs(t, err, &appError)
+				assert.Equal(t, model.CodeVersionMismatch, appError.Code)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			} else if subTest.hasReferences {
+				taggedPost := post
+				taggedPost.Content = "Agora com #golang para @user2"
+				taggedPost.ExtractReferences()
+
+				mock.ExpectBegin()
+				prep := mock.ExpectPrepare(query)
+				prep.ExpectExec().WithArgs(taggedPost.Title, taggedPost.Content, post.ID, post.Version).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(deleteTagsQuery).WithArgs(post.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(deleteMentionsQuery).WithArgs(post.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(tagQuery).WithArgs(post.ID, "golang").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(mentionQuery).WithArgs(post.ID, "user2").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+
+				err := repository.Update(context.Background(), post.ID, taggedPost)
+				assert.NoError(t, err)
+				assert.NoError(t, mock.ExpectationsWereMet())
 			} else {
+				mock.ExpectBegin()
 				prep := mock.ExpectPrepare(query)
-				prep.ExpectExec().WithArgs(post.Title, post.Content, post.ID).WillReturnResult(sqlmock.NewResult(1, 1))
+				prep.ExpectExec().WithArgs(post.Title, post.Content, post.ID, post.Version).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(deleteTagsQuery).WithArgs(post.ID).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(deleteMentionsQuery).WithArgs(post.ID).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
 
-				err := repository.Update(post.ID, post)
+				err := repository.Update(context.Background(), post.ID, post)
 				assert.NoError(t, err)
 			}
 		})
@@ -304,14 +459,20 @@ func TestDeletePost(t *testing.T) {
 	defer db.Close()
 
 	subTests := []struct {
-		name           string
-		errorInPrepare bool
-		errorInExec    bool
-		err            error
+		name                 string
+		errorInDeleteReposts bool
+		errorInPrepare       bool
+		errorInExec          bool
+		err                  error
 	}{
 		{
 			name: "Delete post",
 		},
+		{
+			name:                 "Delete post - error in deleting reposts",
+			errorInDeleteReposts: true,
+			err:                  errors.New("some error"),
+		},
 		{
 			name:           "Delete post - error in prepare",
 			errorInPrepare: true,
@@ -326,28 +487,45 @@ func TestDeletePost(t *testing.T) {
 
 	repository := repository.NewPostRepository(db)
 
+	repostsQuery := "delete p from posts p where p.repost_of_id = \\? and p.repost_of_id is not null and p.title = '' and p.content = ''"
+
 	query := "delete from posts where id = \\?"
 
 	for _, subTest := range subTests {
 		t.Run(subTest.name, func(t *testing.T) {
 
-			if subTest.errorInPrepare {
+			mock.ExpectBegin()
+
+			if subTest.errorInDeleteReposts {
+				mock.ExpectExec(repostsQuery).WithArgs(post.ID).WillReturnError(subTest.err)
+				mock.ExpectRollback()
+
+				err := repository.Delete(context.Background(), post.ID)
+				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.errorInPrepare {
+				mock.ExpectExec(repostsQuery).WithArgs(post.ID).WillReturnResult(sqlmock.NewResult(0, 0))
 				mock.ExpectPrepare(query).WillReturnError(subTest.err)
+				mock.ExpectRollback()
 
-				err := repository.Delete(post.ID)
+				err := repository.Delete(context.Background(), post.ID)
 				assert.ErrorIs(t, err, subTest.err)
 			} else if subTest.errorInExec {
+				mock.ExpectExec(repostsQuery).WithArgs(post.ID).WillReturnResult(sqlmock.NewResult(0, 0))
 				prep := mock.ExpectPrepare(query)
 				prep.ExpectExec().WithArgs(post.ID).WillReturnError(subTest.err)
+				mock.ExpectRollback()
 
-				err := repository.Delete(post.ID)
+				err := repository.Delete(context.Background(), post.ID)
 				assert.ErrorIs(t, err, subTest.err)
 			} else {
+				mock.ExpectExec(repostsQuery).WithArgs(post.ID).WillReturnResult(sqlmock.NewResult(0, 1))
 				prep := mock.ExpectPrepare(query)
 				prep.ExpectExec().WithArgs(post.ID).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
 
-				err := repository.Delete(post.ID)
+				err := repository.Delete(context.Background(), post.ID)
 				assert.NoError(t, err)
+				assert.NoError(t, mock.ExpectationsWereMet())
 			}
 		})
 	}
@@ -364,6 +542,8 @@ func TestFindPostByUser(t *testing.T) {
 
 	subTests := []struct {
 		name           string
+		emptyPage      bool
+		userNotFound   bool
 		errorInExec    bool
 		errorInScanRow bool
 		err            error
@@ -372,6 +552,14 @@ func TestFindPostByUser(t *testing.T) {
 			name:        "Find by user",
 			errorInExec: false,
 		},
+		{
+			name:      "Find by user - empty page",
+			emptyPage: true,
+		},
+		{
+			name:         "Find by user - user not found",
+			userNotFound: true,
+		},
 		{
 			name:        "Find by user - error in exec query",
 			errorInExec: true,
@@ -386,32 +574,50 @@ func TestFindPostByUser(t *testing.T) {
 
 	repository := repository.NewPostRepository(db)
 
-	query := "select distinct p.*, u.nick from posts p join users u on p.author_id = u.id where	u.id = \\?"
+	page := model.PageRequest{Limit: 20, Cursor: 10}
+
+	query := "select distinct p.id, p.title, p.content, p.author_id, p.likes, p.created_at, p.parent_post_id, p.repost_of_id, p.version, p.updated_at, u.nick, exists\\(select 1 from post_likes pl where pl.post_id = p.id and pl.user_id = \\?\\), \\(select count\\(\\*\\) from comments c where c.post_id = p.id\\), \\(select count\\(\\*\\) from posts r where r.parent_post_id = p.id\\), \\(select count\\(\\*\\) from posts r where r.repost_of_id = p.id\\), o.title, o.content, o.author_id, ou.nick, o.created_at from posts p join users u on p.author_id = u.id left join posts o on p.repost_of_id = o.id left join users ou on o.author_id = ou.id where u.id = \\? and p.id < \\? order by p.id desc limit \\?"
+
+	existsQuery := "select exists\\(select 1 from users where id = \\?\\)"
 
 	for _, subTest := range subTests {
 		t.Run(subTest.name, func(t *testing.T) {
 
-			if subTest.errorInExec {
+			if subTest.emptyPage || subTest.userNotFound {
+				rows := sqlmock.NewRows([]string{"id", "title", "content", "author_id", "likes", "created_at", "parent_post_id", "repost_of_id", "version", "updated_at", "nick", "liked_by_me", "comment_count", "reply_count", "repost_count", "original_title", "original_content", "original_author_id", "original_nick", "original_created_at"})
+
+				mock.ExpectQuery(query).WithArgs(post.AuthorID, post.AuthorID, page.Cursor, page.Limit+1).WillReturnRows(rows)
+				mock.ExpectQuery(existsQuery).WithArgs(post.AuthorID).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(subTest.emptyPage))
+
+				postPage, err := repository.FindByUser(context.Background(), post.AuthorID, post.AuthorID, page)
+
+				if subTest.userNotFound {
+					assert.True(t, model.IsNotFound(err), "Error should be a not found error")
+				} else {
+					assert.NoError(t, err)
+					assert.Empty(t, postPage.Data)
+				}
+			} else if subTest.errorInExec {
 				mock.ExpectQuery(query).WillReturnError(subTest.err)
 
-				_, err := repository.FindByUser(post.AuthorID)
+				_, err := repository.FindByUser(context.Background(), post.AuthorID, post.AuthorID, page)
 				assert.ErrorIs(t, err, subTest.err)
 			} else if subTest.errorInScanRow {
 				rows := sqlmock.NewRows([]string{"id"}).
 					AddRow(-1)
 
-				mock.ExpectQuery(query).WithArgs(post.AuthorID).WillReturnRows(rows)
+				mock.ExpectQuery(query).WithArgs(post.AuthorID, post.AuthorID, page.Cursor, page.Limit+1).WillReturnRows(rows)
 
-				_, err := repository.FindByUser(post.AuthorID)
+				_, err := repository.FindByUser(context.Background(), post.AuthorID, post.AuthorID, page)
 				assert.Error(t, err)
 			} else {
-				rows := sqlmock.NewRows([]string{"id", "title", "content", "author_id", "likes", "created_at", "nick"}).
-					AddRow(post.ID, post.Title, post.Content, post.AuthorID, post.Likes, post.CreatedAt, post.AuthorNick)
+				rows := sqlmock.NewRows([]string{"id", "title", "content", "author_id", "likes", "created_at", "parent_post_id", "repost_of_id", "version", "updated_at", "nick", "liked_by_me", "comment_count", "reply_count", "repost_count", "original_title", "original_content", "original_author_id", "original_nick", "original_created_at"}).
+					AddRow(post.ID, post.Title, post.Content, post.AuthorID, post.Likes, post.CreatedAt, post.ParentPostID, post.RepostOfID, post.Version, post.UpdatedAt, post.AuthorNick, post.LikedByMe, post.CommentCount, post.ReplyCount, post.RepostCount, nil, nil, nil, nil, nil)
 
-				mock.ExpectQuery(query).WithArgs(post.AuthorID).WillReturnRows(rows)
+				mock.ExpectQuery(query).WithArgs(post.AuthorID, post.AuthorID, page.Cursor, page.Limit+1).WillReturnRows(rows)
 
-				createdPosts, _ := repository.FindByUser(post.AuthorID)
-				assert.Equal(t, []model.Post{post}, createdPosts)
+				postPage, _ := repository.FindByUser(context.Background(), post.AuthorID, post.AuthorID, page)
+				assert.Equal(t, model.PostPage{Data: []model.Post{post}}, postPage)
 			}
 		})
 	}
@@ -422,111 +628,637 @@ func TestLikePost(t *testing.T) {
 	db, mock := mock.NewDatabaseConnection()
 	defer db.Close()
 
+	subTests := []struct {
+		name          string
+		alreadyLiked  bool
+		errorInBegin  bool
+		errorInInsert bool
+		errorInUpdate bool
+		errorInCommit bool
+		err           error
+	}{
+		{
+			name: "Like post",
+		},
+		{
+			name:         "Like post - already liked",
+			alreadyLiked: true,
+		},
+		{
+			name:         "Like post - error in begin",
+			errorInBegin: true,
+			err:          errors.New("some error"),
+		},
+		{
+			name:          "Like post - error in insert",
+			errorInInsert: true,
+			err:           errors.New("some error"),
+		},
+		{
+			name:          "Like post - error in update",
+			errorInUpdate: true,
+			err:           errors.New("some error"),
+		},
+		{
+			name:          "Like post - error in commit",
+			errorInCommit: true,
+			err:           errors.New("some error"),
+		},
+	}
+
+	repository := repository.NewPostRepository(db)
+
+	insertQuery := "insert ignore into post_likes \\(post_id, user_id\\) values \\(\\?, \\?\\)"
+	updateQuery := "update posts set likes = likes \\+ 1 where id = \\?"
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+
+			if subTest.errorInBegin {
+				mock.ExpectBegin().WillReturnError(subTest.err)
+
+				err := repository.LikePost(context.Background(), 1, 2)
+				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.errorInInsert {
+				mock.ExpectBegin()
+				mock.ExpectExec(insertQuery).WithArgs(1, 2).WillReturnError(subTest.err)
+				mock.ExpectRollback()
+
+				err := repository.LikePost(context.Background(), 1, 2)
+				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.errorInUpdate {
+				mock.ExpectBegin()
+				mock.ExpectExec(insertQuery).WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(updateQuery).WithArgs(1).WillReturnError(subTest.err)
+				mock.ExpectRollback()
+
+				err := repository.LikePost(context.Background(), 1, 2)
+				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.errorInCommit {
+				mock.ExpectBegin()
+				mock.ExpectExec(insertQuery).WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(updateQuery).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit().WillReturnError(subTest.err)
+
+				err := repository.LikePost(context.Background(), 1, 2)
+				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.alreadyLiked {
+				mock.ExpectBegin()
+				mock.ExpectExec(insertQuery).WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+
+				err := repository.LikePost(context.Background(), 1, 2)
+				assert.NoError(t, err)
+			} else {
+				mock.ExpectBegin()
+				mock.ExpectExec(insertQuery).WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(updateQuery).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+
+				err := repository.LikePost(context.Background(), 1, 2)
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDesikePost(t *testing.T) {
+	db, mock := mock.NewDatabaseConnection()
+	defer db.Close()
+
+	subTests := []struct {
+		name          string
+		notLiked      bool
+		errorInBegin  bool
+		errorInDelete bool
+		errorInUpdate bool
+		err           error
+	}{
+		{
+			name: "Deslike post",
+		},
+		{
+			name:     "Deslike post - not liked",
+			notLiked: true,
+		},
+		{
+			name:         "Deslike post - error in begin",
+			errorInBegin: true,
+			err:          errors.New("some error"),
+		},
+		{
+			name:          "Deslike post - error in delete",
+			errorInDelete: true,
+			err:           errors.New("some error"),
+		},
+		{
+			name:          "Deslike post - error in update",
+			errorInUpdate: true,
+			err:           errors.New("some error"),
+		},
+	}
+
+	repository := repository.NewPostRepository(db)
+
+	deleteQuery := "delete from post_likes where post_id = \\? and user_id = \\?"
+	updateQuery := "update posts set likes = case when likes \\> 0 then likes \\- 1 else 0 end where id = \\?"
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+
+			if subTest.errorInBegin {
+				mock.ExpectBegin().WillReturnError(subTest.err)
+
+				err := repository.DeslikePost(context.Background(), 1, 2)
+				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.errorInDelete {
+				mock.ExpectBegin()
+				mock.ExpectExec(deleteQuery).WithArgs(1, 2).WillReturnError(subTest.err)
+				mock.ExpectRollback()
+
+				err := repository.DeslikePost(context.Background(), 1, 2)
+				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.errorInUpdate {
+				mock.ExpectBegin()
+				mock.ExpectExec(deleteQuery).WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(updateQuery).WithArgs(1).WillReturnError(subTest.err)
+				mock.ExpectRollback()
+
+				err := repository.DeslikePost(context.Background(), 1, 2)
+				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.notLiked {
+				mock.ExpectBegin()
+				mock.ExpectExec(deleteQuery).WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+
+				err := repository.DeslikePost(context.Background(), 1, 2)
+				assert.NoError(t, err)
+			} else {
+				mock.ExpectBegin()
+				mock.ExpectExec(deleteQuery).WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(updateQuery).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+
+				err := repository.DeslikePost(context.Background(), 1, 2)
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+
+}
+
+func TestFindPostLikes(t *testing.T) {
+	userJson, _ := ioutil.ReadFile("../test/resource/json/created_user.json")
+
+	var user model.User
+	json.Unmarshal(userJson, &user)
+
+	db, mock := mock.NewDatabaseConnection()
+	defer db.Close()
+
 	subTests := []struct {
 		name           string
-		errorInPrepare bool
 		errorInExec    bool
+		errorInScanRow bool
 		err            error
 	}{
 		{
-			name: "Like post",
+			name: "Find post likes",
 		},
 		{
-			name:           "Like post - error in prepare",
-			errorInPrepare: true,
+			name:        "Find post likes - error in exec query",
+			errorInExec: true,
+			err:         errors.New("some error"),
+		},
+		{
+			name:           "Find post likes - error in scan row",
+			errorInScanRow: true,
 			err:            errors.New("some error"),
 		},
+	}
+
+	repository := repository.NewPostRepository(db)
+
+	page := model.PageRequest{Limit: 20}
+
+	query := "select u.id, u.name, u.nick, u.email, u.created_at, u.version, u.updated_at from users u join post_likes pl on u.id = pl.user_id where pl.post_id = \\? and u.id > \\? order by u.id limit \\?"
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+
+			if subTest.errorInExec {
+				mock.ExpectQuery(query).WillReturnError(subTest.err)
+
+				_, err := repository.FindLikes(context.Background(), 1, page)
+				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.errorInScanRow {
+				rows := sqlmock.NewRows([]string{"id"}).
+					AddRow(-1)
+
+				mock.ExpectQuery(query).WithArgs(1, page.Cursor, page.Limit+1).WillReturnRows(rows)
+
+				_, err := repository.FindLikes(context.Background(), 1, page)
+				assert.Error(t, err)
+			} else {
+				rows := sqlmock.NewRows([]string{"id", "name", "nick", "email", "created_at", "version", "updated_at"}).
+					AddRow(user.ID, user.Name, user.Nick, user.Email, user.CreatedAt, user.Version, user.UpdatedAt)
+
+				mock.ExpectQuery(query).WithArgs(1, page.Cursor, page.Limit+1).WillReturnRows(rows)
+
+				userPage, _ := repository.FindLikes(context.Background(), 1, page)
+				assert.Equal(t, model.UserPage{Data: []model.User{user}}, userPage)
+			}
+		})
+	}
+}
+
+func TestPostThread(t *testing.T) {
+	threadJson, _ := ioutil.ReadFile("../test/resource/json/stored_thread.json")
+
+	var thread []model.Post
+	json.Unmarshal(threadJson, &thread)
+
+	root, reply := thread[0], thread[1]
+
+	db, mock := mock.NewDatabaseConnection()
+	defer db.Close()
+
+	subTests := []struct {
+		name           string
+		notFound       bool
+		errorInExec    bool
+		errorInScanRow bool
+		err            error
+	}{
+		{
+			name: "Thread",
+		},
 		{
-			name:        "Like post - error in exec",
+			name:     "Thread - not found",
+			notFound: true,
+		},
+		{
+			name:        "Thread - error in exec query",
 			errorInExec: true,
 			err:         errors.New("some error"),
 		},
+		{
+			name:           "Thread - error in scan row",
+			errorInScanRow: true,
+			err:            errors.New("some error"),
+		},
 	}
 
 	repository := repository.NewPostRepository(db)
 
-	query := "update posts set likes = likes \\+ 1 where id = \\?"
+	query := "with recursive thread \\(id, depth\\) as \\( select id, 0 from posts where id = \\? union all select r.id, t.depth \\+ 1 from posts r join thread t on r.parent_post_id = t.id where t.depth < \\? \\) select p.id, p.title, p.content, p.author_id, p.likes, p.created_at, p.parent_post_id, p.repost_of_id, p.version, p.updated_at, u.nick, exists\\(select 1 from post_likes pl where pl.post_id = p.id and pl.user_id = \\?\\), \\(select count\\(\\*\\) from comments c where c.post_id = p.id\\), \\(select count\\(\\*\\) from posts r where r.parent_post_id = p.id\\), \\(select count\\(\\*\\) from posts r where r.repost_of_id = p.id\\) from posts p join thread t on p.id = t.id join users u on p.author_id = u.id order by p.id"
+
+	columns := []string{"id", "title", "content", "author_id", "likes", "created_at", "parent_post_id", "repost_of_id", "version", "updated_at", "nick", "liked_by_me", "comment_count", "reply_count", "repost_count"}
 
 	for _, subTest := range subTests {
 		t.Run(subTest.name, func(t *testing.T) {
 
-			if subTest.errorInPrepare {
-				mock.ExpectPrepare(query).WillReturnError(subTest.err)
+			if subTest.notFound {
+				mock.ExpectQuery(query).WithArgs(root.ID, config.MaxThreadDepth, root.AuthorID).WillReturnRows(sqlmock.NewRows(columns))
 
-				err := repository.LikePost(1)
-				assert.ErrorIs(t, err, subTest.err)
+				_, err := repository.Thread(context.Background(), root.ID, root.AuthorID)
+				assert.True(t, model.IsNotFound(err), "Error should be a not found error")
 			} else if subTest.errorInExec {
-				prep := mock.ExpectPrepare(query)
-				prep.ExpectExec().WithArgs(1).WillReturnError(subTest.err)
+				mock.ExpectQuery(query).WillReturnError(subTest.err)
 
-				err := repository.LikePost(1)
+				_, err := repository.Thread(context.Background(), root.ID, root.AuthorID)
 				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.errorInScanRow {
+				rows := sqlmock.NewRows([]string{"id"}).
+					AddRow(-1)
+
+				mock.ExpectQuery(query).WithArgs(root.ID, config.MaxThreadDepth, root.AuthorID).WillReturnRows(rows)
+
+				_, err := repository.Thread(context.Background(), root.ID, root.AuthorID)
+				assert.Error(t, err)
 			} else {
-				prep := mock.ExpectPrepare(query)
-				prep.ExpectExec().WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
+				rows := sqlmock.NewRows(columns).
+					AddRow(root.ID, root.Title, root.Content, root.AuthorID, root.Likes, root.CreatedAt, root.ParentPostID, root.RepostOfID, root.Version, root.UpdatedAt, root.AuthorNick, root.LikedByMe, root.CommentCount, root.ReplyCount, root.RepostCount).
+					AddRow(reply.ID, reply.Title, reply.Content, reply.AuthorID, reply.Likes, reply.CreatedAt, reply.ParentPostID, reply.RepostOfID, reply.Version, reply.UpdatedAt, reply.AuthorNick, reply.LikedByMe, reply.CommentCount, reply.ReplyCount, reply.RepostCount)
 
-				err := repository.LikePost(1)
-				assert.NoError(t, err)
+				mock.ExpectQuery(query).WithArgs(root.ID, config.MaxThreadDepth, root.AuthorID).WillReturnRows(rows)
+
+				posts, _ := repository.Thread(context.Background(), root.ID, root.AuthorID)
+				assert.Equal(t, thread, posts)
 			}
 		})
 	}
 }
 
-func TestDesikePost(t *testing.T) {
+func TestSearchPosts(t *testing.T) {
+	postJson, _ := ioutil.ReadFile("../test/resource/json/created_post.json")
+
+	var post model.Post
+	json.Unmarshal(postJson, &post)
+
 	db, mock := mock.NewDatabaseConnection()
 	defer db.Close()
 
 	subTests := []struct {
 		name           string
-		errorInPrepare bool
+		hasNextPage    bool
 		errorInExec    bool
+		errorInScanRow bool
 		err            error
 	}{
 		{
-			name:           "Deslike post",
-			errorInPrepare: false,
-			errorInExec:    false,
+			name: "Search",
 		},
 		{
-			name:           "Deslike post - error in prepare",
-			errorInPrepare: true,
-			errorInExec:    false,
+			name:        "Search - with next page",
+			hasNextPage: true,
+		},
+		{
+			name:        "Search - error in exec query",
+			errorInExec: true,
+			err:         errors.New("some error"),
+		},
+		{
+			name:           "Search - error in scan row",
+			errorInScanRow: true,
 			err:            errors.New("some error"),
 		},
+	}
+
+	repository := repository.NewPostRepository(db)
+
+	page := model.SearchPageRequest{Limit: 1, After: &model.SearchCursor{Score: 0.6, ID: 2}}
+
+	search := model.PostSearch{Query: "Publicação", AuthorID: &post.AuthorID}
+
+	query := "select p.id, p.title, p.content, p.author_id, p.likes, p.created_at, p.parent_post_id, p.repost_of_id, p.version, p.updated_at, u.nick, exists\\(select 1 from post_likes pl where pl.post_id = p.id and pl.user_id = \\?\\), \\(select count\\(\\*\\) from comments c where c.post_id = p.id\\), \\(select count\\(\\*\\) from posts r where r.parent_post_id = p.id\\), \\(select count\\(\\*\\) from posts r where r.repost_of_id = p.id\\), match\\(p.title, p.content\\) against \\(\\? in natural language mode\\) as score from posts p join users u on p.author_id = u.id where match\\(p.title, p.content\\) against \\(\\? in natural language mode\\) and \\(\\? is null or p.author_id = \\?\\) and \\(\\? is null or p.created_at >= \\?\\) and \\(\\? is null or p.created_at < \\?\\) and \\(\\? is null or \\(match\\(p.title, p.content\\) against \\(\\? in natural language mode\\), p.id\\) < \\(\\?, \\?\\)\\) order by score desc, p.id desc limit \\?"
+
+	columns := []string{"id", "title", "content", "author_id", "likes", "created_at", "parent_post_id", "repost_of_id", "version", "updated_at", "nick", "liked_by_me", "comment_count", "reply_count", "repost_count", "score"}
+
+	expectedResult := model.PostSearchResult{Post: post, Score: 0.5, Snippet: "Essa é a <mark>publicação</mark> do Usuário 1! Oba!"}
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+
+			if subTest.errorInExec {
+				mock.ExpectQuery(query).WillReturnError(subTest.err)
+
+				_, err := repository.Search(context.Background(), search, post.AuthorID, page)
+				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.errorInScanRow {
+				rows := sqlmock.NewRows([]string{"id"}).
+					AddRow(-1)
+
+				mock.ExpectQuery(query).WillReturnRows(rows)
+
+				_, err := repository.Search(context.Background(), search, post.AuthorID, page)
+				assert.Error(t, err)
+			} else if subTest.hasNextPage {
+				rows := sqlmock.NewRows(columns).
+					AddRow(post.ID, post.Title, post.Content, post.AuthorID, post.Likes, post.CreatedAt, nil, nil, post.Version, post.UpdatedAt, post.AuthorNick, post.LikedByMe, post.CommentCount, post.ReplyCount, post.RepostCount, 0.5).
+					AddRow(post.ID+1, post.Title, post.Content, post.AuthorID, post.Likes, post.CreatedAt, nil, nil, post.Version, post.UpdatedAt, post.AuthorNick, post.LikedByMe, post.CommentCount, post.ReplyCount, post.RepostCount, 0.4)
+
+				mock.ExpectQuery(query).WithArgs(post.AuthorID, search.Query, search.Query, post.AuthorID, post.AuthorID, nil, nil, nil, nil, page.After.Score, search.Query, page.After.Score, page.After.ID, page.Limit+1).WillReturnRows(rows)
+
+				searchPage, _ := repository.Search(context.Background(), search, post.AuthorID, page)
+				assert.Equal(t, model.PostSearchPage{Data: []model.PostSearchResult{expectedResult}, NextCursor: model.EncodeSearchCursor(0.5, post.ID)}, searchPage)
+			} else {
+				rows := sqlmock.NewRows(columns).
+					AddRow(post.ID, post.Title, post.Content, post.AuthorID, post.Likes, post.CreatedAt, nil, nil, post.Version, post.UpdatedAt, post.AuthorNick, post.LikedByMe, post.CommentCount, post.ReplyCount, post.RepostCount, 0.5)
+
+				mock.ExpectQuery(query).WithArgs(post.AuthorID, search.Query, search.Query, post.AuthorID, post.AuthorID, nil, nil, nil, nil, page.After.Score, search.Query, page.After.Score, page.After.ID, page.Limit+1).WillReturnRows(rows)
+
+				searchPage, _ := repository.Search(context.Background(), search, post.AuthorID, page)
+				assert.Equal(t, model.PostSearchPage{Data: []model.PostSearchResult{expectedResult}}, searchPage)
+			}
+		})
+	}
+}
+
+func TestFindPostsByTag(t *testing.T) {
+	postJson, _ := ioutil.ReadFile("../test/resource/json/created_post.json")
+
+	var post model.Post
+	json.Unmarshal(postJson, &post)
+
+	db, mock := mock.NewDatabaseConnection()
+	defer db.Close()
+
+	subTests := []struct {
+		name           string
+		errorInExec    bool
+		errorInScanRow bool
+		err            error
+	}{
+		{
+			name: "Find by tag",
+		},
 		{
-			name:           "Deslike post - error in exec",
-			errorInPrepare: false,
-			errorInExec:    true,
+			name:        "Find by tag - error in exec query",
+			errorInExec: true,
+			err:         errors.New("some error"),
+		},
+		{
+			name:           "Find by tag - error in scan row",
+			errorInScanRow: true,
 			err:            errors.New("some error"),
 		},
 	}
 
 	repository := repository.NewPostRepository(db)
 
-	query := "update posts set likes = case when likes \\> 0 then likes \\- 1 else 0 end where id = \\?"
+	page := model.PageRequest{Limit: 1}
+
+	query := "select p.id, p.title, p.content, p.author_id, p.likes, p.created_at, p.parent_post_id, p.repost_of_id, p.version, p.updated_at, u.nick, exists\\(select 1 from post_likes pl where pl.post_id = p.id and pl.user_id = \\?\\), \\(select count\\(\\*\\) from comments c where c.post_id = p.id\\), \\(select count\\(\\*\\) from posts r where r.parent_post_id = p.id\\), \\(select count\\(\\*\\) from posts r where r.repost_of_id = p.id\\), o.title, o.content, o.author_id, ou.nick, o.created_at from posts p join users u on p.author_id = u.id left join posts o on p.repost_of_id = o.id left join users ou on o.author_id = ou.id join post_tags pt on p.id = pt.post_id where pt.tag = \\? and p.id < \\? order by p.id desc limit \\?"
 
 	for _, subTest := range subTests {
 		t.Run(subTest.name, func(t *testing.T) {
 
-			if subTest.errorInPrepare {
-				mock.ExpectPrepare(query).WillReturnError(subTest.err)
+			if subTest.errorInExec {
+				mock.ExpectQuery(query).WillReturnError(subTest.err)
 
-				err := repository.DeslikePost(1)
+				_, err := repository.FindByTag(context.Background(), "golang", post.AuthorID, page)
 				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.errorInScanRow {
+				rows := sqlmock.NewRows([]string{"id"}).
+					AddRow(-1)
+
+				mock.ExpectQuery(query).WithArgs(post.AuthorID, "golang", math.MaxInt64, page.Limit+1).WillReturnRows(rows)
+
+				_, err := repository.FindByTag(context.Background(), "golang", post.AuthorID, page)
+				assert.Error(t, err)
+			} else {
+				rows := sqlmock.NewRows([]string{"id", "title", "content", "author_id", "likes", "created_at", "parent_post_id", "repost_of_id", "version", "updated_at", "nick", "liked_by_me", "comment_count", "reply_count", "repost_count", "original_title", "original_content", "original_author_id", "original_nick", "original_created_at"}).
+					AddRow(post.ID, post.Title, post.Content, post.AuthorID, post.Likes, post.CreatedAt, post.ParentPostID, post.RepostOfID, post.Version, post.UpdatedAt, post.AuthorNick, post.LikedByMe, post.CommentCount, post.ReplyCount, post.RepostCount, nil, nil, nil, nil, nil)
+
+				mock.ExpectQuery(query).WithArgs(post.AuthorID, "golang", math.MaxInt64, page.Limit+1).WillReturnRows(rows)
+
+				postPage, _ := repository.FindByTag(context.Background(), "golang", post.AuthorID, page)
+				assert.Equal(t, model.PostPage{Data: []model.Post{post}}, postPage)
+			}
+		})
+	}
+}
+
+func TestFindPostsByMention(t *testing.T) {
+	postJson, _ := ioutil.ReadFile("../test/resource/json/created_post.json")
+
+	var post model.Post
+	json.Unmarshal(postJson, &post)
+
+	db, mock := mock.NewDatabaseConnection()
+	defer db.Close()
+
+	subTests := []struct {
+		name           string
+		userNotFound   bool
+		errorInExec    bool
+		errorInScanRow bool
+		err            error
+	}{
+		{
+			name: "Find by mention",
+		},
+		{
+			name:         "Find by mention - user not found",
+			userNotFound: true,
+		},
+		{
+			name:        "Find by mention - error in exec query",
+			errorInExec: true,
+			err:         errors.New("some error"),
+		},
+		{
+			name:           "Find by mention - error in scan row",
+			errorInScanRow: true,
+			err:            errors.New("some error"),
+		},
+	}
+
+	repository := repository.NewPostRepository(db)
+
+	page := model.PageRequest{Limit: 1}
+
+	mentionedID := uint64(2)
+
+	query := "select p.id, p.title, p.content, p.author_id, p.likes, p.created_at, p.parent_post_id, p.repost_of_id, p.version, p.updated_at, u.nick, exists\\(select 1 from post_likes pl where pl.post_id = p.id and pl.user_id = \\?\\), \\(select count\\(\\*\\) from comments c where c.post_id = p.id\\), \\(select count\\(\\*\\) from posts r where r.parent_post_id = p.id\\), \\(select count\\(\\*\\) from posts r where r.repost_of_id = p.id\\), o.title, o.content, o.author_id, ou.nick, o.created_at from posts p join users u on p.author_id = u.id left join posts o on p.repost_of_id = o.id left join users ou on o.author_id = ou.id join post_mentions pm on p.id = pm.post_id where pm.user_id = \\? and p.id < \\? order by p.id desc limit \\?"
+
+	existsQuery := "select exists\\(select 1 from users where id = \\?\\)"
+
+	columns := []string{"id", "title", "content", "author_id", "likes", "created_at", "parent_post_id", "repost_of_id", "version", "updated_at", "nick", "liked_by_me", "comment_count", "reply_count", "repost_count", "original_title", "original_content", "original_author_id", "original_nick", "original_created_at"}
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+
+			if subTest.userNotFound {
+				mock.ExpectQuery(query).WithArgs(post.AuthorID, mentionedID, math.MaxInt64, page.Limit+1).WillReturnRows(sqlmock.NewRows(columns))
+				mock.ExpectQuery(existsQuery).WithArgs(mentionedID).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+				_, err := repository.FindByMention(context.Background(), mentionedID, post.AuthorID, page)
+				assert.True(t, model.IsNotFound(err), "Error should be a not found error")
 			} else if subTest.errorInExec {
-				prep := mock.ExpectPrepare(query)
-				prep.ExpectExec().WithArgs(1).WillReturnError(subTest.err)
+				mock.ExpectQuery(query).WillReturnError(subTest.err)
 
-				err := repository.DeslikePost(1)
+				_, err := repository.FindByMention(context.Background(), mentionedID, post.AuthorID, page)
 				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.errorInScanRow {
+				rows := sqlmock.NewRows([]string{"id"}).
+					AddRow(-1)
+
+				mock.ExpectQuery(query).WithArgs(post.AuthorID, mentionedID, math.MaxInt64, page.Limit+1).WillReturnRows(rows)
+
+				_, err := repository.FindByMention(context.Background(), mentionedID, post.AuthorID, page)
+				assert.Error(t, err)
 			} else {
-				prep := mock.ExpectPrepare(query)
-				prep.ExpectExec().WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
+				rows := sqlmock.NewRows(columns).
+					AddRow(post.ID, post.Title, post.Content, post.AuthorID, post.Likes, post.CreatedAt, post.ParentPostID, post.RepostOfID, post.Version, post.UpdatedAt, post.AuthorNick, post.LikedByMe, post.CommentCount, post.ReplyCount, post.RepostCount, nil, nil, nil, nil, nil)
 
-				err := repository.DeslikePost(1)
-				assert.NoError(t, err)
+				mock.ExpectQuery(query).WithArgs(post.AuthorID, mentionedID, math.MaxInt64, page.Limit+1).WillReturnRows(rows)
+
+				postPage, _ := repository.FindByMention(context.Background(), mentionedID, post.AuthorID, page)
+				assert.Equal(t, model.PostPage{Data: []model.Post{post}}, postPage)
 			}
 		})
 	}
+}
 
+func TestTrendingTags(t *testing.T) {
+	db, mock := mock.NewDatabaseConnection()
+	defer db.Close()
+
+	subTests := []struct {
+		name           string
+		errorInExec    bool
+		errorInScanRow bool
+		errorInRows    bool
+		err            error
+	}{
+		{
+			name: "Trending tags",
+		},
+		{
+			name:        "Trending tags - error in exec query",
+			errorInExec: true,
+			err:         errors.New("some error"),
+		},
+		{
+			name:           "Trending tags - error in scan row",
+			errorInScanRow: true,
+			err:            errors.New("some error"),
+		},
+		{
+			name:        "Trending tags - error in reading rows",
+			errorInRows: true,
+			err:         errors.New("some error"),
+		},
+	}
+
+	repository := repository.NewPostRepository(db)
+
+	since := time.Date(2021, 4, 6, 0, 0, 0, 0, time.UTC)
+
+	query := "select pt.tag, count\\(\\*\\) as uses from post_tags pt join posts p on pt.post_id = p.id where p.created_at >= \\? group by pt.tag order by uses desc, pt.tag limit \\?"
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+
+			if subTest.errorInExec {
+				mock.ExpectQuery(query).WillReturnError(subTest.err)
+
+				_, err := repository.TrendingTags(context.Background(), since, 10)
+				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.errorInScanRow {
+				rows := sqlmock.NewRows([]string{"tag", "uses"}).
+					AddRow("golang", -1)
+
+				mock.ExpectQuery(query).WithArgs(since, 10).WillReturnRows(rows)
+
+				_, err := repository.TrendingTags(context.Background(), since, 10)
+				assert.Error(t, err)
+			} else if subTest.errorInRows {
+				rows := sqlmock.NewRows([]string{"tag", "uses"}).
+					AddRow("golang", 2).
+					AddRow("devbook", 1).
+					RowError(1, subTest.err)
+
+				mock.ExpectQuery(query).WithArgs(since, 10).WillReturnRows(rows)
+
+				_, err := repository.TrendingTags(context.Background(), since, 10)
+				assert.ErrorIs(t, err, subTest.err)
+			} else {
+				rows := sqlmock.NewRows([]string{"tag", "uses"}).
+					AddRow("golang", 2).
+					AddRow("devbook", 1)
+
+				mock.ExpectQuery(query).WithArgs(since, 10).WillReturnRows(rows)
+
+				tags, _ := repository.TrendingTags(context.Background(), since, 10)
+				assert.Equal(t, []model.TrendingTag{{Tag: "golang", Posts: 2}, {Tag: "devbook", Posts: 1}}, tags)
+			}
+		})
+	}
 }
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/waliqueiroz/devbook-api/model"
+)
+
+type SessionRepository struct {
+	db *sql.DB
+}
+
+// NewSessionRepository creates a new session repository
+func NewSessionRepository(db *sql.DB) *SessionRepository {
+	return &SessionRepository{db}
+}
+
+// Create inserts a session into database and returns its ID
+func (repository SessionRepository) Create(ctx context.Context, session model.Session) (uint64, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, err := repository.db.PrepareContext(ctx, "insert into sessions (user_id, refresh_token_hash, expires_at) values (?, ?, ?)")
+	if err != nil {
+		return 0, err
+	}
+	defer statement.Close()
+
+	result, err := statement.ExecContext(ctx, session.UserID, session.RefreshTokenHash, session.ExpiresAt)
+	if err != nil {
+		return 0, err
+	}
+
+	lastInsertID, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(lastInsertID), nil
+}
+
+// FindByRefreshToken returns the active session that owns a given refresh token hash or a not found error if there is none
+func (repository SessionRepository) FindByRefreshToken(ctx context.Context, refreshTokenHash string) (model.Session, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := repository.db.QueryContext(ctx, `select id, user_id, refresh_token_hash, expires_at from sessions
+									where refresh_token_hash = ? and revoked_at is null and expires_at > now()`, refreshTokenHash)
+	if err != nil {
+		return model.Session{}, err
+	}
+
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return model.Session{}, err
+		}
+
+		return model.Session{}, model.NewNotFoundError("session")
+	}
+
+	var session model.Session
+
+	err = rows.Scan(&session.ID, &session.UserID, &session.RefreshTokenHash, &session.ExpiresAt)
+	if err != nil {
+		return model.Session{}, err
+	}
+
+	return session, nil
+}
+
+// Rotate replaces the refresh token of a session. It returns a not found error if the current token was already rotated or revoked
+func (repository SessionRepository) Rotate(ctx context.Context, sessionID uint64, currentHash, newHash string, expiresAt time.Time) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, err := repository.db.PrepareContext(ctx, `update sessions set refresh_token_hash = ?, expires_at = ?
+											where id = ? and refresh_token_hash = ? and revoked_at is null`)
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	result, err := statement.ExecContext(ctx, newHash, expiresAt, sessionID, currentHash)
+	if err != nil {
+		return err
+	}
+
+	affectedRows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affectedRows == 0 {
+		return model.NewNotFoundError("session")
+	}
+
+	return nil
+}
+
+// Revoke revokes a session
+func (repository SessionRepository) Revoke(ctx context.Context, sessionID uint64) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, err := repository.db.PrepareContext(ctx, "update sessions set revoked_at = now() where id = ? and revoked_at is null")
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	_, err = statement.ExecContext(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RevokeAllByUser revokes every session of a given user
+func (repository SessionRepository) RevokeAllByUser(ctx context.Context, userID uint64) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, err := repository.db.PrepareContext(ctx, "update sessions set revoked_at = now() where user_id = ? and revoked_at is null")
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	_, err = statement.ExecContext(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// IsActive checks if a session was neither revoked nor expired
+func (repository SessionRepository) IsActive(ctx context.Context, sessionID uint64) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	var active bool
+
+	err := repository.db.QueryRowContext(ctx, "select exists(select 1 from sessions where id = ? and revoked_at is null and expires_at > now())", sessionID).Scan(&active)
+	if err != nil {
+		return false, err
+	}
+
+	return active, nil
+}
@@ -0,0 +1,391 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/waliqueiroz/devbook-api/model"
+	"github.com/waliqueiroz/devbook-api/repository"
+	"github.com/waliqueiroz/devbook-api/test/mock"
+)
+
+func newSession() model.Session {
+	return model.Session{
+		ID:               1,
+		UserID:           1,
+		RefreshTokenHash: "d4b1d1a8f5c7b0a5e0e0b5c5f0e4e1c6c0f6e4f3b2c3d3e0a1b4c3d2e1f0a9b8",
+		ExpiresAt:        time.Date(2021, 4, 6, 13, 34, 50, 0, time.UTC),
+	}
+}
+
+func TestCreateSession(t *testing.T) {
+	session := newSession()
+
+	db, mock := mock.NewDatabaseConnection()
+	defer db.Close()
+
+	subTests := []struct {
+		name           string
+		errorInPrepare bool
+		errorInExec    bool
+		errorInResult  bool
+		err            error
+	}{
+		{
+			name: "Create session",
+		},
+		{
+			name:           "Create session - error in prepare",
+			errorInPrepare: true,
+			err:            errors.New("some error"),
+		},
+		{
+			name:        "Create session - error in exec",
+			errorInExec: true,
+			err:         errors.New("some error"),
+		},
+		{
+			name:          "Create session - error in result",
+			errorInResult: true,
+			err:           errors.New("some error"),
+		},
+	}
+
+	repository := repository.NewSessionRepository(db)
+
+	query := "insert into sessions \\(user_id, refresh_token_hash, expires_at\\) values \\(\\?, \\?, \\?\\)"
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+
+			if subTest.errorInPrepare {
+				mock.ExpectPrepare(query).WillReturnError(subTest.err)
+
+				_, err := repository.Create(context.Background(), session)
+				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.errorInExec {
+				prep := mock.ExpectPrepare(query)
+				prep.ExpectExec().WithArgs(session.UserID, session.RefreshTokenHash, session.ExpiresAt).WillReturnError(subTest.err)
+
+				_, err := repository.Create(context.Background(), session)
+				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.errorInResult {
+				prep := mock.ExpectPrepare(query)
+				prep.ExpectExec().WithArgs(session.UserID, session.RefreshTokenHash, session.ExpiresAt).WillReturnResult(sqlmock.NewErrorResult(subTest.err))
+
+				_, err := repository.Create(context.Background(), session)
+				assert.ErrorIs(t, err, subTest.err)
+			} else {
+				prep := mock.ExpectPrepare(query)
+				prep.ExpectExec().WithArgs(session.UserID, session.RefreshTokenHash, session.ExpiresAt).WillReturnResult(sqlmock.NewResult(1, 1))
+
+				sessionID, err := repository.Create(context.Background(), session)
+				assert.NoError(t, err)
+				assert.Equal(t, session.ID, sessionID)
+			}
+		})
+	}
+}
+
+func TestFindSessionByRefreshToken(t *testing.T) {
+	session := newSession()
+
+	db, mock := mock.NewDatabaseConnection()
+	defer db.Close()
+
+	subTests := []struct {
+		name           string
+		notFound       bool
+		errorInExec    bool
+		errorInScanRow bool
+		err            error
+	}{
+		{
+			name: "Find by refresh token",
+		},
+		{
+			name:     "Find by refresh token - not found",
+			notFound: true,
+		},
+		{
+			name:        "Find by refresh token - error in exec query",
+			errorInExec: true,
+			err:         errors.New("some error"),
+		},
+		{
+			name:           "Find by refresh token - error in scan row",
+			errorInScanRow: true,
+			err:            errors.New("some error"),
+		},
+	}
+
+	repository := repository.NewSessionRepository(db)
+
+	query := "select id, user_id, refresh_token_hash, expires_at from sessions where refresh_token_hash = \\? and revoked_at is null and expires_at > now\\(\\)"
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+
+			if subTest.errorInExec {
+				mock.ExpectQuery(query).WillReturnError(subTest.err)
+
+				_, err := repository.FindByRefreshToken(context.Background(), session.RefreshTokenHash)
+				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.notFound {
+				rows := sqlmock.NewRows([]string{"id", "user_id", "refresh_token_hash", "expires_at"})
+
+				mock.ExpectQuery(query).WithArgs(session.RefreshTokenHash).WillReturnRows(rows)
+
+				_, err := repository.FindByRefreshToken(context.Background(), session.RefreshTokenHash)
+				assert.True(t, model.IsNotFound(err), "Error should be a not found error")
+			} else if subTest.errorInScanRow {
+				rows := sqlmock.NewRows([]string{"id"}).
+					AddRow(-1)
+
+				mock.ExpectQuery(query).WithArgs(session.RefreshTokenHash).WillReturnRows(rows)
+
+				_, err := repository.FindByRefreshToken(context.Background(), session.RefreshTokenHash)
+				assert.Error(t, err)
+			} else {
+				rows := sqlmock.NewRows([]string{"id", "user_id", "refresh_token_hash", "expires_at"}).
+					AddRow(session.ID, session.UserID, session.RefreshTokenHash, session.ExpiresAt)
+
+				mock.ExpectQuery(query).WithArgs(session.RefreshTokenHash).WillReturnRows(rows)
+
+				storedSession, _ := repository.FindByRefreshToken(context.Background(), session.RefreshTokenHash)
+				assert.Equal(t, session, storedSession)
+			}
+		})
+	}
+}
+
+func TestRotateSession(t *testing.T) {
+	session := newSession()
+	newHash := "a9b8d4b1d1a8f5c7b0a5e0e0b5c5f0e4e1c6c0f6e4f3b2c3d3e0a1b4c3d2e1f0"
+
+	db, mock := mock.NewDatabaseConnection()
+	defer db.Close()
+
+	subTests := []struct {
+		name           string
+		alreadyRotated bool
+		errorInPrepare bool
+		errorInExec    bool
+		err            error
+	}{
+		{
+			name: "Rotate session",
+		},
+		{
+			name:           "Rotate session - already rotated",
+			alreadyRotated: true,
+		},
+		{
+			name:           "Rotate session - error in prepare",
+			errorInPrepare: true,
+			err:            errors.New("some error"),
+		},
+		{
+			name:        "Rotate session - error in exec",
+			errorInExec: true,
+			err:         errors.New("some error"),
+		},
+	}
+
+	repository := repository.NewSessionRepository(db)
+
+	query := "update sessions set refresh_token_hash = \\?, expires_at = \\? where id = \\? and refresh_token_hash = \\? and revoked_at is null"
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+
+			if subTest.errorInPrepare {
+				mock.ExpectPrepare(query).WillReturnError(subTest.err)
+
+				err := repository.Rotate(context.Background(), session.ID, session.RefreshTokenHash, newHash, session.ExpiresAt)
+				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.errorInExec {
+				prep := mock.ExpectPrepare(query)
+				prep.ExpectExec().WithArgs(newHash, session.ExpiresAt, session.ID, session.RefreshTokenHash).WillReturnError(subTest.err)
+
+				err := repository.Rotate(context.Background(), session.ID, session.RefreshTokenHash, newHash, session.ExpiresAt)
+				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.alreadyRotated {
+				prep := mock.ExpectPrepare(query)
+				prep.ExpectExec().WithArgs(newHash, session.ExpiresAt, session.ID, session.RefreshTokenHash).WillReturnResult(sqlmock.NewResult(0, 0))
+
+				err := repository.Rotate(context.Background(), session.ID, session.RefreshTokenHash, newHash, session.ExpiresAt)
+				assert.True(t, model.IsNotFound(err), "Error should be a not found error")
+			} else {
+				prep := mock.ExpectPrepare(query)
+				prep.ExpectExec().WithArgs(newHash, session.ExpiresAt, session.ID, session.RefreshTokenHash).WillReturnResult(sqlmock.NewResult(0, 1))
+
+				err := repository.Rotate(context.Background(), session.ID, session.RefreshTokenHash, newHash, session.ExpiresAt)
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	db, mock := mock.NewDatabaseConnection()
+	defer db.Close()
+
+	subTests := []struct {
+		name           string
+		errorInPrepare bool
+		errorInExec    bool
+		err            error
+	}{
+		{
+			name: "Revoke session",
+		},
+		{
+			name:           "Revoke session - error in prepare",
+			errorInPrepare: true,
+			err:            errors.New("some error"),
+		},
+		{
+			name:        "Revoke session - error in exec",
+			errorInExec: true,
+			err:         errors.New("some error"),
+		},
+	}
+
+	repository := repository.NewSessionRepository(db)
+
+	query := "update sessions set revoked_at = now\\(\\) where id = \\? and revoked_at is null"
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+
+			if subTest.errorInPrepare {
+				mock.ExpectPrepare(query).WillReturnError(subTest.err)
+
+				err := repository.Revoke(context.Background(), 1)
+				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.errorInExec {
+				prep := mock.ExpectPrepare(query)
+				prep.ExpectExec().WithArgs(1).WillReturnError(subTest.err)
+
+				err := repository.Revoke(context.Background(), 1)
+				assert.ErrorIs(t, err, subTest.err)
+			} else {
+				prep := mock.ExpectPrepare(query)
+				prep.ExpectExec().WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+
+				err := repository.Revoke(context.Background(), 1)
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRevokeAllSessionsByUser(t *testing.T) {
+	db, mock := mock.NewDatabaseConnection()
+	defer db.Close()
+
+	subTests := []struct {
+		name           string
+		errorInPrepare bool
+		errorInExec    bool
+		err            error
+	}{
+		{
+			name: "Revoke all sessions",
+		},
+		{
+			name:           "Revoke all sessions - error in prepare",
+			errorInPrepare: true,
+			err:            errors.New("some error"),
+		},
+		{
+			name:        "Revoke all sessions - error in exec",
+			errorInExec: true,
+			err:         errors.New("some error"),
+		},
+	}
+
+	repository := repository.NewSessionRepository(db)
+
+	query := "update sessions set revoked_at = now\\(\\) where user_id = \\? and revoked_at is null"
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+
+			if subTest.errorInPrepare {
+				mock.ExpectPrepare(query).WillReturnError(subTest.err)
+
+				err := repository.RevokeAllByUser(context.Background(), 1)
+				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.errorInExec {
+				prep := mock.ExpectPrepare(query)
+				prep.ExpectExec().WithArgs(1).WillReturnError(subTest.err)
+
+				err := repository.RevokeAllByUser(context.Background(), 1)
+				assert.ErrorIs(t, err, subTest.err)
+			} else {
+				prep := mock.ExpectPrepare(query)
+				prep.ExpectExec().WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 3))
+
+				err := repository.RevokeAllByUser(context.Background(), 1)
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestIsSessionActive(t *testing.T) {
+	db, mock := mock.NewDatabaseConnection()
+	defer db.Close()
+
+	subTests := []struct {
+		name        string
+		active      bool
+		errorInExec bool
+		err         error
+	}{
+		{
+			name:   "Active session",
+			active: true,
+		},
+		{
+			name:   "Revoked session",
+			active: false,
+		},
+		{
+			name:        "Is active - error in exec query",
+			errorInExec: true,
+			err:         errors.New("some error"),
+		},
+	}
+
+	repository := repository.NewSessionRepository(db)
+
+	query := "select exists\\(select 1 from sessions where id = \\? and revoked_at is null and expires_at > now\\(\\)\\)"
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+
+			if subTest.errorInExec {
+				mock.ExpectQuery(query).WillReturnError(subTest.err)
+
+				_, err := repository.IsActive(context.Background(), 1)
+				assert.ErrorIs(t, err, subTest.err)
+			} else {
+				rows := sqlmock.NewRows([]string{"active"}).
+					AddRow(subTest.active)
+
+				mock.ExpectQuery(query).WithArgs(1).WillReturnRows(rows)
+
+				active, err := repository.IsActive(context.Background(), 1)
+				assert.NoError(t, err)
+				assert.Equal(t, subTest.active, active)
+			}
+		})
+	}
+}
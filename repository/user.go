@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
@@ -17,16 +18,19 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 }
 
 // Create inserts a user into database
-func (repository UserRepository) Create(user model.User) (model.User, error) {
-	statement, err := repository.db.Prepare("insert into users (name, nick, email, password) values (?, ?, ?, ?)")
+func (repository UserRepository) Create(ctx context.Context, user model.User) (model.User, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, err := repository.db.PrepareContext(ctx, "insert into users (name, nick, email, password) values (?, ?, ?, ?)")
 	if err != nil {
 		return model.User{}, err
 	}
 	defer statement.Close()
 
-	result, err := statement.Exec(user.Name, user.Nick, user.Email, user.Password)
+	result, err := statement.ExecContext(ctx, user.Name, user.Nick, user.Email, user.Password)
 	if err != nil {
-		return model.User{}, err
+		return model.User{}, userConflictError(err)
 	}
 
 	lastInsertID, err := result.LastInsertId()
@@ -34,7 +38,7 @@ func (repository UserRepository) Create(user model.User) (model.User, error) {
 		return model.User{}, err
 	}
 
-	newUser, err := repository.FindByID(uint64(lastInsertID))
+	newUser, err := repository.FindByID(ctx, uint64(lastInsertID))
 	if err != nil {
 		return model.User{}, err
 	}
@@ -42,101 +46,127 @@ func (repository UserRepository) Create(user model.User) (model.User, error) {
 	return newUser, nil
 }
 
-// FindByNameOrNick returns all users that name or nick match with the argument
-func (repository UserRepository) FindByNameOrNick(nameOrNick string) ([]model.User, error) {
+// FindByNameOrNick returns a page of users that name or nick match with the argument
+func (repository UserRepository) FindByNameOrNick(ctx context.Context, nameOrNick string, page model.PageRequest) (model.UserPage, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	nameOrNick = fmt.Sprintf("%%%s%%", nameOrNick)
 
-	rows, err := repository.db.Query("select id, name, nick, email, created_at from users where name like ? or nick like ?", nameOrNick, nameOrNick)
+	rows, err := repository.db.QueryContext(ctx, "select "+selectColumns("u", userColumns)+" from users u where (u.name like ? or u.nick like ?) and u.id > ? order by u.id limit ?",
+		nameOrNick, nameOrNick, page.Cursor, page.Limit+1)
 
 	if err != nil {
-		return nil, err
+		return model.UserPage{}, err
 	}
 
 	defer rows.Close()
 
-	var users []model.User
-
-	for rows.Next() {
-		var user model.User
-
-		err = rows.Scan(&user.ID, &user.Name, &user.Nick, &user.Email, &user.CreatedAt)
-
-		if err != nil {
-			return nil, err
-		}
-
-		users = append(users, user)
+	users, err := scanUsers(rows)
+	if err != nil {
+		return model.UserPage{}, err
 	}
 
-	return users, nil
+	return newUserPage(users, page), nil
 }
 
-// FindByID returns a user thar match with a given ID
-func (repository UserRepository) FindByID(userID uint64) (model.User, error) {
+// FindByID returns a user that match with a given ID or a not found error if there is none
+func (repository UserRepository) FindByID(ctx context.Context, userID uint64) (model.User, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
 
-	rows, err := repository.db.Query("select id, name, nick, email, created_at from users where id = ?", userID)
+	row := repository.db.QueryRowContext(ctx, "select "+selectColumns("u", userColumns)+" from users u where u.id = ?", userID)
+
+	user, err := scanUser(row)
+	if err == sql.ErrNoRows {
+		return model.User{}, model.NewNotFoundError("user")
+	}
 
 	if err != nil {
 		return model.User{}, err
 	}
 
-	defer rows.Close()
-
-	var user model.User
+	return user, nil
+}
 
-	if rows.Next() {
+// Update updates a user in database, as long as it was not changed since the version held by the user.
+// Changing the email makes the account unverified until the new address is confirmed. MySQL assigns
+// the columns from left to right, so verified_at is compared with the email before it changes
+func (repository UserRepository) Update(ctx context.Context, userID uint64, user model.User) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
 
-		err = rows.Scan(&user.ID, &user.Name, &user.Nick, &user.Email, &user.CreatedAt)
+	statement, err := repository.db.PrepareContext(ctx, `update users set verified_at = if(email = ?, verified_at, null), name = ?, nick = ?, email = ?,
+											version = version + 1, updated_at = current_timestamp() where id = ? and version = ?`)
 
-		if err != nil {
-			return model.User{}, err
-		}
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
 
+	result, err := statement.ExecContext(ctx, user.Email, user.Name, user.Nick, user.Email, userID, user.Version)
+	if err != nil {
+		return userConflictError(err)
 	}
 
-	return user, nil
+	return checkVersionUpdated(result, "user")
 }
 
-// Update updates a user in database
-func (repository UserRepository) Update(userID uint64, user model.User) error {
-
-	statement, err := repository.db.Prepare("update users set name = ?, nick = ?, email = ? where id = ?")
+// Delete deletes a user in database. Their posts go along with the user, and so do the plain reposts of them
+func (repository UserRepository) Delete(ctx context.Context, userID uint64) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
 
+	tx, err := repository.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	defer statement.Close()
+	defer tx.Rollback()
 
-	_, err = statement.Exec(user.Name, user.Nick, user.Email, userID)
+	_, err = tx.ExecContext(ctx, "delete p from posts p join posts o on p.repost_of_id = o.id where o.author_id = ? and "+plainRepostCondition, userID)
 	if err != nil {
 		return err
 	}
 
-	return nil
-}
-
-// Delete deletes a user in database
-func (repository UserRepository) Delete(userID uint64) error {
-
-	statement, err := repository.db.Prepare("delete from users where id = ?")
+	statement, err := tx.PrepareContext(ctx, "delete from users where id = ?")
 
 	if err != nil {
 		return err
 	}
 	defer statement.Close()
 
-	_, err = statement.Exec(userID)
+	_, err = statement.ExecContext(ctx, userID)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return tx.Commit()
+}
+
+// Taken tells if a nick and an email are already used by some user
+func (repository UserRepository) Taken(ctx context.Context, nick, email string) (bool, bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	var nickTaken, emailTaken bool
+
+	err := repository.db.QueryRowContext(ctx,
+		"select exists(select 1 from users where nick = ?), exists(select 1 from users where email = ?)",
+		nick, email,
+	).Scan(&nickTaken, &emailTaken)
+	if err != nil {
+		return false, false, err
+	}
+
+	return nickTaken, emailTaken, nil
 }
 
-// FindByEmail returns all users that email match with the argument
-func (repository UserRepository) FindByEmail(email string) (model.User, error) {
+// FindByEmail returns the ID, the password hash and the verification time of the user that has a given email
+func (repository UserRepository) FindByEmail(ctx context.Context, email string) (model.User, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
 
-	rows, err := repository.db.Query("select id, password from users where email = ?", email)
+	rows, err := repository.db.QueryContext(ctx, "select id, password, verified_at from users where email = ?", email)
 
 	if err != nil {
 		return model.User{}, err
@@ -148,7 +178,7 @@ func (repository UserRepository) FindByEmail(email string) (model.User, error) {
 
 	if rows.Next() {
 
-		err = rows.Scan(&user.ID, &user.Password)
+		err = rows.Scan(&user.ID, &user.Password, &user.VerifiedAt)
 
 		if err != nil {
 			return model.User{}, err
@@ -160,14 +190,17 @@ func (repository UserRepository) FindByEmail(email string) (model.User, error) {
 }
 
 // Follow allows a user to follow another
-func (repository UserRepository) Follow(userID, followerID uint64) error {
-	statement, err := repository.db.Prepare("insert ignore into followers (user_id, follower_id) values (?, ?)")
+func (repository UserRepository) Follow(ctx context.Context, userID, followerID uint64) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, err := repository.db.PrepareContext(ctx, "insert ignore into followers (user_id, follower_id) values (?, ?)")
 	if err != nil {
 		return err
 	}
 	defer statement.Close()
 
-	_, err = statement.Exec(userID, followerID)
+	_, err = statement.ExecContext(ctx, userID, followerID)
 	if err != nil {
 		return err
 	}
@@ -176,14 +209,17 @@ func (repository UserRepository) Follow(userID, followerID uint64) error {
 }
 
 // Unfollow allows a user to unfollow another
-func (repository UserRepository) Unfollow(userID, followerID uint64) error {
-	statement, err := repository.db.Prepare("delete from followers where user_id = ? and follower_id = ?")
+func (repository UserRepository) Unfollow(ctx context.Context, userID, followerID uint64) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, err := repository.db.PrepareContext(ctx, "delete from followers where user_id = ? and follower_id = ?")
 	if err != nil {
 		return err
 	}
 	defer statement.Close()
 
-	_, err = statement.Exec(userID, followerID)
+	_, err = statement.ExecContext(ctx, userID, followerID)
 	if err != nil {
 		return err
 	}
@@ -191,67 +227,91 @@ func (repository UserRepository) Unfollow(userID, followerID uint64) error {
 	return nil
 }
 
-// SearchFollowers returns a list of followers for a given user
-func (repository UserRepository) SearchFollowers(userID uint64) ([]model.User, error) {
-	rows, err := repository.db.Query(`select u.id, u.name, u.nick, u.email, u.created_at 
-									from users u join followers f on u.id = f.follower_id where f.user_id = ?`,
-		userID)
+// SearchFollowers returns a page of followers for a given user
+func (repository UserRepository) SearchFollowers(ctx context.Context, userID uint64, page model.PageRequest) (model.UserPage, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := repository.db.QueryContext(ctx, `select `+selectColumns("u", userColumns)+`
+									from users u join followers f on u.id = f.follower_id where f.user_id = ? and u.id > ?
+									order by u.id limit ?`,
+		userID, page.Cursor, page.Limit+1)
 	if err != nil {
-		return nil, err
+		return model.UserPage{}, err
 	}
 
 	defer rows.Close()
 
-	var users []model.User
+	users, err := scanUsers(rows)
+	if err != nil {
+		return model.UserPage{}, err
+	}
 
-	for rows.Next() {
-		var user model.User
+	return newUserPage(users, page), nil
 
-		err = rows.Scan(&user.ID, &user.Name, &user.Nick, &user.Email, &user.CreatedAt)
+}
 
-		if err != nil {
-			return nil, err
-		}
+// SearchFollowing returns a page of users that a given user is following
+func (repository UserRepository) SearchFollowing(ctx context.Context, userID uint64, page model.PageRequest) (model.UserPage, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
 
-		users = append(users, user)
+	rows, err := repository.db.QueryContext(ctx, `select `+selectColumns("u", userColumns)+`
+									from users u join followers f on u.id = f.user_id where f.follower_id = ? and u.id > ?
+									order by u.id limit ?`,
+		userID, page.Cursor, page.Limit+1)
+	if err != nil {
+		return model.UserPage{}, err
+	}
+
+	defer rows.Close()
+
+	users, err := scanUsers(rows)
+	if err != nil {
+		return model.UserPage{}, err
 	}
 
-	return users, nil
+	return newUserPage(users, page), nil
 
 }
 
-// SearchFollowing returns a list of users that a given user is following
-func (repository UserRepository) SearchFollowing(userID uint64) ([]model.User, error) {
-	rows, err := repository.db.Query(`select u.id, u.name, u.nick, u.email, u.created_at 
-									from users u join followers f on u.id = f.user_id where f.follower_id = ?`,
-		userID)
+// FollowingIDs returns the IDs of all the users that a given user is following
+func (repository UserRepository) FollowingIDs(ctx context.Context, userID uint64) ([]uint64, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := repository.db.QueryContext(ctx, "select user_id from followers where follower_id = ?", userID)
 	if err != nil {
 		return nil, err
 	}
 
 	defer rows.Close()
 
-	var users []model.User
+	var ids []uint64
 
 	for rows.Next() {
-		var user model.User
+		var id uint64
 
-		err = rows.Scan(&user.ID, &user.Name, &user.Nick, &user.Email, &user.CreatedAt)
-
-		if err != nil {
+		if err = rows.Scan(&id); err != nil {
 			return nil, err
 		}
 
-		users = append(users, user)
+		ids = append(ids, id)
 	}
 
-	return users, nil
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
 
+	return ids, nil
 }
 
 // FindPassword returns the hashed password of a given user
-func (repository UserRepository) FindPassword(userID uint64) (string, error) {
-	rows, err := repository.db.Query(`select password from users where id = ?`,
+func (repository UserRepository) FindPassword(ctx context.Context, userID uint64) (string, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := repository.db.QueryContext(ctx, `select password from users where id = ?`,
 		userID)
 	if err != nil {
 		return "", err
@@ -275,14 +335,17 @@ func (repository UserRepository) FindPassword(userID uint64) (string, error) {
 }
 
 // UpdatePassword updates the password for a given user
-func (repository UserRepository) UpdatePassword(userID uint64, password string) error {
-	statement, err := repository.db.Prepare("update users set password = ? where id = ?")
+func (repository UserRepository) UpdatePassword(ctx context.Context, userID uint64, password string) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, err := repository.db.PrepareContext(ctx, "update users set password = ? where id = ?")
 	if err != nil {
 		return err
 	}
 	defer statement.Close()
 
-	_, err = statement.Exec(password, userID)
+	_, err = statement.ExecContext(ctx, password, userID)
 	if err != nil {
 		return err
 	}
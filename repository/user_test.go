@@ -1,14 +1,19 @@
 package repository_test
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
 	"github.com/stretchr/testify/assert"
+	"github.com/waliqueiroz/devbook-api/config"
 	"github.com/waliqueiroz/devbook-api/model"
 	"github.com/waliqueiroz/devbook-api/repository"
 	"github.com/waliqueiroz/devbook-api/test/mock"
@@ -30,6 +35,7 @@ func TestCreateUser(t *testing.T) {
 		errorInResult  bool
 		errorInScanRow bool
 		err            error
+		conflict       string
 	}{
 		{
 			name: "Create user",
@@ -44,6 +50,12 @@ func TestCreateUser(t *testing.T) {
 			errorInExec: true,
 			err:         errors.New("some error"),
 		},
+		{
+			name:        "Create user - duplicate email",
+			errorInExec: true,
+			err:         &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'juliette@mail.com' for key 'users.email'"},
+			conflict:    "email",
+		},
 		{
 			name:          "Create user - error in result",
 			errorInResult: true,
@@ -59,7 +71,7 @@ func TestCreateUser(t *testing.T) {
 	repository := repository.NewUserRepository(db)
 
 	insertQuery := "insert into users \\(name, nick, email, password\\) values \\(\\?, \\?, \\?, \\?\\)"
-	selectQuery := "select id, name, nick, email, created_at from users where id = \\?"
+	selectQuery := "select u.id, u.name, u.nick, u.email, u.created_at, u.version, u.updated_at from users u where u.id = \\?"
 
 	for _, subTest := range subTests {
 		t.Run(subTest.name, func(t *testing.T) {
@@ -67,19 +79,26 @@ func TestCreateUser(t *testing.T) {
 			if subTest.errorInPrepare {
 				mock.ExpectPrepare(insertQuery).WillReturnError(subTest.err)
 
-				_, err := repository.Create(user)
+				_, err := repository.Create(context.Background(), user)
 				assert.ErrorIs(t, err, subTest.err)
 			} else if subTest.errorInExec {
 				prep := mock.ExpectPrepare(insertQuery)
 				prep.ExpectExec().WithArgs(user.Name, user.Nick, user.Email, user.Password).WillReturnError(subTest.err)
 
-				_, err := repository.Create(user)
+				_, err := repository.Create(context.Background(), user)
 				assert.ErrorIs(t, err, subTest.err)
+
+				if subTest.conflict != "" {
+					var appError *model.AppError
+					assert.ErrorAs(t, err, &appError)
+					assert.Equal(t, http.StatusConflict, appError.Status)
+					assert.Equal(t, subTest.conflict, appError.Field)
+				}
 			} else if subTest.errorInResult {
 				prep := mock.ExpectPrepare(insertQuery)
 				prep.ExpectExec().WithArgs(user.Name, user.Nick, user.Email, user.Password).WillReturnResult(sqlmock.NewErrorResult(subTest.err))
 
-				_, err := repository.Create(user)
+				_, err := repository.Create(context.Background(), user)
 				assert.ErrorIs(t, err, subTest.err)
 			} else if subTest.errorInScanRow {
 				prep := mock.ExpectPrepare(insertQuery)
@@ -90,18 +109,18 @@ func TestCreateUser(t *testing.T) {
 
 				mock.ExpectQuery(selectQuery).WithArgs(user.ID).WillReturnRows(rows)
 
-				_, err := repository.Create(user)
+				_, err := repository.Create(context.Background(), user)
 				assert.Error(t, err)
 			} else {
 				prep := mock.ExpectPrepare(insertQuery)
 				prep.ExpectExec().WithArgs(user.Name, user.Nick, user.Email, user.Password).WillReturnResult(sqlmock.NewResult(1, 1))
 
-				rows := sqlmock.NewRows([]string{"id", "name", "nick", "email", "created_at"}).
-					AddRow(user.ID, user.Name, user.Nick, user.Email, user.CreatedAt)
+				rows := sqlmock.NewRows([]string{"id", "name", "nick", "email", "created_at", "version", "updated_at"}).
+					AddRow(user.ID, user.Name, user.Nick, user.Email, user.CreatedAt, user.Version, user.UpdatedAt)
 
 				mock.ExpectQuery(selectQuery).WithArgs(user.ID).WillReturnRows(rows)
 
-				createduser, err := repository.Create(user)
+				createduser, err := repository.Create(context.Background(), user)
 				fmt.Println(err)
 				assert.Equal(t, user, createduser)
 			}
@@ -120,6 +139,7 @@ func TestFindUserByID(t *testing.T) {
 
 	subTests := []struct {
 		name           string
+		notFound       bool
 		errorInExec    bool
 		errorInScanRow bool
 		err            error
@@ -128,6 +148,10 @@ func TestFindUserByID(t *testing.T) {
 			name:        "Find by ID",
 			errorInExec: false,
 		},
+		{
+			name:     "Find by ID - not found",
+			notFound: true,
+		},
 		{
 			name:        "Find by ID - error in exec query",
 			errorInExec: true,
@@ -142,15 +166,22 @@ func TestFindUserByID(t *testing.T) {
 
 	repository := repository.NewUserRepository(db)
 
-	query := "select id, name, nick, email, created_at from users where id = \\?"
+	query := "select u.id, u.name, u.nick, u.email, u.created_at, u.version, u.updated_at from users u where u.id = \\?"
 
 	for _, subTest := range subTests {
 		t.Run(subTest.name, func(t *testing.T) {
 
-			if subTest.errorInExec {
+			if subTest.notFound {
+				rows := sqlmock.NewRows([]string{"id", "name", "nick", "email", "created_at", "version", "updated_at"})
+
+				mock.ExpectQuery(query).WithArgs(user.ID).WillReturnRows(rows)
+
+				_, err := repository.FindByID(context.Background(), user.ID)
+				assert.True(t, model.IsNotFound(err), "Error should be a not found error")
+			} else if subTest.errorInExec {
 				mock.ExpectQuery(query).WillReturnError(subTest.err)
 
-				_, err := repository.FindByID(user.ID)
+				_, err := repository.FindByID(context.Background(), user.ID)
 				assert.ErrorIs(t, err, subTest.err)
 			} else if subTest.errorInScanRow {
 				rows := sqlmock.NewRows([]string{"id"}).
@@ -158,21 +189,45 @@ func TestFindUserByID(t *testing.T) {
 
 				mock.ExpectQuery(query).WithArgs(user.ID).WillReturnRows(rows)
 
-				_, err := repository.FindByID(user.ID)
+				_, err := repository.FindByID(context.Background(), user.ID)
 				assert.Error(t, err)
 			} else {
-				rows := sqlmock.NewRows([]string{"id", "name", "nick", "email", "created_at"}).
-					AddRow(user.ID, user.Name, user.Nick, user.Email, user.CreatedAt)
+				rows := sqlmock.NewRows([]string{"id", "name", "nick", "email", "created_at", "version", "updated_at"}).
+					AddRow(user.ID, user.Name, user.Nick, user.Email, user.CreatedAt, user.Version, user.UpdatedAt)
 
 				mock.ExpectQuery(query).WithArgs(user.ID).WillReturnRows(rows)
 
-				createdUser, _ := repository.FindByID(user.ID)
+				createdUser, _ := repository.FindByID(context.Background(), user.ID)
 				assert.Equal(t, user, createdUser)
 			}
 		})
 	}
 }
 
+func TestFindUserByIDTimeout(t *testing.T) {
+	db, mock := mock.NewDatabaseConnection()
+	defer db.Close()
+
+	queryTimeout := config.DBQueryTimeout
+	config.DBQueryTimeout = 10 * time.Millisecond
+	defer func() { config.DBQueryTimeout = queryTimeout }()
+
+	query := "select u.id, u.name, u.nick, u.email, u.created_at, u.version, u.updated_at from users u where u.id = \\?"
+
+	rows := sqlmock.NewRows([]string{"id", "name", "nick", "email", "created_at", "version", "updated_at"}).
+		AddRow(1, "Juliette", "juliette", "juliette@email.com", time.Now(), 1, time.Now())
+
+	mock.ExpectQuery(query).WithArgs(1).WillDelayFor(time.Second).WillReturnRows(rows)
+
+	repository := repository.NewUserRepository(db)
+
+	start := time.Now()
+	_, err := repository.FindByID(context.Background(), 1)
+
+	assert.Error(t, err, "The query should run out of time")
+	assert.Less(t, int64(time.Since(start)), int64(time.Second), "The query should be cancelled by the timeout")
+}
+
 func TestFindUserByNameOrNick(t *testing.T) {
 	userJson, _ := ioutil.ReadFile("../test/resource/json/created_user.json")
 
@@ -186,6 +241,7 @@ func TestFindUserByNameOrNick(t *testing.T) {
 
 	subTests := []struct {
 		name           string
+		hasNextPage    bool
 		errorInExec    bool
 		errorInScanRow bool
 		err            error
@@ -194,6 +250,10 @@ func TestFindUserByNameOrNick(t *testing.T) {
 			name:        "Index",
 			errorInExec: false,
 		},
+		{
+			name:        "Index - with next page",
+			hasNextPage: true,
+		},
 		{
 			name:        "Index - error in exec query",
 			errorInExec: true,
@@ -208,7 +268,9 @@ func TestFindUserByNameOrNick(t *testing.T) {
 
 	repository := repository.NewUserRepository(db)
 
-	query := "select id, name, nick, email, created_at from users where name like \\? or nick like \\?"
+	page := model.PageRequest{Limit: 1}
+
+	query := "select u.id, u.name, u.nick, u.email, u.created_at, u.version, u.updated_at from users u where \\(u.name like \\? or u.nick like \\?\\) and u.id > \\? order by u.id limit \\?"
 
 	for _, subTest := range subTests {
 		t.Run(subTest.name, func(t *testing.T) {
@@ -216,24 +278,33 @@ func TestFindUserByNameOrNick(t *testing.T) {
 			if subTest.errorInExec {
 				mock.ExpectQuery(query).WillReturnError(subTest.err)
 
-				_, err := repository.FindByNameOrNick(user.Name)
+				_, err := repository.FindByNameOrNick(context.Background(), user.Name, page)
 				assert.ErrorIs(t, err, subTest.err)
 			} else if subTest.errorInScanRow {
 				rows := sqlmock.NewRows([]string{"id"}).
 					AddRow(-1)
 
-				mock.ExpectQuery(query).WithArgs(nameOrNick, nameOrNick).WillReturnRows(rows)
+				mock.ExpectQuery(query).WithArgs(nameOrNick, nameOrNick, page.Cursor, page.Limit+1).WillReturnRows(rows)
 
-				_, err := repository.FindByNameOrNick(user.Name)
+				_, err := repository.FindByNameOrNick(context.Background(), user.Name, page)
 				assert.Error(t, err)
+			} else if subTest.hasNextPage {
+				rows := sqlmock.NewRows([]string{"id", "name", "nick", "email", "created_at", "version", "updated_at"}).
+					AddRow(user.ID, user.Name, user.Nick, user.Email, user.CreatedAt, user.Version, user.UpdatedAt).
+					AddRow(user.ID+1, user.Name, user.Nick, user.Email, user.CreatedAt, user.Version, user.UpdatedAt)
+
+				mock.ExpectQuery(query).WithArgs(nameOrNick, nameOrNick, page.Cursor, page.Limit+1).WillReturnRows(rows)
+
+				userPage, _ := repository.FindByNameOrNick(context.Background(), user.Name, page)
+				assert.Equal(t, model.UserPage{Data: []model.User{user}, NextCursor: model.EncodeCursor(user.ID)}, userPage)
 			} else {
-				rows := sqlmock.NewRows([]string{"id", "name", "nick", "email", "created_at"}).
-					AddRow(user.ID, user.Name, user.Nick, user.Email, user.CreatedAt)
+				rows := sqlmock.NewRows([]string{"id", "name", "nick", "email", "created_at", "version", "updated_at"}).
+					AddRow(user.ID, user.Name, user.Nick, user.Email, user.CreatedAt, user.Version, user.UpdatedAt)
 
-				mock.ExpectQuery(query).WithArgs(nameOrNick, nameOrNick).WillReturnRows(rows)
+				mock.ExpectQuery(query).WithArgs(nameOrNick, nameOrNick, page.Cursor, page.Limit+1).WillReturnRows(rows)
 
-				createdUsers, _ := repository.FindByNameOrNick(user.Name)
-				assert.Equal(t, []model.User{user}, createdUsers)
+				userPage, _ := repository.FindByNameOrNick(context.Background(), user.Name, page)
+				assert.Equal(t, model.UserPage{Data: []model.User{user}}, userPage)
 			}
 		})
 	}
@@ -252,6 +323,7 @@ func TestUpdateUser(t *testing.T) {
 		name           string
 		errorInPrepare bool
 		errorInExec    bool
+		staleVersion   bool
 		err            error
 	}{
 		{
@@ -267,11 +339,15 @@ func TestUpdateUser(t *testing.T) {
 			errorInExec: true,
 			err:         errors.New("some error"),
 		},
+		{
+			name:         "Update user - changed since it was read",
+			staleVersion: true,
+		},
 	}
 
 	repository := repository.NewUserRepository(db)
 
-	query := "update users set name = \\?, nick = \\?, email = \\? where id = \\?"
+	query := "update users set verified_at = if\\(email = \\?, verified_at, null\\), name = \\?, nick = \\?, email = \\?, version = version \\+ 1, updated_at = current_timestamp\\(\\) where id = \\? and version = \\?"
 
 	for _, subTest := range subTests {
 		t.Run(subTest.name, func(t *testing.T) {
@@ -279,19 +355,27 @@ func TestUpdateUser(t *testing.T) {
 			if subTest.errorInPrepare {
 				mock.ExpectPrepare(query).WillReturnError(subTest.err)
 
-				err := repository.Update(user.ID, user)
+				err := repository.Update(context.Background(), user.ID, user)
 				assert.ErrorIs(t, err, subTest.err)
 			} else if subTest.errorInExec {
 				prep := mock.ExpectPrepare(query)
-				prep.ExpectExec().WithArgs(user.Name, user.Nick, user.Email, user.ID).WillReturnError(subTest.err)
+				prep.ExpectExec().WithArgs(user.Email, user.Name, user.Nick, user.Email, user.ID, user.Version).WillReturnError(subTest.err)
 
-				err := repository.Update(user.ID, user)
+				err := repository.Update(context.Background(), user.ID, user)
 				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.staleVersion {
+				prep := mock.ExpectPrepare(query)
+				prep.ExpectExec().WithArgs(user.Email, user.Name, user.Nick, user.Email, user.ID, user.Version).WillReturnResult(sqlmock.NewResult(0, 0))
+
+				var appError *model.AppError
+				err := repository.Update(context.Background(), user.ID, user)
+				assert.ErrorAs(t, err, &appError)
+				assert.Equal(t, model.CodeVersionMismatch, appError.Code)
 			} else {
 				prep := mock.ExpectPrepare(query)
-				prep.ExpectExec().WithArgs(user.Name, user.Nick, user.Email, user.ID).WillReturnResult(sqlmock.NewResult(1, 1))
+				prep.ExpectExec().WithArgs(user.Email, user.Name, user.Nick, user.Email, user.ID, user.Version).WillReturnResult(sqlmock.NewResult(1, 1))
 
-				err := repository.Update(user.ID, user)
+				err := repository.Update(context.Background(), user.ID, user)
 				assert.NoError(t, err)
 			}
 		})
@@ -308,14 +392,20 @@ func TestDeleteUser(t *testing.T) {
 	defer db.Close()
 
 	subTests := []struct {
-		name           string
-		errorInPrepare bool
-		errorInExec    bool
-		err            error
+		name                 string
+		errorInDeleteReposts bool
+		errorInPrepare       bool
+		errorInExec          bool
+		err                  error
 	}{
 		{
 			name: "Delete user",
 		},
+		{
+			name:                 "Delete user - error in deleting reposts",
+			errorInDeleteReposts: true,
+			err:                  errors.New("some error"),
+		},
 		{
 			name:           "Delete user - error in prepare",
 			errorInPrepare: true,
@@ -330,28 +420,45 @@ func TestDeleteUser(t *testing.T) {
 
 	repository := repository.NewUserRepository(db)
 
+	repostsQuery := "delete p from posts p join posts o on p.repost_of_id = o.id where o.author_id = \\? and p.repost_of_id is not null and p.title = '' and p.content = ''"
+
 	query := "delete from users where id = \\?"
 
 	for _, subTest := range subTests {
 		t.Run(subTest.name, func(t *testing.T) {
 
-			if subTest.errorInPrepare {
+			mock.ExpectBegin()
+
+			if subTest.errorInDeleteReposts {
+				mock.ExpectExec(repostsQuery).WithArgs(user.ID).WillReturnError(subTest.err)
+				mock.ExpectRollback()
+
+				err := repository.Delete(context.Background(), user.ID)
+				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.errorInPrepare {
+				mock.ExpectExec(repostsQuery).WithArgs(user.ID).WillReturnResult(sqlmock.NewResult(0, 0))
 				mock.ExpectPrepare(query).WillReturnError(subTest.err)
+				mock.ExpectRollback()
 
-				err := repository.Delete(user.ID)
+				err := repository.Delete(context.Background(), user.ID)
 				assert.ErrorIs(t, err, subTest.err)
 			} else if subTest.errorInExec {
+				mock.ExpectExec(repostsQuery).WithArgs(user.ID).WillReturnResult(sqlmock.NewResult(0, 0))
 				prep := mock.ExpectPrepare(query)
 				prep.ExpectExec().WithArgs(user.ID).WillReturnError(subTest.err)
+				mock.ExpectRollback()
 
-				err := repository.Delete(user.ID)
+				err := repository.Delete(context.Background(), user.ID)
 				assert.ErrorIs(t, err, subTest.err)
 			} else {
+				mock.ExpectExec(repostsQuery).WithArgs(user.ID).WillReturnResult(sqlmock.NewResult(0, 1))
 				prep := mock.ExpectPrepare(query)
 				prep.ExpectExec().WithArgs(user.ID).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
 
-				err := repository.Delete(user.ID)
+				err := repository.Delete(context.Background(), user.ID)
 				assert.NoError(t, err)
+				assert.NoError(t, mock.ExpectationsWereMet())
 			}
 		})
 	}
@@ -372,12 +479,17 @@ func TestFindUserByEmail(t *testing.T) {
 		name           string
 		errorInExec    bool
 		errorInScanRow bool
+		unverified     bool
 		err            error
 	}{
 		{
 			name:        "Find by Email",
 			errorInExec: false,
 		},
+		{
+			name:       "Find by Email - not verified",
+			unverified: true,
+		},
 		{
 			name:        "Find by Email - error in exec query",
 			errorInExec: true,
@@ -392,7 +504,7 @@ func TestFindUserByEmail(t *testing.T) {
 
 	repository := repository.NewUserRepository(db)
 
-	query := "select id, password from users where email = \\?"
+	query := "select id, password, verified_at from users where email = \\?"
 
 	for _, subTest := range subTests {
 		t.Run(subTest.name, func(t *testing.T) {
@@ -400,26 +512,32 @@ func TestFindUserByEmail(t *testing.T) {
 			if subTest.errorInExec {
 				mock.ExpectQuery(query).WillReturnError(subTest.err)
 
-				_, err := repository.FindByEmail(user.Email)
+				_, err := repository.FindByEmail(context.Background(), user.Email)
 				assert.ErrorIs(t, err, subTest.err)
 			} else if subTest.errorInScanRow {
-				rows := sqlmock.NewRows([]string{"id", "password"}).
-					AddRow(-1, user.Password)
+				rows := sqlmock.NewRows([]string{"id", "password", "verified_at"}).
+					AddRow(-1, user.Password, nil)
 
 				mock.ExpectQuery(query).WithArgs(user.Email).WillReturnRows(rows)
 
-				_, err := repository.FindByEmail(user.Email)
+				_, err := repository.FindByEmail(context.Background(), user.Email)
 				fmt.Println("teste", err)
 				assert.Error(t, err)
 			} else {
-				rows := sqlmock.NewRows([]string{"id", "password"}).
-					AddRow(user.ID, user.Password)
+				var verifiedAt interface{} = user.CreatedAt
+				if subTest.unverified {
+					verifiedAt = nil
+				}
+
+				rows := sqlmock.NewRows([]string{"id", "password", "verified_at"}).
+					AddRow(user.ID, user.Password, verifiedAt)
 
 				mock.ExpectQuery(query).WithArgs(user.Email).WillReturnRows(rows)
 
-				createdUser, _ := repository.FindByEmail(user.Email)
+				createdUser, _ := repository.FindByEmail(context.Background(), user.Email)
 				assert.Equal(t, user.ID, createdUser.ID)
 				assert.Equal(t, user.Password, createdUser.Password)
+				assert.Equal(t, !subTest.unverified, createdUser.Verified())
 			}
 		})
 	}
@@ -465,19 +583,19 @@ func TestFollowUser(t *testing.T) {
 			if subTest.errorInPrepare {
 				mock.ExpectPrepare(query).WillReturnError(subTest.err)
 
-				err := repository.Follow(2, user.ID)
+				err := repository.Follow(context.Background(), 2, user.ID)
 				assert.ErrorIs(t, err, subTest.err)
 			} else if subTest.errorInExec {
 				prep := mock.ExpectPrepare(query)
 				prep.ExpectExec().WithArgs(2, user.ID).WillReturnError(subTest.err)
 
-				err := repository.Follow(2, user.ID)
+				err := repository.Follow(context.Background(), 2, user.ID)
 				assert.ErrorIs(t, err, subTest.err)
 			} else {
 				prep := mock.ExpectPrepare(query)
 				prep.ExpectExec().WithArgs(2, user.ID).WillReturnResult(sqlmock.NewResult(1, 1))
 
-				err := repository.Follow(2, user.ID)
+				err := repository.Follow(context.Background(), 2, user.ID)
 				assert.NoError(t, err)
 			}
 		})
@@ -524,19 +642,19 @@ func TestUnfollowUser(t *testing.T) {
 			if subTest.errorInPrepare {
 				mock.ExpectPrepare(query).WillReturnError(subTest.err)
 
-				err := repository.Unfollow(2, user.ID)
+				err := repository.Unfollow(context.Background(), 2, user.ID)
 				assert.ErrorIs(t, err, subTest.err)
 			} else if subTest.errorInExec {
 				prep := mock.ExpectPrepare(query)
 				prep.ExpectExec().WithArgs(2, user.ID).WillReturnError(subTest.err)
 
-				err := repository.Unfollow(2, user.ID)
+				err := repository.Unfollow(context.Background(), 2, user.ID)
 				assert.ErrorIs(t, err, subTest.err)
 			} else {
 				prep := mock.ExpectPrepare(query)
 				prep.ExpectExec().WithArgs(2, user.ID).WillReturnResult(sqlmock.NewResult(1, 1))
 
-				err := repository.Unfollow(2, user.ID)
+				err := repository.Unfollow(context.Background(), 2, user.ID)
 				assert.NoError(t, err)
 			}
 		})
@@ -576,7 +694,9 @@ func TestSearchFollowers(t *testing.T) {
 
 	repository := repository.NewUserRepository(db)
 
-	query := "select u.id, u.name, u.nick, u.email, u.created_at from users u join followers f on u.id = f.follower_id where f.user_id = \\?"
+	page := model.PageRequest{Limit: 20}
+
+	query := "select u.id, u.name, u.nick, u.email, u.created_at, u.version, u.updated_at from users u join followers f on u.id = f.follower_id where f.user_id = \\? and u.id > \\? order by u.id limit \\?"
 
 	for _, subTest := range subTests {
 		t.Run(subTest.name, func(t *testing.T) {
@@ -584,24 +704,24 @@ func TestSearchFollowers(t *testing.T) {
 			if subTest.errorInExec {
 				mock.ExpectQuery(query).WillReturnError(subTest.err)
 
-				_, err := repository.SearchFollowers(user.ID)
+				_, err := repository.SearchFollowers(context.Background(), user.ID, page)
 				assert.ErrorIs(t, err, subTest.err)
 			} else if subTest.errorInScanRow {
 				rows := sqlmock.NewRows([]string{"id"}).
 					AddRow(-1)
 
-				mock.ExpectQuery(query).WithArgs(user.ID).WillReturnRows(rows)
+				mock.ExpectQuery(query).WithArgs(user.ID, page.Cursor, page.Limit+1).WillReturnRows(rows)
 
-				_, err := repository.SearchFollowers(user.ID)
+				_, err := repository.SearchFollowers(context.Background(), user.ID, page)
 				assert.Error(t, err)
 			} else {
-				rows := sqlmock.NewRows([]string{"id", "name", "nick", "email", "created_at"}).
-					AddRow(user.ID, user.Name, user.Nick, user.Email, user.CreatedAt)
+				rows := sqlmock.NewRows([]string{"id", "name", "nick", "email", "created_at", "version", "updated_at"}).
+					AddRow(user.ID, user.Name, user.Nick, user.Email, user.CreatedAt, user.Version, user.UpdatedAt)
 
-				mock.ExpectQuery(query).WithArgs(user.ID).WillReturnRows(rows)
+				mock.ExpectQuery(query).WithArgs(user.ID, page.Cursor, page.Limit+1).WillReturnRows(rows)
 
-				createdUsers, _ := repository.SearchFollowers(user.ID)
-				assert.Equal(t, []model.User{user}, createdUsers)
+				userPage, _ := repository.SearchFollowers(context.Background(), user.ID, page)
+				assert.Equal(t, model.UserPage{Data: []model.User{user}}, userPage)
 			}
 		})
 	}
@@ -640,7 +760,9 @@ func TestSearchFollowing(t *testing.T) {
 
 	repository := repository.NewUserRepository(db)
 
-	query := "select u.id, u.name, u.nick, u.email, u.created_at from users u join followers f on u.id = f.user_id where f.follower_id = \\?"
+	page := model.PageRequest{Limit: 20}
+
+	query := "select u.id, u.name, u.nick, u.email, u.created_at, u.version, u.updated_at from users u join followers f on u.id = f.user_id where f.follower_id = \\? and u.id > \\? order by u.id limit \\?"
 
 	for _, subTest := range subTests {
 		t.Run(subTest.name, func(t *testing.T) {
@@ -648,24 +770,24 @@ func TestSearchFollowing(t *testing.T) {
 			if subTest.errorInExec {
 				mock.ExpectQuery(query).WillReturnError(subTest.err)
 
-				_, err := repository.SearchFollowing(user.ID)
+				_, err := repository.SearchFollowing(context.Background(), user.ID, page)
 				assert.ErrorIs(t, err, subTest.err)
 			} else if subTest.errorInScanRow {
 				rows := sqlmock.NewRows([]string{"id"}).
 					AddRow(-1)
 
-				mock.ExpectQuery(query).WithArgs(user.ID).WillReturnRows(rows)
+				mock.ExpectQuery(query).WithArgs(user.ID, page.Cursor, page.Limit+1).WillReturnRows(rows)
 
-				_, err := repository.SearchFollowing(user.ID)
+				_, err := repository.SearchFollowing(context.Background(), user.ID, page)
 				assert.Error(t, err)
 			} else {
-				rows := sqlmock.NewRows([]string{"id", "name", "nick", "email", "created_at"}).
-					AddRow(user.ID, user.Name, user.Nick, user.Email, user.CreatedAt)
+				rows := sqlmock.NewRows([]string{"id", "name", "nick", "email", "created_at", "version", "updated_at"}).
+					AddRow(user.ID, user.Name, user.Nick, user.Email, user.CreatedAt, user.Version, user.UpdatedAt)
 
-				mock.ExpectQuery(query).WithArgs(user.ID).WillReturnRows(rows)
+				mock.ExpectQuery(query).WithArgs(user.ID, page.Cursor, page.Limit+1).WillReturnRows(rows)
 
-				createdUsers, _ := repository.SearchFollowing(user.ID)
-				assert.Equal(t, []model.User{user}, createdUsers)
+				userPage, _ := repository.SearchFollowing(context.Background(), user.ID, page)
+				assert.Equal(t, model.UserPage{Data: []model.User{user}}, userPage)
 			}
 		})
 	}
@@ -714,7 +836,7 @@ func TestFindPassword(t *testing.T) {
 			if subTest.errorInExec {
 				mock.ExpectQuery(query).WillReturnError(subTest.err)
 
-				_, err := repository.FindPassword(user.ID)
+				_, err := repository.FindPassword(context.Background(), user.ID)
 				assert.ErrorIs(t, err, subTest.err)
 			} else if subTest.errorInScanRow {
 				rows := sqlmock.NewRows([]string{"password", "name"}).
@@ -722,7 +844,7 @@ func TestFindPassword(t *testing.T) {
 
 				mock.ExpectQuery(query).WithArgs(user.ID).WillReturnRows(rows)
 
-				_, err := repository.FindPassword(user.ID)
+				_, err := repository.FindPassword(context.Background(), user.ID)
 				assert.Error(t, err)
 			} else {
 				rows := sqlmock.NewRows([]string{"password"}).
@@ -730,7 +852,7 @@ func TestFindPassword(t *testing.T) {
 
 				mock.ExpectQuery(query).WithArgs(user.ID).WillReturnRows(rows)
 
-				password, _ := repository.FindPassword(user.ID)
+				password, _ := repository.FindPassword(context.Background(), user.ID)
 				assert.Equal(t, user.Password, password)
 			}
 		})
@@ -779,20 +901,148 @@ func TestUpdatePassword(t *testing.T) {
 			if subTest.errorInPrepare {
 				mock.ExpectPrepare(query).WillReturnError(subTest.err)
 
-				err := repository.UpdatePassword(user.ID, password)
+				err := repository.UpdatePassword(context.Background(), user.ID, password)
 				assert.ErrorIs(t, err, subTest.err)
 			} else if subTest.errorInExec {
 				prep := mock.ExpectPrepare(query)
 				prep.ExpectExec().WithArgs(password, user.ID).WillReturnError(subTest.err)
 
-				err := repository.UpdatePassword(user.ID, password)
+				err := repository.UpdatePassword(context.Background(), user.ID, password)
 				assert.ErrorIs(t, err, subTest.err)
 			} else {
 				prep := mock.ExpectPrepare(query)
 				prep.ExpectExec().WithArgs(password, user.ID).WillReturnResult(sqlmock.NewResult(1, 1))
 
-				err := repository.UpdatePassword(user.ID, password)
+				err := repository.UpdatePassword(context.Background(), user.ID, password)
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestFollowingIDs(t *testing.T) {
+	db, mock := mock.NewDatabaseConnection()
+	defer db.Close()
+
+	subTests := []struct {
+		name           string
+		errorInExec    bool
+		errorInScanRow bool
+		errorInRows    bool
+		err            error
+	}{
+		{
+			name: "Following IDs",
+		},
+		{
+			name:        "Following IDs - error in exec query",
+			errorInExec: true,
+			err:         errors.New("some error"),
+		},
+		{
+			name:           "Following IDs - error in scan row",
+			errorInScanRow: true,
+			err:            errors.New("some error"),
+		},
+		{
+			name:        "Following IDs - error in reading rows",
+			errorInRows: true,
+			err:         errors.New("some error"),
+		},
+	}
+
+	repository := repository.NewUserRepository(db)
+
+	query := "select user_id from followers where follower_id = \\?"
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+
+			if subTest.errorInExec {
+				mock.ExpectQuery(query).WillReturnError(subTest.err)
+
+				_, err := repository.FollowingIDs(context.Background(), 1)
+				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.errorInScanRow {
+				rows := sqlmock.NewRows([]string{"user_id"}).
+					AddRow(-1)
+
+				mock.ExpectQuery(query).WithArgs(1).WillReturnRows(rows)
+
+				_, err := repository.FollowingIDs(context.Background(), 1)
+				assert.Error(t, err)
+			} else if subTest.errorInRows {
+				rows := sqlmock.NewRows([]string{"user_id"}).
+					AddRow(2).
+					AddRow(3).
+					RowError(1, subTest.err)
+
+				mock.ExpectQuery(query).WithArgs(1).WillReturnRows(rows)
+
+				_, err := repository.FollowingIDs(context.Background(), 1)
+				assert.ErrorIs(t, err, subTest.err)
+			} else {
+				rows := sqlmock.NewRows([]string{"user_id"}).
+					AddRow(2).
+					AddRow(3)
+
+				mock.ExpectQuery(query).WithArgs(1).WillReturnRows(rows)
+
+				ids, _ := repository.FollowingIDs(context.Background(), 1)
+				assert.Equal(t, []uint64{2, 3}, ids)
+			}
+		})
+	}
+}
+
+func TestTaken(t *testing.T) {
+	db, mock := mock.NewDatabaseConnection()
+	defer db.Close()
+
+	subTests := []struct {
+		name        string
+		nickTaken   bool
+		emailTaken  bool
+		errorInExec bool
+		err         error
+	}{
+		{
+			name: "Taken - nothing taken",
+		},
+		{
+			name:       "Taken - nick and email taken",
+			nickTaken:  true,
+			emailTaken: true,
+		},
+		{
+			name:        "Taken - error in exec query",
+			errorInExec: true,
+			err:         errors.New("some error"),
+		},
+	}
+
+	repository := repository.NewUserRepository(db)
+
+	query := "select exists\\(select 1 from users where nick = \\?\\), exists\\(select 1 from users where email = \\?\\)"
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+
+			if subTest.errorInExec {
+				mock.ExpectQuery(query).WithArgs("juliette", "juliette@mail.com").WillReturnError(subTest.err)
+
+				_, _, err := repository.Taken(context.Background(), "juliette", "juliette@mail.com")
+				assert.ErrorIs(t, err, subTest.err)
+			} else {
+				rows := sqlmock.NewRows([]string{"nick", "email"}).
+					AddRow(subTest.nickTaken, subTest.emailTaken)
+
+				mock.ExpectQuery(query).WithArgs("juliette", "juliette@mail.com").WillReturnRows(rows)
+
+				nickTaken, emailTaken, err := repository.Taken(context.Background(), "juliette", "juliette@mail.com")
 				assert.NoError(t, err)
+				assert.Equal(t, subTest.nickTaken, nickTaken)
+				assert.Equal(t, subTest.emailTaken, emailTaken)
 			}
 		})
 	}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/waliqueiroz/devbook-api/model"
+)
+
+type VerificationRepository struct {
+	db *sql.DB
+}
+
+// NewVerificationRepository creates a new email verification repository
+func NewVerificationRepository(db *sql.DB) *VerificationRepository {
+	return &VerificationRepository{db}
+}
+
+// Create stores a verification token of a user and tells if it was stored. Tokens the user received before stop
+// working, so only the last email sent is valid. With a cooldown, no token is stored while the last one is more
+// recent than it, and the user is locked meanwhile so concurrent requests cannot both get past it
+func (repository VerificationRepository) Create(ctx context.Context, verification model.Verification, cooldown time.Duration) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := repository.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if cooldown > 0 {
+		var recent bool
+
+		err = tx.QueryRowContext(ctx, `select exists(select 1 from email_verifications where user_id = u.id and created_at > now() - interval ? second)
+										from users u where u.id = ? for update`, int64(cooldown.Seconds()), verification.UserID).Scan(&recent)
+		if err == sql.ErrNoRows {
+			return false, model.NewNotFoundError("user")
+		}
+
+		if err != nil {
+			return false, err
+		}
+
+		if recent {
+			return false, nil
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, "update email_verifications set used_at = now() where user_id = ? and used_at is null", verification.UserID)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.ExecContext(ctx, "insert into email_verifications (user_id, token_hash, expires_at) values (?, ?, ?)",
+		verification.UserID, verification.TokenHash, verification.ExpiresAt)
+	if err != nil {
+		return false, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Consume uses up the verification token of a user and marks the email of the user as verified.
+// It returns a not found error if the token is unknown, expired or was already used
+func (repository VerificationRepository) Consume(ctx context.Context, userID uint64, tokenHash string) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	tx, err := repository.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `update email_verifications set used_at = now()
+										where user_id = ? and token_hash = ? and used_at is null and expires_at > now()`, userID, tokenHash)
+	if err != nil {
+		return err
+	}
+
+	affectedRows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affectedRows == 0 {
+		return model.NewNotFoundError("verification")
+	}
+
+	_, err = tx.ExecContext(ctx, "update users set verified_at = now() where id = ? and verified_at is null", userID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
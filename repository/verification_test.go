@@ -0,0 +1,223 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/waliqueiroz/devbook-api/model"
+	"github.com/waliqueiroz/devbook-api/repository"
+	"github.com/waliqueiroz/devbook-api/test/mock"
+)
+
+func newVerification() model.Verification {
+	return model.Verification{
+		UserID:    1,
+		TokenHash: "d4b1d1a8f5c7b0a5e0e0b5c5f0e4e1c6c0f6e4f3b2c3d3e0a1b4c3d2e1f0a9b8",
+		ExpiresAt: time.Date(2021, 4, 6, 13, 34, 50, 0, time.UTC),
+	}
+}
+
+func TestCreateVerification(t *testing.T) {
+	verification := newVerification()
+
+	db, mock := mock.NewDatabaseConnection()
+	defer db.Close()
+
+	subTests := []struct {
+		name              string
+		cooldown          time.Duration
+		errorInBegin      bool
+		errorInCooldown   bool
+		unknownUser       bool
+		recent            bool
+		errorInInvalidate bool
+		errorInInsert     bool
+		err               error
+	}{
+		{
+			name: "Create verification",
+		},
+		{
+			name:     "Create verification after the cooldown",
+			cooldown: time.Minute,
+		},
+		{
+			name:     "Create verification within the cooldown",
+			cooldown: time.Minute,
+			recent:   true,
+		},
+		{
+			name:        "Create verification with a cooldown for an unknown user",
+			cooldown:    time.Minute,
+			unknownUser: true,
+		},
+		{
+			name:         "Create verification - error in begin",
+			errorInBegin: true,
+			err:          errors.New("some error"),
+		},
+		{
+			name:            "Create verification - error checking the cooldown",
+			cooldown:        time.Minute,
+			errorInCooldown: true,
+			err:             errors.New("some error"),
+		},
+		{
+			name:              "Create verification - error invalidating the pending tokens",
+			errorInInvalidate: true,
+			err:               errors.New("some error"),
+		},
+		{
+			name:          "Create verification - error in insert",
+			errorInInsert: true,
+			err:           errors.New("some error"),
+		},
+	}
+
+	repository := repository.NewVerificationRepository(db)
+
+	cooldownQuery := "select exists\\(select 1 from email_verifications where user_id = u.id and created_at > now\\(\\) - interval \\? second\\) from users u where u.id = \\? for update"
+	invalidateQuery := "update email_verifications set used_at = now\\(\\) where user_id = \\? and used_at is null"
+	insertQuery := "insert into email_verifications \\(user_id, token_hash, expires_at\\) values \\(\\?, \\?, \\?\\)"
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+
+			if subTest.errorInBegin {
+				mock.ExpectBegin().WillReturnError(subTest.err)
+
+				_, err := repository.Create(context.Background(), verification, subTest.cooldown)
+				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.errorInCooldown {
+				mock.ExpectBegin()
+				mock.ExpectQuery(cooldownQuery).WithArgs(60, verification.UserID).WillReturnError(subTest.err)
+				mock.ExpectRollback()
+
+				_, err := repository.Create(context.Background(), verification, subTest.cooldown)
+				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.unknownUser {
+				mock.ExpectBegin()
+				mock.ExpectQuery(cooldownQuery).WithArgs(60, verification.UserID).WillReturnRows(sqlmock.NewRows([]string{"recent"}))
+				mock.ExpectRollback()
+
+				_, err := repository.Create(context.Background(), verification, subTest.cooldown)
+				assert.True(t, model.IsNotFound(err), "Error should be a not found error")
+			} else if subTest.recent {
+				mock.ExpectBegin()
+				mock.ExpectQuery(cooldownQuery).WithArgs(60, verification.UserID).WillReturnRows(sqlmock.NewRows([]string{"recent"}).AddRow(true))
+				mock.ExpectRollback()
+
+				created, err := repository.Create(context.Background(), verification, subTest.cooldown)
+				assert.NoError(t, err)
+				assert.False(t, created, "Verification should not be created within the cooldown")
+			} else if subTest.errorInInvalidate {
+				mock.ExpectBegin()
+				mock.ExpectExec(invalidateQuery).WithArgs(verification.UserID).WillReturnError(subTest.err)
+				mock.ExpectRollback()
+
+				_, err := repository.Create(context.Background(), verification, subTest.cooldown)
+				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.errorInInsert {
+				mock.ExpectBegin()
+				mock.ExpectExec(invalidateQuery).WithArgs(verification.UserID).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(insertQuery).WithArgs(verification.UserID, verification.TokenHash, verification.ExpiresAt).WillReturnError(subTest.err)
+				mock.ExpectRollback()
+
+				_, err := repository.Create(context.Background(), verification, subTest.cooldown)
+				assert.ErrorIs(t, err, subTest.err)
+			} else {
+				mock.ExpectBegin()
+				if subTest.cooldown > 0 {
+					mock.ExpectQuery(cooldownQuery).WithArgs(60, verification.UserID).WillReturnRows(sqlmock.NewRows([]string{"recent"}).AddRow(false))
+				}
+				mock.ExpectExec(invalidateQuery).WithArgs(verification.UserID).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(insertQuery).WithArgs(verification.UserID, verification.TokenHash, verification.ExpiresAt).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+
+				created, err := repository.Create(context.Background(), verification, subTest.cooldown)
+				assert.NoError(t, err)
+				assert.True(t, created, "Verification should be created")
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestConsumeVerification(t *testing.T) {
+	verification := newVerification()
+
+	db, mock := mock.NewDatabaseConnection()
+	defer db.Close()
+
+	subTests := []struct {
+		name          string
+		notFound      bool
+		errorInUse    bool
+		errorInVerify bool
+		err           error
+	}{
+		{
+			name: "Consume verification",
+		},
+		{
+			name:     "Consume verification - unknown, expired or used token",
+			notFound: true,
+		},
+		{
+			name:       "Consume verification - error using the token",
+			errorInUse: true,
+			err:        errors.New("some error"),
+		},
+		{
+			name:          "Consume verification - error verifying the user",
+			errorInVerify: true,
+			err:           errors.New("some error"),
+		},
+	}
+
+	repository := repository.NewVerificationRepository(db)
+
+	useQuery := "update email_verifications set used_at = now\\(\\)\\s+where user_id = \\? and token_hash = \\? and used_at is null and expires_at > now\\(\\)"
+	verifyQuery := "update users set verified_at = now\\(\\) where id = \\? and verified_at is null"
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			mock.ExpectBegin()
+
+			if subTest.notFound {
+				mock.ExpectExec(useQuery).WithArgs(verification.UserID, verification.TokenHash).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+
+				err := repository.Consume(context.Background(), verification.UserID, verification.TokenHash)
+				assert.True(t, model.IsNotFound(err), "Error should be a not found error")
+			} else if subTest.errorInUse {
+				mock.ExpectExec(useQuery).WithArgs(verification.UserID, verification.TokenHash).WillReturnError(subTest.err)
+				mock.ExpectRollback()
+
+				err := repository.Consume(context.Background(), verification.UserID, verification.TokenHash)
+				assert.ErrorIs(t, err, subTest.err)
+			} else if subTest.errorInVerify {
+				mock.ExpectExec(useQuery).WithArgs(verification.UserID, verification.TokenHash).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(verifyQuery).WithArgs(verification.UserID).WillReturnError(subTest.err)
+				mock.ExpectRollback()
+
+				err := repository.Consume(context.Background(), verification.UserID, verification.TokenHash)
+				assert.ErrorIs(t, err, subTest.err)
+			} else {
+				mock.ExpectExec(useQuery).WithArgs(verification.UserID, verification.TokenHash).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(verifyQuery).WithArgs(verification.UserID).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+
+				err := repository.Consume(context.Background(), verification.UserID, verification.TokenHash)
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/waliqueiroz/devbook-api/model"
+)
+
+// checkVersionUpdated tells if an update guarded by a version found its row. Updates always increase
+// the version, so no affected rows means the row was changed or deleted since that version was read
+func checkVersionUpdated(result sql.Result, resource string) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return model.NewVersionMismatchError(resource)
+	}
+
+	return nil
+}
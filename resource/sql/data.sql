@@ -1,29 +1,33 @@
 insert into
-    users (name, nick, email, password)
+    users (name, nick, email, password, verified_at)
 values
     (
         "Usuário1",
         "user1",
         "user1@mail.com",
-        "$2a$10$finFsyhIR/7UK/8nKmlUu.kdN.Vw3AaHBHBMZlp1HiP3J2JpMgkI6"
+        "$2a$10$finFsyhIR/7UK/8nKmlUu.kdN.Vw3AaHBHBMZlp1HiP3J2JpMgkI6",
+        current_timestamp()
     ),
     (
         "Usuário2",
         "user2",
         "user2@mail.com",
-        "$2a$10$finFsyhIR/7UK/8nKmlUu.kdN.Vw3AaHBHBMZlp1HiP3J2JpMgkI6"
+        "$2a$10$finFsyhIR/7UK/8nKmlUu.kdN.Vw3AaHBHBMZlp1HiP3J2JpMgkI6",
+        current_timestamp()
     ),
     (
         "Usuário3",
         "user3",
         "user3@mail.com",
-        "$2a$10$finFsyhIR/7UK/8nKmlUu.kdN.Vw3AaHBHBMZlp1HiP3J2JpMgkI6"
+        "$2a$10$finFsyhIR/7UK/8nKmlUu.kdN.Vw3AaHBHBMZlp1HiP3J2JpMgkI6",
+        current_timestamp()
     ),
     (
         "Usuário4",
         "user4",
         "user4@mail.com",
-        "$2a$10$finFsyhIR/7UK/8nKmlUu.kdN.Vw3AaHBHBMZlp1HiP3J2JpMgkI6"
+        "$2a$10$finFsyhIR/7UK/8nKmlUu.kdN.Vw3AaHBHBMZlp1HiP3J2JpMgkI6",
+        current_timestamp()
     );
 
 insert into
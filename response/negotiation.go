@@ -0,0 +1,39 @@
+package response
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// PrefersJSON checks if the Accept header of a request ranks application/json above text/plain.
+// Requests without an explicit preference, such as "*/*", are not considered JSON clients
+func PrefersJSON(r *http.Request) bool {
+	jsonQuality, plainQuality := -1.0, -1.0
+
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, err := strconv.ParseFloat(params["q"], 64); err == nil {
+			quality = q
+		}
+
+		switch mediaType {
+		case "application/json":
+			if jsonQuality < 0 {
+				jsonQuality = quality
+			}
+		case "text/plain":
+			if plainQuality < 0 {
+				plainQuality = quality
+			}
+		}
+	}
+
+	return jsonQuality > 0 && jsonQuality >= plainQuality
+}
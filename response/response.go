@@ -2,8 +2,12 @@ package response
 
 import (
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
+
+	"github.com/waliqueiroz/devbook-api/i18n"
+	"github.com/waliqueiroz/devbook-api/logger"
+	"github.com/waliqueiroz/devbook-api/model"
 )
 
 // JSON write a json response to a request
@@ -13,16 +17,66 @@ func JSON(w http.ResponseWriter, statusCode int, data interface{}) {
 
 	if data != nil {
 		if err := json.NewEncoder(w).Encode(data); err != nil {
-			log.Fatal(err)
+			slog.Error("could not write the response", "error", err)
 		}
 	}
 }
 
-// JSON write an error in json format to a request
-func Error(w http.ResponseWriter, statusCode int, err error) {
-	JSON(w, statusCode, struct {
-		Error string `json:"error"`
-	}{
-		Error: err.Error(),
-	})
+// Text write a plain text response to a request
+func Text(w http.ResponseWriter, statusCode int, text string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(statusCode)
+
+	if _, err := w.Write([]byte(text)); err != nil {
+		slog.Error("could not write the response", "error", err)
+	}
+}
+
+// errorBody is the JSON sent for an error. Field errors are only sent when some data failed a validation,
+// and the field alone when its value conflicts with the stored data
+type errorBody struct {
+	Error  string             `json:"error"`
+	Code   string             `json:"code"`
+	Field  string             `json:"field,omitempty"`
+	Errors []model.FieldError `json:"errors,omitempty"`
+}
+
+// Error write an error in json format to a request, and logs it along with the request ID.
+// Only the public code and message of a model.AppError are sent to the client, and its status takes
+// precedence over the given one. Any other error is replaced by a generic message for the status, so
+// the details of a failure only reach the logs. Server errors are logged as errors, client errors only as information.
+// Field errors are translated into the language asked for in the Accept-Language header
+func Error(w http.ResponseWriter, r *http.Request, statusCode int, err error) {
+	appError := model.ToAppError(err, statusCode)
+	statusCode = appError.Status
+
+	level := slog.LevelInfo
+	if statusCode >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+
+	logger.FromContext(r.Context()).Log(r.Context(), level, "request failed", "status", statusCode, "code", appError.Code, "error", err)
+
+	body := errorBody{
+		Error: appError.Message,
+		Code:  appError.Code,
+		Field: appError.Field,
+	}
+
+	if len(appError.Fields) > 0 {
+		language := i18n.Negotiate(r.Header.Get("Accept-Language"))
+
+		body.Error = i18n.Message(language, appError.Code, "")
+		body.Errors = make([]model.FieldError, len(appError.Fields))
+
+		for i, field := range appError.Fields {
+			field.Message = i18n.Message(language, field.Code, field.Field, field.Args...)
+			body.Errors[i] = field
+		}
+
+		w.Header().Set("Content-Language", language)
+		w.Header().Add("Vary", "Accept-Language")
+	}
+
+	JSON(w, statusCode, body)
 }
@@ -0,0 +1,164 @@
+package response_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/waliqueiroz/devbook-api/model"
+	"github.com/waliqueiroz/devbook-api/response"
+)
+
+func TestError(t *testing.T) {
+	subTests := []struct {
+		name               string
+		statusCode         int
+		err                error
+		expectedStatusCode int
+		expectedCode       string
+		expectedMessage    string
+	}{
+		{
+			name:               "Application error",
+			statusCode:         http.StatusBadRequest,
+			err:                model.NewBadRequestError("limit must be a number between 1 and 100"),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedCode:       model.CodeBadRequest,
+			expectedMessage:    "limit must be a number between 1 and 100",
+		},
+		{
+			name:               "Wrapped application error with its own status",
+			statusCode:         http.StatusBadRequest,
+			err:                fmt.Errorf("preparing user: %w", model.NewInternalError(errors.New("bcrypt: cost out of range"))),
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedCode:       model.CodeInternal,
+			expectedMessage:    "internal server error",
+		},
+		{
+			name:               "Resource not found",
+			statusCode:         http.StatusNotFound,
+			err:                model.NewNotFoundError("user"),
+			expectedStatusCode: http.StatusNotFound,
+			expectedCode:       model.CodeNotFound,
+			expectedMessage:    "user not found",
+		},
+		{
+			name:               "Unknown server error",
+			statusCode:         http.StatusInternalServerError,
+			err:                errors.New("Error 1054: Unknown column 'nick' in 'field list'"),
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedCode:       model.CodeInternal,
+			expectedMessage:    "internal server error",
+		},
+		{
+			name:               "Unknown client error",
+			statusCode:         http.StatusUnauthorized,
+			err:                errors.New("signature is invalid"),
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedCode:       "unauthorized",
+			expectedMessage:    "unauthorized",
+		},
+		{
+			name:               "Query timeout",
+			statusCode:         http.StatusServiceUnavailable,
+			err:                fmt.Errorf("finding user: %w", context.DeadlineExceeded),
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedCode:       model.CodeUnavailable,
+			expectedMessage:    "the service is temporarily unavailable, try again later",
+		},
+	}
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			request := httptest.NewRequest("GET", "/users", nil)
+			recorder := httptest.NewRecorder()
+
+			response.Error(recorder, request, subTest.statusCode, subTest.err)
+
+			var body struct {
+				Error string `json:"error"`
+				Code  string `json:"code"`
+			}
+			json.Unmarshal(recorder.Body.Bytes(), &body)
+
+			assert.Equal(t, subTest.expectedStatusCode, recorder.Code, "Status code does not match with expected")
+			assert.Equal(t, subTest.expectedCode, body.Code, "Error code does not match with expected")
+			assert.Equal(t, subTest.expectedMessage, body.Error, "Error message does not match with expected")
+		})
+	}
+}
+
+func TestErrorWithFields(t *testing.T) {
+	var fields model.FieldErrors
+	fields.Add("name", model.FieldRequired)
+	fields.Add("email", model.FieldInvalidEmail)
+
+	subTests := []struct {
+		name             string
+		acceptLanguage   string
+		expectedLanguage string
+		expectedMessage  string
+		expectedFields   []model.FieldError
+	}{
+		{
+			name:             "Without a language",
+			expectedLanguage: "pt-BR",
+			expectedMessage:  "alguns campos não são válidos",
+			expectedFields: []model.FieldError{
+				{Field: "name", Code: model.FieldRequired, Message: "o campo nome é obrigatório"},
+				{Field: "email", Code: model.FieldInvalidEmail, Message: "o email inserido é inválido"},
+			},
+		},
+		{
+			name:             "Asking for English",
+			acceptLanguage:   "fr-FR, en-US;q=0.8, pt;q=0.5",
+			expectedLanguage: "en",
+			expectedMessage:  "some fields are not valid",
+			expectedFields: []model.FieldError{
+				{Field: "name", Code: model.FieldRequired, Message: "the name field is required"},
+				{Field: "email", Code: model.FieldInvalidEmail, Message: "the email is not valid"},
+			},
+		},
+		{
+			name:             "Asking for Portuguese first",
+			acceptLanguage:   "en;q=0.7, pt-PT",
+			expectedLanguage: "pt-BR",
+			expectedMessage:  "alguns campos não são válidos",
+			expectedFields: []model.FieldError{
+				{Field: "name", Code: model.FieldRequired, Message: "o campo nome é obrigatório"},
+				{Field: "email", Code: model.FieldInvalidEmail, Message: "o email inserido é inválido"},
+			},
+		},
+	}
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			request := httptest.NewRequest("POST", "/users", nil)
+			if subTest.acceptLanguage != "" {
+				request.Header.Set("Accept-Language", subTest.acceptLanguage)
+			}
+
+			recorder := httptest.NewRecorder()
+
+			response.Error(recorder, request, http.StatusBadRequest, fields.Err())
+
+			var body struct {
+				Error  string             `json:"error"`
+				Code   string             `json:"code"`
+				Errors []model.FieldError `json:"errors"`
+			}
+			json.Unmarshal(recorder.Body.Bytes(), &body)
+
+			assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code, "Status code does not match with expected")
+			assert.Equal(t, subTest.expectedLanguage, recorder.Header().Get("Content-Language"), "Language does not match with expected")
+			assert.Equal(t, model.CodeValidationFailed, body.Code, "Error code does not match with expected")
+			assert.Equal(t, subTest.expectedMessage, body.Error, "Error message does not match with expected")
+			assert.Equal(t, subTest.expectedFields, body.Errors, "Field errors do not match with expected")
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"github.com/waliqueiroz/devbook-api/interfaces"
 	"github.com/waliqueiroz/devbook-api/middleware"
 )
 
@@ -13,24 +14,31 @@ type Route struct {
 	Method       string
 	Function     func(http.ResponseWriter, *http.Request)
 	RequiresAuth bool
+	// AcceptsStreamToken lets an authenticated route take a stream token in the token query parameter
+	AcceptsStreamToken bool
 }
 
 // Generate will return a router with the configured routes
-func Generate(applicationRoutes []Route) *mux.Router {
+func Generate(applicationRoutes []Route, sessionRepository interfaces.SessionRepository) *mux.Router {
 	r := mux.NewRouter()
-	return config(r, applicationRoutes)
+	return config(r, applicationRoutes, sessionRepository)
 }
 
 // Config put all the routes inside router
-func config(r *mux.Router, applicationRoutes []Route) *mux.Router {
+func config(r *mux.Router, applicationRoutes []Route, sessionRepository interfaces.SessionRepository) *mux.Router {
+	authenticate := middleware.Authenticate(sessionRepository)
+	authenticateStream := middleware.AuthenticateStream(sessionRepository)
 
 	for _, route := range applicationRoutes {
+		handler := middleware.LimitBody(route.Function)
 
-		if route.RequiresAuth {
-			r.HandleFunc(route.URI, middleware.Logger(middleware.Authenticate(route.Function))).Methods(route.Method)
-		} else {
-			r.HandleFunc(route.URI, middleware.Logger(route.Function)).Methods(route.Method)
+		if route.RequiresAuth && route.AcceptsStreamToken {
+			handler = authenticateStream(handler)
+		} else if route.RequiresAuth {
+			handler = authenticate(handler)
 		}
+
+		r.HandleFunc(route.URI, middleware.RequestID(middleware.Logger(route.URI, middleware.Metrics(route.URI, handler)))).Methods(route.Method)
 	}
 
 	return r
@@ -15,5 +15,17 @@ func Auth(authController *controller.AuthController) []router.Route {
 			Function:     authController.Login,
 			RequiresAuth: false,
 		},
+		{
+			URI:          "/refresh",
+			Method:       http.MethodPost,
+			Function:     authController.Refresh,
+			RequiresAuth: false,
+		},
+		{
+			URI:          "/logout",
+			Method:       http.MethodPost,
+			Function:     authController.Logout,
+			RequiresAuth: true,
+		},
 	}
 }
@@ -0,0 +1,37 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/waliqueiroz/devbook-api/controller"
+	"github.com/waliqueiroz/devbook-api/router"
+)
+
+func Comment(commentController *controller.CommentController) []router.Route {
+	return []router.Route{
+		{
+			URI:          "/posts/{postID}/comments",
+			Method:       http.MethodGet,
+			Function:     commentController.Index,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/posts/{postID}/comments",
+			Method:       http.MethodPost,
+			Function:     commentController.Create,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/comments/{commentID}",
+			Method:       http.MethodPut,
+			Function:     commentController.Update,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/comments/{commentID}",
+			Method:       http.MethodDelete,
+			Function:     commentController.Delete,
+			RequiresAuth: true,
+		},
+	}
+}
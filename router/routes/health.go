@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/waliqueiroz/devbook-api/controller"
+	"github.com/waliqueiroz/devbook-api/router"
+)
+
+func Health(healthController *controller.HealthController) []router.Route {
+	return []router.Route{
+		{
+			URI:          "/healthz",
+			Method:       http.MethodGet,
+			Function:     healthController.Live,
+			RequiresAuth: false,
+		},
+		{
+			URI:          "/readyz",
+			Method:       http.MethodGet,
+			Function:     healthController.Ready,
+			RequiresAuth: false,
+		},
+	}
+}
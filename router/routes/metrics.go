@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/waliqueiroz/devbook-api/controller"
+	"github.com/waliqueiroz/devbook-api/router"
+)
+
+func Metrics(metricsController *controller.MetricsController) []router.Route {
+	return []router.Route{
+		{
+			URI:          "/metrics",
+			Method:       http.MethodGet,
+			Function:     metricsController.Metrics,
+			RequiresAuth: false,
+		},
+	}
+}
@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/waliqueiroz/devbook-api/controller"
+	"github.com/waliqueiroz/devbook-api/router"
+)
+
+func Notification(notificationController *controller.NotificationController) []router.Route {
+	return []router.Route{
+		{
+			URI:          "/notifications",
+			Method:       http.MethodGet,
+			Function:     notificationController.Index,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/notifications/read",
+			Method:       http.MethodPost,
+			Function:     notificationController.MarkAsRead,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/notifications/unread-count",
+			Method:       http.MethodGet,
+			Function:     notificationController.CountUnread,
+			RequiresAuth: true,
+		},
+	}
+}
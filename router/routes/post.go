@@ -21,6 +21,12 @@ func Post(postController *controller.PostController) []router.Route {
 			Function:     postController.Index,
 			RequiresAuth: true,
 		},
+		{
+			URI:          "/posts/search",
+			Method:       http.MethodGet,
+			Function:     postController.Search,
+			RequiresAuth: true,
+		},
 		{
 			URI:          "/posts/{postID}",
 			Method:       http.MethodGet,
@@ -33,6 +39,12 @@ func Post(postController *controller.PostController) []router.Route {
 			Function:     postController.Update,
 			RequiresAuth: true,
 		},
+		{
+			URI:          "/posts/{postID}",
+			Method:       http.MethodPatch,
+			Function:     postController.Patch,
+			RequiresAuth: true,
+		},
 		{
 			URI:          "/posts/{postID}",
 			Method:       http.MethodDelete,
@@ -57,5 +69,35 @@ func Post(postController *controller.PostController) []router.Route {
 			Function:     postController.DeslikePost,
 			RequiresAuth: true,
 		},
+		{
+			URI:          "/posts/{postID}/likes",
+			Method:       http.MethodGet,
+			Function:     postController.FindLikes,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/posts/{postID}/thread",
+			Method:       http.MethodGet,
+			Function:     postController.Thread,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/tags/trending",
+			Method:       http.MethodGet,
+			Function:     postController.TrendingTags,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/tags/{tag}/posts",
+			Method:       http.MethodGet,
+			Function:     postController.FindByTag,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/users/{userID}/mentions",
+			Method:       http.MethodGet,
+			Function:     postController.FindByMention,
+			RequiresAuth: true,
+		},
 	}
 }
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/waliqueiroz/devbook-api/controller"
+	"github.com/waliqueiroz/devbook-api/router"
+)
+
+func Stream(streamController *controller.StreamController) []router.Route {
+	return []router.Route{
+		{
+			URI:                "/stream",
+			Method:             http.MethodGet,
+			Function:           streamController.Stream,
+			RequiresAuth:       true,
+			AcceptsStreamToken: true,
+		},
+		{
+			URI:          "/stream/token",
+			Method:       http.MethodPost,
+			Function:     streamController.CreateToken,
+			RequiresAuth: true,
+		},
+	}
+}
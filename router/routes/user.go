@@ -21,6 +21,24 @@ func User(userController *controller.UserController) []router.Route {
 			Function:     userController.Index,
 			RequiresAuth: true,
 		},
+		{
+			URI:          "/users/availability",
+			Method:       http.MethodGet,
+			Function:     userController.Availability,
+			RequiresAuth: false,
+		},
+		{
+			URI:          "/users/verify",
+			Method:       http.MethodPost,
+			Function:     userController.Verify,
+			RequiresAuth: false,
+		},
+		{
+			URI:          "/users/verify/resend",
+			Method:       http.MethodPost,
+			Function:     userController.ResendVerification,
+			RequiresAuth: false,
+		},
 		{
 			URI:          "/users/{userID}",
 			Method:       http.MethodGet,
@@ -33,6 +51,12 @@ func User(userController *controller.UserController) []router.Route {
 			Function:     userController.Update,
 			RequiresAuth: true,
 		},
+		{
+			URI:          "/users/{userID}",
+			Method:       http.MethodPatch,
+			Function:     userController.Patch,
+			RequiresAuth: true,
+		},
 		{
 			URI:          "/users/{userID}",
 			Method:       http.MethodDelete,
@@ -1,6 +1,11 @@
 package security
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"golang.org/x/crypto/bcrypt"
+)
 
 // Hash takes a string and returns a hash of it
 func Hash(password string) ([]byte, error) {
@@ -11,3 +16,9 @@ func Hash(password string) ([]byte, error) {
 func Verify(hashedPassword string, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 }
+
+// HashToken returns the SHA-256 hex digest of a token, so it can be stored and looked up without keeping the token itself
+func HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,137 @@
+package stream
+
+import (
+	"fmt"
+	"sync"
+)
+
+// historySize is how many of the latest events are kept to be replayed to clients that reconnect
+const historySize = 256
+
+// subscriptionBuffer is how many events may wait for a slow client before new ones are dropped
+const subscriptionBuffer = 32
+
+// Types of event
+const (
+	EventPost         = "post"
+	EventNotification = "notification"
+)
+
+// Event represents something published to the clients listening to a topic
+type Event struct {
+	ID    uint64
+	Type  string
+	Data  interface{}
+	topic string
+}
+
+// Subscription receives the events published to a set of topics
+type Subscription struct {
+	Events chan Event
+	topics map[string]bool
+}
+
+// Hub delivers the events published in this process to the subscriptions listening to their topics
+type Hub struct {
+	mutex         sync.Mutex
+	lastID        uint64
+	history       []Event
+	subscriptions map[*Subscription]bool
+	closed        chan struct{}
+	closeOnce     sync.Once
+}
+
+// NewHub creates a new hub
+func NewHub() *Hub {
+	return &Hub{
+		subscriptions: make(map[*Subscription]bool),
+		closed:        make(chan struct{}),
+	}
+}
+
+// Close tells the clients listening to the hub that the server is shutting down, so they can end their streams
+func (hub *Hub) Close() {
+	hub.closeOnce.Do(func() {
+		close(hub.closed)
+	})
+}
+
+// Done returns a channel that is closed when the hub is closed
+func (hub *Hub) Done() <-chan struct{} {
+	return hub.closed
+}
+
+// UserTopic returns the topic of the events addressed to a user, like their notifications
+func UserTopic(userID uint64) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+// AuthorTopic returns the topic of the posts created by a user
+func AuthorTopic(userID uint64) string {
+	return fmt.Sprintf("author:%d", userID)
+}
+
+// Publish sends an event to the subscriptions listening to a topic. Subscriptions that are not
+// keeping up miss the event, and are expected to reconnect and ask for a replay
+func (hub *Hub) Publish(topic, eventType string, data interface{}) {
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+
+	hub.lastID++
+	event := Event{ID: hub.lastID, Type: eventType, Data: data, topic: topic}
+
+	hub.history = append(hub.history, event)
+	if len(hub.history) > historySize {
+		hub.history = hub.history[len(hub.history)-historySize:]
+	}
+
+	for subscription := range hub.subscriptions {
+		if !subscription.topics[topic] {
+			continue
+		}
+
+		select {
+		case subscription.Events <- event:
+		default:
+		}
+	}
+}
+
+// Subscribe listens to a set of topics. When a last event ID is given, the events published after
+// it that are still in the history are returned, so a client that reconnects does not miss anything
+func (hub *Hub) Subscribe(topics []string, lastEventID *uint64) (*Subscription, []Event) {
+	subscription := &Subscription{
+		Events: make(chan Event, subscriptionBuffer),
+		topics: make(map[string]bool),
+	}
+
+	for _, topic := range topics {
+		subscription.topics[topic] = true
+	}
+
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+
+	var missed []Event
+
+	// IDs above the last one were given by a previous run of the process, so there is nothing to replay
+	if lastEventID != nil && *lastEventID <= hub.lastID {
+		for _, event := range hub.history {
+			if event.ID > *lastEventID && subscription.topics[event.topic] {
+				missed = append(missed, event)
+			}
+		}
+	}
+
+	hub.subscriptions[subscription] = true
+
+	return subscription, missed
+}
+
+// Unsubscribe stops delivering events to a subscription
+func (hub *Hub) Unsubscribe(subscription *Subscription) {
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+
+	delete(hub.subscriptions, subscription)
+}
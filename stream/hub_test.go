@@ -0,0 +1,112 @@
+package stream_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/waliqueiroz/devbook-api/stream"
+)
+
+func TestPublish(t *testing.T) {
+	hub := stream.NewHub()
+
+	subscription, missed := hub.Subscribe([]string{stream.UserTopic(1), stream.AuthorTopic(2)}, nil)
+	defer hub.Unsubscribe(subscription)
+
+	assert.Empty(t, missed)
+
+	hub.Publish(stream.AuthorTopic(2), stream.EventPost, "post from a followed user")
+	hub.Publish(stream.AuthorTopic(3), stream.EventPost, "post from someone else")
+	hub.Publish(stream.UserTopic(1), stream.EventNotification, "notification")
+
+	event := <-subscription.Events
+	assert.Equal(t, uint64(1), event.ID)
+	assert.Equal(t, stream.EventPost, event.Type)
+	assert.Equal(t, "post from a followed user", event.Data)
+
+	event = <-subscription.Events
+	assert.Equal(t, uint64(3), event.ID)
+	assert.Equal(t, stream.EventNotification, event.Type)
+
+	assert.Len(t, subscription.Events, 0)
+}
+
+func TestSubscribeWithLastEventID(t *testing.T) {
+	hub := stream.NewHub()
+
+	hub.Publish(stream.UserTopic(1), stream.EventNotification, "first")
+	hub.Publish(stream.UserTopic(2), stream.EventNotification, "someone else's")
+	hub.Publish(stream.UserTopic(1), stream.EventNotification, "second")
+
+	subTests := []struct {
+		name           string
+		lastEventID    *uint64
+		expectedMissed []interface{}
+	}{
+		{
+			name: "Subscribe without a last event ID",
+		},
+		{
+			name:           "Subscribe with a last event ID",
+			lastEventID:    uint64Pointer(1),
+			expectedMissed: []interface{}{"second"},
+		},
+		{
+			name:           "Subscribe with the ID of the first event",
+			lastEventID:    uint64Pointer(0),
+			expectedMissed: []interface{}{"first", "second"},
+		},
+		{
+			name:        "Subscribe with an ID from a previous run",
+			lastEventID: uint64Pointer(100),
+		},
+	}
+
+	for _, subTest := range subTests {
+		t.Run(subTest.name, func(t *testing.T) {
+			subscription, missed := hub.Subscribe([]string{stream.UserTopic(1)}, subTest.lastEventID)
+			defer hub.Unsubscribe(subscription)
+
+			var missedData []interface{}
+			for _, event := range missed {
+				missedData = append(missedData, event.Data)
+			}
+
+			assert.Equal(t, subTest.expectedMissed, missedData)
+		})
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	hub := stream.NewHub()
+
+	subscription, _ := hub.Subscribe([]string{stream.UserTopic(1)}, nil)
+	hub.Unsubscribe(subscription)
+
+	hub.Publish(stream.UserTopic(1), stream.EventNotification, "notification")
+
+	assert.Len(t, subscription.Events, 0)
+}
+
+func uint64Pointer(value uint64) *uint64 {
+	return &value
+}
+
+func TestClose(t *testing.T) {
+	hub := stream.NewHub()
+
+	select {
+	case <-hub.Done():
+		t.Fatal("Hub should not be done before it is closed")
+	default:
+	}
+
+	hub.Close()
+	hub.Close()
+
+	select {
+	case <-hub.Done():
+	default:
+		t.Fatal("Hub should be done after it is closed")
+	}
+}
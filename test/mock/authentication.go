@@ -0,0 +1,22 @@
+package mock
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/waliqueiroz/devbook-api/authentication"
+)
+
+// Authenticate injects the identity of a given user in a request, as the authentication middleware would.
+// A zero user ID leaves the request unauthenticated
+func Authenticate(r *http.Request, userID uint64) *http.Request {
+	if userID == 0 {
+		return r
+	}
+
+	return r.WithContext(authentication.WithPrincipal(r.Context(), authentication.Principal{
+		UserID:    userID,
+		SessionID: 1,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}))
+}
@@ -0,0 +1,61 @@
+package mock
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/waliqueiroz/devbook-api/model"
+)
+
+type CommentRepositoryMock struct{}
+
+// NewCommentRepository creates a new comment repository
+func NewCommentRepository() *CommentRepositoryMock {
+	return &CommentRepositoryMock{}
+}
+
+// Create inserts a comment into database
+func (repository CommentRepositoryMock) Create(ctx context.Context, comment model.Comment) (model.Comment, error) {
+	return repository.getStoredComment()
+}
+
+// FindByID returns a comment that match with a given ID
+func (repository CommentRepositoryMock) FindByID(ctx context.Context, commentID uint64) (model.Comment, error) {
+	if commentID == UnknownID {
+		return model.Comment{}, model.NewNotFoundError("comment")
+	}
+
+	return repository.getStoredComment()
+}
+
+// FindByPost returns a page of comments of a given post
+func (repository CommentRepositoryMock) FindByPost(ctx context.Context, postID uint64, page model.PageRequest) (model.CommentPage, error) {
+	storedCommentListJson, _ := ioutil.ReadFile("../test/resource/json/stored_comment_list.json")
+
+	var storedCommentList []model.Comment
+
+	json.Unmarshal(storedCommentListJson, &storedCommentList)
+
+	return model.CommentPage{Data: storedCommentList}, nil
+}
+
+// Update updates a comment in database
+func (repository CommentRepositoryMock) Update(ctx context.Context, commentID uint64, comment model.Comment) error {
+	return nil
+}
+
+// Delete deletes a comment from database
+func (repository CommentRepositoryMock) Delete(ctx context.Context, commentID uint64) error {
+	return nil
+}
+
+func (repository CommentRepositoryMock) getStoredComment() (model.Comment, error) {
+	storedCommentJson, _ := ioutil.ReadFile("../test/resource/json/created_comment.json")
+
+	var storedComment model.Comment
+
+	json.Unmarshal(storedCommentJson, &storedComment)
+
+	return storedComment, nil
+}
@@ -0,0 +1,39 @@
+package mock
+
+import (
+	"context"
+	"database/sql"
+)
+
+type DatabaseMock struct {
+	PingError error
+}
+
+// NewDatabase creates a database mock whose ping fails with a given error, or succeeds if it is nil
+func NewDatabase(pingError error) *DatabaseMock {
+	return &DatabaseMock{pingError}
+}
+
+// PingContext checks the connection to the database
+func (database DatabaseMock) PingContext(ctx context.Context) error {
+	return database.PingError
+}
+
+// Stats returns the state of the connection pool
+func (database DatabaseMock) Stats() sql.DBStats {
+	return sql.DBStats{MaxOpenConnections: 10, OpenConnections: 2, InUse: 1, Idle: 1}
+}
+
+type SchemaCheckerMock struct {
+	CheckError error
+}
+
+// NewSchemaChecker creates a schema checker mock that fails with a given error, or succeeds if it is nil
+func NewSchemaChecker(checkError error) *SchemaCheckerMock {
+	return &SchemaCheckerMock{checkError}
+}
+
+// Check tells if the database schema matches the API
+func (checker SchemaCheckerMock) Check(ctx context.Context) error {
+	return checker.CheckError
+}
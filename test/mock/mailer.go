@@ -0,0 +1,36 @@
+package mock
+
+import (
+	"context"
+	"sync"
+
+	"github.com/waliqueiroz/devbook-api/model"
+)
+
+// MailerMock keeps the emails it is asked to send, so tests can check them
+type MailerMock struct {
+	mutex sync.Mutex
+	sent  []model.Email
+}
+
+// NewMailer creates a new mailer
+func NewMailer() *MailerMock {
+	return &MailerMock{}
+}
+
+// Send keeps an email instead of sending it
+func (mailer *MailerMock) Send(ctx context.Context, email model.Email) error {
+	mailer.mutex.Lock()
+	defer mailer.mutex.Unlock()
+
+	mailer.sent = append(mailer.sent, email)
+	return nil
+}
+
+// Sent returns the emails kept so far
+func (mailer *MailerMock) Sent() []model.Email {
+	mailer.mutex.Lock()
+	defer mailer.mutex.Unlock()
+
+	return append([]model.Email(nil), mailer.sent...)
+}
@@ -0,0 +1,52 @@
+package mock
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"time"
+
+	"github.com/waliqueiroz/devbook-api/model"
+)
+
+type NotificationRepositoryMock struct{}
+
+// NewNotificationRepository creates a new notification repository
+func NewNotificationRepository() *NotificationRepositoryMock {
+	return &NotificationRepositoryMock{}
+}
+
+// Create inserts a notification into database
+func (repository NotificationRepositoryMock) Create(ctx context.Context, notification model.Notification) (model.Notification, bool, error) {
+	notification.ID = 1
+	notification.ActorNick = "user2"
+	notification.CreatedAt = time.Date(2021, time.April, 6, 13, 34, 50, 0, time.UTC)
+
+	return notification, true, nil
+}
+
+// CreateMentions notifies the users with the given nicks that they were mentioned in a post
+func (repository NotificationRepositoryMock) CreateMentions(ctx context.Context, actorID, postID uint64, nicks []string) ([]model.Notification, error) {
+	return nil, nil
+}
+
+// FindByUser returns a page of notifications of a given user
+func (repository NotificationRepositoryMock) FindByUser(ctx context.Context, userID uint64, unreadOnly bool, page model.PageRequest) (model.NotificationPage, error) {
+	storedNotificationListJson, _ := ioutil.ReadFile("../test/resource/json/stored_notification_list.json")
+
+	var storedNotificationList []model.Notification
+
+	json.Unmarshal(storedNotificationListJson, &storedNotificationList)
+
+	return model.NotificationPage{Data: storedNotificationList}, nil
+}
+
+// MarkAsRead marks the given notifications of a user as read
+func (repository NotificationRepositoryMock) MarkAsRead(ctx context.Context, userID uint64, notificationIDs []uint64) error {
+	return nil
+}
+
+// CountUnread returns how many notifications a given user has not read yet
+func (repository NotificationRepositoryMock) CountUnread(ctx context.Context, userID uint64) (uint64, error) {
+	return 2, nil
+}
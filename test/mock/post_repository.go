@@ -1,12 +1,17 @@
 package mock
 
 import (
+	"context"
 	"encoding/json"
 	"io/ioutil"
+	"time"
 
 	"github.com/waliqueiroz/devbook-api/model"
 )
 
+// UnknownID is an ID that the repository mocks never find
+const UnknownID = 999
+
 type PostRepositoryMock struct{}
 
 // NewPostRepositoryMock creates a new post repository
@@ -15,53 +20,124 @@ func NewPostRepository() *PostRepositoryMock {
 }
 
 // Create inserts a post into database
-func (repository PostRepositoryMock) Create(post model.Post) (model.Post, error) {
+func (repository PostRepositoryMock) Create(ctx context.Context, post model.Post) (model.Post, error) {
 	return repository.getStoredPost()
 }
 
-// FindByID returns a post that match with a given ID
-func (repository PostRepositoryMock) FindByID(postID uint64) (model.Post, error) {
+// FindByID returns a post that match with a given ID, flagging if the given user liked it.
+// Like the MySQL repository, it fails once the context is done
+func (repository PostRepositoryMock) FindByID(ctx context.Context, postID, userID uint64) (model.Post, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Post{}, err
+	}
+
+	if postID == UnknownID {
+		return model.Post{}, model.NewNotFoundError("post")
+	}
+
 	return repository.getStoredPost()
 }
 
-// Index returns all posts by a user and from who they are following
-func (repository PostRepositoryMock) Index(userID uint64) ([]model.Post, error) {
-	return repository.getStoredPostList()
+// Index returns a page of posts by a user and from who they are following
+func (repository PostRepositoryMock) Index(ctx context.Context, userID uint64, page model.PageRequest) (model.PostPage, error) {
+	return repository.getStoredPostPage()
 }
 
 // Update updates a post in database
-func (repository PostRepositoryMock) Update(postID uint64, post model.Post) error {
+func (repository PostRepositoryMock) Update(ctx context.Context, postID uint64, post model.Post) error {
 	return nil
 }
 
 // Update deletes a post from database
-func (repository PostRepositoryMock) Delete(postID uint64) error {
+func (repository PostRepositoryMock) Delete(ctx context.Context, postID uint64) error {
 	return nil
 }
 
-// FindByUser returns all posts from a given user
-func (repository PostRepositoryMock) FindByUser(userID uint64) ([]model.Post, error) {
-	return repository.getStoredPostList()
+// FindByUser returns a page of posts from a given user, flagging the ones liked by the viewer
+func (repository PostRepositoryMock) FindByUser(ctx context.Context, userID, viewerID uint64, page model.PageRequest) (model.PostPage, error) {
+	if userID == UnknownID {
+		return model.PostPage{}, model.NewNotFoundError("user")
+	}
+
+	return repository.getStoredPostPage()
 }
 
-// LikePost increases the number of likes in a post
-func (repository PostRepositoryMock) LikePost(postID uint64) error {
+// LikePost registers that a given user liked a post
+func (repository PostRepositoryMock) LikePost(ctx context.Context, postID, userID uint64) error {
 	return nil
 }
 
-// DeslikePost decreases the number of likes in a post
-func (repository PostRepositoryMock) DeslikePost(postID uint64) error {
+// DeslikePost removes the like of a given user from a post
+func (repository PostRepositoryMock) DeslikePost(ctx context.Context, postID, userID uint64) error {
 	return nil
 }
 
-func (repository PostRepositoryMock) getStoredPostList() ([]model.Post, error) {
+// FindLikes returns a page of the users that liked a given post
+func (repository PostRepositoryMock) FindLikes(ctx context.Context, postID uint64, page model.PageRequest) (model.UserPage, error) {
+	storedUserlistJson, _ := ioutil.ReadFile("../test/resource/json/stored_user_list.json")
+
+	var storedUserList []model.User
+
+	json.Unmarshal(storedUserlistJson, &storedUserList)
+
+	return model.UserPage{Data: storedUserList}, nil
+}
+
+// Thread returns a post and all the replies below it
+func (repository PostRepositoryMock) Thread(ctx context.Context, postID, userID uint64) ([]model.Post, error) {
+	if postID == UnknownID {
+		return nil, model.NewNotFoundError("post")
+	}
+
+	storedThreadJson, _ := ioutil.ReadFile("../test/resource/json/stored_thread.json")
+
+	var storedThread []model.Post
+
+	json.Unmarshal(storedThreadJson, &storedThread)
+
+	return storedThread, nil
+}
+
+// Search returns a page of posts that match a full-text search
+func (repository PostRepositoryMock) Search(ctx context.Context, search model.PostSearch, viewerID uint64, page model.SearchPageRequest) (model.PostSearchPage, error) {
+	storedPostPage, _ := repository.getStoredPostPage()
+
+	var results []model.PostSearchResult
+
+	for _, post := range storedPostPage.Data {
+		results = append(results, model.PostSearchResult{Post: post, Score: 1, Snippet: search.Snippet(post.Content)})
+	}
+
+	return model.PostSearchPage{Data: results}, nil
+}
+
+// FindByTag returns a page of posts that use a given tag
+func (repository PostRepositoryMock) FindByTag(ctx context.Context, tag string, viewerID uint64, page model.PageRequest) (model.PostPage, error) {
+	return repository.getStoredPostPage()
+}
+
+// FindByMention returns a page of posts that mention a given user
+func (repository PostRepositoryMock) FindByMention(ctx context.Context, userID, viewerID uint64, page model.PageRequest) (model.PostPage, error) {
+	if userID == UnknownID {
+		return model.PostPage{}, model.NewNotFoundError("user")
+	}
+
+	return repository.getStoredPostPage()
+}
+
+// TrendingTags returns the tags most used by the posts created since a given time
+func (repository PostRepositoryMock) TrendingTags(ctx context.Context, since time.Time, limit uint64) ([]model.TrendingTag, error) {
+	return []model.TrendingTag{{Tag: "golang", Posts: 2}, {Tag: "devbook", Posts: 1}}, nil
+}
+
+func (repository PostRepositoryMock) getStoredPostPage() (model.PostPage, error) {
 	storedPostListJson, _ := ioutil.ReadFile("../test/resource/json/stored_post_list.json")
 
 	var storedPostList []model.Post
 
 	json.Unmarshal(storedPostListJson, &storedPostList)
 
-	return storedPostList, nil
+	return model.PostPage{Data: storedPostList}, nil
 }
 
 func (repository PostRepositoryMock) getStoredPost() (model.Post, error) {
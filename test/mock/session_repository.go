@@ -0,0 +1,58 @@
+package mock
+
+import (
+	"context"
+	"time"
+
+	"github.com/waliqueiroz/devbook-api/model"
+	"github.com/waliqueiroz/devbook-api/security"
+)
+
+// RefreshToken is the only refresh token that the session repository mock knows
+const RefreshToken = "refresh-token"
+
+type SessionRepositoryMock struct{}
+
+// NewSessionRepository creates a new session repository
+func NewSessionRepository() *SessionRepositoryMock {
+	return &SessionRepositoryMock{}
+}
+
+// Create inserts a session into database and returns its ID
+func (repository SessionRepositoryMock) Create(ctx context.Context, session model.Session) (uint64, error) {
+	return 1, nil
+}
+
+// FindByRefreshToken returns the active session that owns a given refresh token hash
+func (repository SessionRepositoryMock) FindByRefreshToken(ctx context.Context, refreshTokenHash string) (model.Session, error) {
+	if refreshTokenHash != security.HashToken(RefreshToken) {
+		return model.Session{}, model.NewNotFoundError("session")
+	}
+
+	return model.Session{
+		ID:               1,
+		UserID:           1,
+		RefreshTokenHash: refreshTokenHash,
+		ExpiresAt:        time.Now().Add(time.Hour),
+	}, nil
+}
+
+// Rotate replaces the refresh token of a session
+func (repository SessionRepositoryMock) Rotate(ctx context.Context, sessionID uint64, currentHash, newHash string, expiresAt time.Time) error {
+	return nil
+}
+
+// Revoke revokes a session
+func (repository SessionRepositoryMock) Revoke(ctx context.Context, sessionID uint64) error {
+	return nil
+}
+
+// RevokeAllByUser revokes every session of a given user
+func (repository SessionRepositoryMock) RevokeAllByUser(ctx context.Context, userID uint64) error {
+	return nil
+}
+
+// IsActive checks if a session was neither revoked nor expired. Only the session with UnknownID is not
+func (repository SessionRepositoryMock) IsActive(ctx context.Context, sessionID uint64) (bool, error) {
+	return sessionID != UnknownID, nil
+}
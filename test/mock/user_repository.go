@@ -1,82 +1,131 @@
 package mock
 
 import (
+	"context"
 	"encoding/json"
 	"io/ioutil"
+	"strings"
+	"time"
 
 	"github.com/waliqueiroz/devbook-api/model"
 )
 
-type UserRepositoryMock struct{}
+// UnverifiedEmail is the email of the only user that the user repository mock has not verified
+const UnverifiedEmail = "unverified@mail.com"
+
+type UserRepositoryMock struct {
+	updated *model.User
+}
 
 // NewUserRepository creates a new user repository
 func NewUserRepository() *UserRepositoryMock {
-	return &UserRepositoryMock{}
+	return &UserRepositoryMock{updated: &model.User{}}
 }
 
 // Create inserts a user into database
-func (repository UserRepositoryMock) Create(user model.User) (model.User, error) {
+func (repository UserRepositoryMock) Create(ctx context.Context, user model.User) (model.User, error) {
 	return repository.getStoredUser()
 }
 
-// FindByNameOrNick returns all users that name or nick match with the argument
-func (repository UserRepositoryMock) FindByNameOrNick(nameOrNick string) ([]model.User, error) {
-	return repository.getStoredUserList()
+// FindByNameOrNick returns a page of users that name or nick match with the argument
+func (repository UserRepositoryMock) FindByNameOrNick(ctx context.Context, nameOrNick string, page model.PageRequest) (model.UserPage, error) {
+	return repository.getStoredUserPage()
 }
 
-// FindByID returns a user thar match with a given ID
-func (repository UserRepositoryMock) FindByID(userID uint64) (model.User, error) {
+// FindByID returns a user that match with a given ID. Like the MySQL repository, it fails once the context is done
+func (repository UserRepositoryMock) FindByID(ctx context.Context, userID uint64) (model.User, error) {
+	if err := ctx.Err(); err != nil {
+		return model.User{}, err
+	}
+
+	if userID == UnknownID {
+		return model.User{}, model.NewNotFoundError("user")
+	}
+
 	return repository.getStoredUser()
 }
 
-// Update updates a user in database
-func (repository UserRepositoryMock) Update(userID uint64, user model.User) error {
+// Update keeps the user it is asked to update. Like the MySQL repository, the stored user is verified
+// and changing the email makes it unverified
+func (repository UserRepositoryMock) Update(ctx context.Context, userID uint64, user model.User) error {
+	storedUser, _ := repository.getStoredUser()
+
+	user.VerifiedAt = nil
+	if strings.EqualFold(user.Email, storedUser.Email) {
+		verifiedAt := time.Date(2021, time.April, 8, 14, 40, 0, 0, time.UTC)
+		user.VerifiedAt = &verifiedAt
+	}
+
+	*repository.updated = user
+
 	return nil
 }
 
+// Updated returns the last user updated
+func (repository UserRepositoryMock) Updated() model.User {
+	return *repository.updated
+}
+
 // Delete deletes a user in database
-func (repository UserRepositoryMock) Delete(userID uint64) error {
+func (repository UserRepositoryMock) Delete(ctx context.Context, userID uint64) error {
 	return nil
 }
 
-// FindByEmail returns all users that email match with the argument
-func (repository UserRepositoryMock) FindByEmail(email string) (model.User, error) {
+// FindByEmail returns the stored user for any email. Only UnverifiedEmail belongs to a user that did not verify it
+func (repository UserRepositoryMock) FindByEmail(ctx context.Context, email string) (model.User, error) {
 	storedUserJson, _ := ioutil.ReadFile("../test/resource/json/stored_user.json")
 
 	var storedUser model.User
 
 	json.Unmarshal(storedUserJson, &storedUser)
 
+	if email != UnverifiedEmail {
+		verifiedAt := time.Date(2021, time.April, 8, 14, 40, 0, 0, time.UTC)
+		storedUser.VerifiedAt = &verifiedAt
+	}
+
 	return storedUser, nil
 }
 
+// Taken tells if a nick and an email are used by the stored user
+func (repository UserRepositoryMock) Taken(ctx context.Context, nick, email string) (bool, bool, error) {
+	storedUser, _ := repository.getStoredUser()
+
+	return nick == storedUser.Nick, email == storedUser.Email, nil
+}
+
 // Follow allows a user to follow another
-func (repository UserRepositoryMock) Follow(userID, followerID uint64) error {
+func (repository UserRepositoryMock) Follow(ctx context.Context, userID, followerID uint64) error {
 	return nil
 }
 
 // Unfollow allows a user to unfollow another
-func (repository UserRepositoryMock) Unfollow(userID, followerID uint64) error {
+func (repository UserRepositoryMock) Unfollow(ctx context.Context, userID, followerID uint64) error {
 	return nil
 }
 
-// SearchFollowers returns a list of followers for a given user
-func (repository UserRepositoryMock) SearchFollowers(userID uint64) ([]model.User, error) {
-	return repository.getStoredUserList()
+// SearchFollowers returns a page of followers for a given user
+func (repository UserRepositoryMock) SearchFollowers(ctx context.Context, userID uint64, page model.PageRequest) (model.UserPage, error) {
+	return repository.getStoredUserPage()
+}
+
+// SearchFollowing returns a page of users that a given user is following
+func (repository UserRepositoryMock) SearchFollowing(ctx context.Context, userID uint64, page model.PageRequest) (model.UserPage, error) {
+	return repository.getStoredUserPage()
 }
 
-// SearchFollowing returns a list of users that a given user is following
-func (repository UserRepositoryMock) SearchFollowing(userID uint64) ([]model.User, error) {
-	return repository.getStoredUserList()
+// FollowingIDs returns the IDs of all the users that a given user is following
+func (repository UserRepositoryMock) FollowingIDs(ctx context.Context, userID uint64) ([]uint64, error) {
+	return []uint64{2}, nil
 }
 
 // FindPassword returns the hashed password of a given user
-func (repository UserRepositoryMock) FindPassword(userID uint64) (string, error) {
+func (repository UserRepositoryMock) FindPassword(ctx context.Context, userID uint64) (string, error) {
 	return "$2a$10$finFsyhIR/7UK/8nKmlUu.kdN.Vw3AaHBHBMZlp1HiP3J2JpMgkI6", nil
 }
 
 // UpdatePassword updates the password for a given user
-func (repository UserRepositoryMock) UpdatePassword(userID uint64, password string) error {
+func (repository UserRepositoryMock) UpdatePassword(ctx context.Context, userID uint64, password string) error {
 	return nil
 }
 
@@ -90,12 +139,12 @@ func (repository UserRepositoryMock) getStoredUser() (model.User, error) {
 	return storedUser, nil
 }
 
-func (repository UserRepositoryMock) getStoredUserList() ([]model.User, error) {
+func (repository UserRepositoryMock) getStoredUserPage() (model.UserPage, error) {
 	storedUserlistJson, _ := ioutil.ReadFile("../test/resource/json/stored_user_list.json")
 
 	var storedUserList []model.User
 
 	json.Unmarshal(storedUserlistJson, &storedUserList)
 
-	return storedUserList, nil
+	return model.UserPage{Data: storedUserList}, nil
 }
@@ -0,0 +1,37 @@
+package mock
+
+import (
+	"context"
+	"time"
+
+	"github.com/waliqueiroz/devbook-api/model"
+)
+
+type VerificationRepositoryMock struct {
+	created map[uint64]bool
+}
+
+// NewVerificationRepository creates a new email verification repository
+func NewVerificationRepository() *VerificationRepositoryMock {
+	return &VerificationRepositoryMock{created: make(map[uint64]bool)}
+}
+
+// Create stores a verification token of a user. With a cooldown, it refuses to store a second token for the same user
+func (repository VerificationRepositoryMock) Create(ctx context.Context, verification model.Verification, cooldown time.Duration) (bool, error) {
+	if cooldown > 0 && repository.created[verification.UserID] {
+		return false, nil
+	}
+
+	repository.created[verification.UserID] = true
+
+	return true, nil
+}
+
+// Consume uses up the verification token of a user. Tokens of UnknownID are treated as already used
+func (repository VerificationRepositoryMock) Consume(ctx context.Context, userID uint64, tokenHash string) error {
+	if userID == UnknownID {
+		return model.NewNotFoundError("verification")
+	}
+
+	return nil
+}